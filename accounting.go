@@ -0,0 +1,227 @@
+package gtm
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// BANDWIDTH_SAVE_INTERVAL is how often the accounting file is written. At most this
+	// much traffic is lost if gtm is killed
+	BANDWIDTH_SAVE_INTERVAL = time.Minute
+	// BANDWIDTH_KEEP_DAYS is how long daily totals are kept. Monthly totals are kept
+	// forever, since there are only 12 per year
+	BANDWIDTH_KEEP_DAYS = 93
+	// BANDWIDTH_FILE_NAME is the name of the accounting file in the user config dir
+	BANDWIDTH_FILE_NAME = "bandwidth.json"
+)
+
+// BandwidthPeriod is the length of an accounting period
+type BandwidthPeriod string
+
+const (
+	BandwidthDaily   BandwidthPeriod = "daily"
+	BandwidthMonthly BandwidthPeriod = "monthly"
+)
+
+// BandwidthUsage is the traffic of one interface during one period. Period is the local
+// date ("2026-01-31") for daily totals and the month ("2026-01") for monthly totals
+type BandwidthUsage struct {
+	Interface string `json:"interface"`
+	Period    string `json:"period"`
+	BytesSent uint64 `json:"bytes_sent"`
+	BytesRecv uint64 `json:"bytes_recv"`
+}
+
+// bandwidthLedger is the content of the accounting file: period -> interface -> usage
+type bandwidthLedger struct {
+	Daily   map[string]map[string]*BandwidthUsage `json:"daily"`
+	Monthly map[string]map[string]*BandwidthUsage `json:"monthly"`
+}
+
+var (
+	ledger         bandwidthLedger
+	ledgerLoadOnce sync.Once
+	ledgerMut      sync.Mutex
+	ledgerSaved    time.Time
+	ledgerDirty    bool
+)
+
+// GetBandwidthUsage returns the totals of every interface for the period containing
+// `t`, ie. GetBandwidthUsage(BandwidthMonthly, time.Now()) for this month so far
+func GetBandwidthUsage(period BandwidthPeriod, t time.Time) []BandwidthUsage {
+	loadBandwidthLedger()
+	ledgerMut.Lock()
+	defer ledgerMut.Unlock()
+
+	var usages map[string]*BandwidthUsage
+	if period == BandwidthMonthly {
+		usages = ledger.Monthly[monthKey(t)]
+	} else {
+		usages = ledger.Daily[dayKey(t)]
+	}
+	result := make([]BandwidthUsage, 0, len(usages))
+	for _, usage := range usages {
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Interface < result[j].Interface })
+	return result
+}
+
+// GetBandwidthHistory returns every stored total of an interface for a period kind,
+// oldest first
+func GetBandwidthHistory(period BandwidthPeriod, iface string) []BandwidthUsage {
+	loadBandwidthLedger()
+	ledgerMut.Lock()
+	defer ledgerMut.Unlock()
+
+	periods := ledger.Daily
+	if period == BandwidthMonthly {
+		periods = ledger.Monthly
+	}
+	var result []BandwidthUsage
+	for _, usages := range periods {
+		if usage, ok := usages[iface]; ok {
+			result = append(result, *usage)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Period < result[j].Period })
+	return result
+}
+
+// SaveBandwidthAccounting writes the accounting file right away, ie. before exiting
+func SaveBandwidthAccounting() error {
+	loadBandwidthLedger()
+	ledgerMut.Lock()
+	defer ledgerMut.Unlock()
+	return saveBandwidthLedger()
+}
+
+// accountBandwidth adds the traffic between two network fetches to today's and this
+// month's totals
+func accountBandwidth(timestamp time.Time, iface string, sent uint64, recv uint64) {
+	if sent == 0 && recv == 0 {
+		return
+	}
+	loadBandwidthLedger()
+	ledgerMut.Lock()
+	defer ledgerMut.Unlock()
+
+	addUsage(ledger.Daily, dayKey(timestamp), iface, sent, recv)
+	addUsage(ledger.Monthly, monthKey(timestamp), iface, sent, recv)
+	ledgerDirty = true
+
+	if GetClock().Since(ledgerSaved) >= BANDWIDTH_SAVE_INTERVAL {
+		if err := saveBandwidthLedger(); err != nil {
+			collectorError(CollectorNetwork, "Failed to save bandwidth accounting!", err)
+		}
+	}
+}
+
+func addUsage(periods map[string]map[string]*BandwidthUsage, period string, iface string,
+	sent uint64, recv uint64) {
+
+	usages, ok := periods[period]
+	if !ok {
+		usages = map[string]*BandwidthUsage{}
+		periods[period] = usages
+	}
+	usage, ok := usages[iface]
+	if !ok {
+		usage = &BandwidthUsage{Interface: iface, Period: period}
+		usages[iface] = usage
+	}
+	usage.BytesSent += sent
+	usage.BytesRecv += recv
+}
+
+func dayKey(t time.Time) string   { return t.Local().Format("2006-01-02") }
+func monthKey(t time.Time) string { return t.Local().Format("2006-01") }
+
+// bandwidthFilePath returns BANDWIDTH_FILE from the config, or the accounting file in
+// the user config dir (ie. ~/.config/gtm/bandwidth.json)
+func bandwidthFilePath() string {
+	if Cfg.BandwidthFile != "" {
+		return Cfg.BandwidthFile
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+	return filepath.Join(dir, "gtm", BANDWIDTH_FILE_NAME)
+}
+
+func loadBandwidthLedger() {
+	ledgerLoadOnce.Do(func() {
+		ledgerMut.Lock()
+		defer ledgerMut.Unlock()
+
+		ledger = bandwidthLedger{
+			Daily:   map[string]map[string]*BandwidthUsage{},
+			Monthly: map[string]map[string]*BandwidthUsage{},
+		}
+		ledgerSaved = GetClock().Now()
+
+		path := bandwidthFilePath()
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return
+		} else if err != nil {
+			logger().Error("Failed to read bandwidth accounting from " + path + " ! " +
+				err.Error())
+			return
+		}
+		var loaded bandwidthLedger
+		if err = json.Unmarshal(data, &loaded); err != nil {
+			logger().Error("Failed to parse bandwidth accounting from " + path + " ! " +
+				err.Error())
+			return
+		}
+		if loaded.Daily != nil {
+			ledger.Daily = loaded.Daily
+		}
+		if loaded.Monthly != nil {
+			ledger.Monthly = loaded.Monthly
+		}
+	})
+}
+
+// saveBandwidthLedger is always called with ledgerMut locked. The file is written to a
+// temporary file first, so a crash while saving can't corrupt the totals
+func saveBandwidthLedger() error {
+	ledgerSaved = GetClock().Now()
+	if !ledgerDirty {
+		return nil
+	}
+
+	cutoff := dayKey(GetClock().Now().AddDate(0, 0, -BANDWIDTH_KEEP_DAYS))
+	for day := range ledger.Daily {
+		if day < cutoff {
+			delete(ledger.Daily, day)
+		}
+	}
+
+	data, err := json.Marshal(ledger)
+	if err != nil {
+		return err
+	}
+	path := bandwidthFilePath()
+	if err = os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		return err
+	}
+	ledgerDirty = false
+	return nil
+}
@@ -0,0 +1,41 @@
+package gtm
+
+import (
+	"errors"
+	"slices"
+)
+
+// GetProcessAffinity returns the logical CPUs (numbered from 0, like CPUStats) a process
+// may run on, in ascending order
+func GetProcessAffinity(pid int32) ([]int, error) {
+	if pid <= 0 {
+		return nil, &ProcessError{PID: pid, Op: "get affinity", Err: errors.New("invalid PID")}
+	}
+	cpus, err := getProcessAffinity(pid)
+	if err != nil {
+		return nil, &ProcessError{PID: pid, Op: "get affinity", Err: err}
+	}
+	slices.Sort(cpus)
+	return cpus, nil
+}
+
+// SetProcessAffinity pins a process to the logical CPUs in `cpus`. On Windows, only the
+// first 64 CPUs (the first processor group) can be pinned to
+func SetProcessAffinity(pid int32, cpus []int) error {
+	if pid <= 0 {
+		return &ProcessError{PID: pid, Op: "set affinity", Err: errors.New("invalid PID")}
+	}
+	if len(cpus) == 0 {
+		return &ProcessError{PID: pid, Op: "set affinity", Err: errors.New("no CPUs given")}
+	}
+	for _, cpu := range cpus {
+		if cpu < 0 {
+			return &ProcessError{PID: pid, Op: "set affinity",
+				Err: errors.New("invalid CPU number")}
+		}
+	}
+	if err := setProcessAffinity(pid, cpus); err != nil {
+		return &ProcessError{PID: pid, Op: "set affinity", Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package gtm
+
+import (
+	"errors"
+	"golang.org/x/sys/unix"
+)
+
+// getProcessAffinity reads the affinity of the main thread. Threads can be pinned on
+// their own, but taskset and htop show (and set) the main thread as well
+func getProcessAffinity(pid int32) ([]int, error) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(int(pid), &set); err != nil {
+		return nil, affinityError(err)
+	}
+	cpus := []int{}
+	for cpu := 0; cpu < len(set)*64; cpu++ {
+		if set.IsSet(cpu) {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+func setProcessAffinity(pid int32, cpus []int) error {
+	var set unix.CPUSet
+	for _, cpu := range cpus {
+		if cpu >= len(set)*64 {
+			return errors.New("invalid CPU number")
+		}
+		set.Set(cpu)
+	}
+	return affinityError(unix.SchedSetaffinity(int(pid), &set))
+}
+
+func affinityError(err error) error {
+	if errors.Is(err, unix.ESRCH) {
+		return ErrProcessNotFound
+	}
+	return err
+}
@@ -0,0 +1,12 @@
+//go:build !linux && !windows
+
+package gtm
+
+// macOS only has affinity tags (hints, not pinning), so affinity is linux & Windows only
+func getProcessAffinity(pid int32) ([]int, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func setProcessAffinity(pid int32, cpus []int) error {
+	return ErrUnsupportedPlatform
+}
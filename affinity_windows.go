@@ -0,0 +1,55 @@
+package gtm
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"unsafe"
+)
+
+var (
+	procGetProcessAffinityMask = kernel32.NewProc("GetProcessAffinityMask")
+	procSetProcessAffinityMask = kernel32.NewProc("SetProcessAffinityMask")
+)
+
+func getProcessAffinity(pid int32) ([]int, error) {
+	handle, err := openProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, pid)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseHandle(handle)
+
+	var processMask, systemMask uintptr
+	ok, _, err := procGetProcessAffinityMask.Call(uintptr(handle),
+		uintptr(unsafe.Pointer(&processMask)), uintptr(unsafe.Pointer(&systemMask)))
+	if ok == 0 {
+		return nil, err
+	}
+	cpus := []int{}
+	for cpu := 0; cpu < int(unsafe.Sizeof(processMask))*8; cpu++ {
+		if processMask&(1<<cpu) != 0 {
+			cpus = append(cpus, cpu)
+		}
+	}
+	return cpus, nil
+}
+
+func setProcessAffinity(pid int32, cpus []int) error {
+	var mask uintptr
+	for _, cpu := range cpus {
+		if cpu >= int(unsafe.Sizeof(mask))*8 {
+			return errors.New("invalid CPU number")
+		}
+		mask |= 1 << cpu
+	}
+	handle, err := openProcess(windows.PROCESS_SET_INFORMATION|
+		windows.PROCESS_QUERY_LIMITED_INFORMATION, pid)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+
+	if ok, _, err := procSetProcessAffinityMask.Call(uintptr(handle), mask); ok == 0 {
+		return err
+	}
+	return nil
+}
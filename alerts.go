@@ -0,0 +1,64 @@
+package gtm
+
+import (
+	"strconv"
+	"sync"
+)
+
+// GPU_TEMPERATURE_CRITICAL is the temperature (°C) above which a GPU raises a critical
+// alert. Most GPUs start throttling around it
+const GPU_TEMPERATURE_CRITICAL = 85
+
+var (
+	// alerting holds the keys of the alerts that fired and haven't cleared yet
+	alerting    = map[string]bool{}
+	alertingMut sync.Mutex
+)
+
+// checkCriticalAlert annotates a critical alert when the condition of `key` starts
+// being `critical`. It fires once until the condition clears, so a GPU staying hot
+// doesn't raise an alert on every fetch
+func checkCriticalAlert(key string, critical bool, text string, metrics ...string) {
+	alertingMut.Lock()
+	fired := critical && !alerting[key]
+	if critical {
+		alerting[key] = true
+	} else {
+		delete(alerting, key)
+	}
+	alertingMut.Unlock()
+
+	if fired {
+		Annotate(Annotation{Kind: AnnotationAlert, Severity: SeverityCritical,
+			Source: "gtm", Text: text, Metrics: metrics})
+	}
+}
+
+// checkGPUAlerts raises a critical alert for every GPU above GPU_TEMPERATURE_CRITICAL
+func checkGPUAlerts(stats []GPUStats) {
+	for _, gpu := range stats {
+		id := strconv.Itoa(int(gpu.Id))
+		checkCriticalAlert("gpu."+id+".temperature",
+			gpu.Temperature > GPU_TEMPERATURE_CRITICAL,
+			"GPU "+id+" temperature > "+strconv.Itoa(GPU_TEMPERATURE_CRITICAL)+"°C",
+			GPUMetric(gpu.Id, MetricGPUTemperature))
+	}
+}
+
+// checkSensorAlerts raises a critical alert for every temperature sensor at or above
+// the critical threshold of its chip
+func checkSensorAlerts(sensors []Sensor) {
+	for _, sensor := range sensors {
+		if sensor.Kind != SensorTemperature || sensor.Critical <= 0 {
+			continue
+		}
+		name := sensor.Label
+		if sensor.Chip != "" {
+			name = sensor.Chip + " " + sensor.Label
+		}
+		checkCriticalAlert("sensor."+sensor.Chip+"."+sensor.Label,
+			sensor.Value >= sensor.Critical,
+			name+" temperature >= "+strconv.FormatFloat(sensor.Critical, 'f', -1, 64)+
+				UnitCelsius)
+	}
+}
@@ -0,0 +1,65 @@
+package gtm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Aliases are friendly names for devices, ie. "eth0" -> "10G uplink". They are read from
+// DISK_ALIASES, GPU_ALIASES and NET_ALIASES in the config as comma separated
+// `name=alias` pairs:
+//
+//	DISK_ALIASES="/mnt/scratch=Scratch NVMe, /dev/sdb=Backup"
+//	GPU_ALIASES="0=Render GPU"
+//	NET_ALIASES="eth0=10G uplink"
+//
+// Disks are matched by mountpoint or device, GPUs by their card id, and network
+// interfaces by name. The alias is added to the stats next to the original name, so it
+// is an extra label rather than a replacement
+type Aliases map[string]string
+
+// parseAliases parses `name=alias` pairs. Names are matched case sensitively, since
+// both mountpoints and interface names are case sensitive on linux
+func parseAliases(key string, value string) Aliases {
+	aliases := Aliases{}
+	for _, item := range parseList(value) {
+		name, alias, ok := strings.Cut(item, "=")
+		name, alias = strings.TrimSpace(name), strings.TrimSpace(alias)
+		if !ok || name == "" || alias == "" {
+			logger().Error("Failed to parse alias: " + key + " ... ignoring: " + item)
+			continue
+		}
+		aliases[name] = alias
+	}
+	return aliases
+}
+
+// Lookup returns the alias of the first name that has one
+func (a Aliases) Lookup(names ...string) string {
+	for _, name := range names {
+		if alias, ok := a[name]; ok {
+			return alias
+		}
+	}
+	return ""
+}
+
+func diskAlias(mountpoint string, device string) string {
+	return Cfg.DiskAliases.Lookup(mountpoint, device)
+}
+
+func gpuAlias(id int32) string {
+	return Cfg.GPUAliases.Lookup(strconv.FormatInt(int64(id), 10))
+}
+
+func netAlias(name string) string {
+	return Cfg.NetAliases.Lookup(name)
+}
+
+// displayName returns the alias when there is one, otherwise the original name
+func displayName(name string, alias string) string {
+	if alias != "" {
+		return alias
+	}
+	return name
+}
@@ -0,0 +1,133 @@
+package gtm
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// ANNOTATION_HISTORY_CAPACITY is the number of annotations kept. The oldest annotation
+// is dropped when a new one is added to a full history
+const ANNOTATION_HISTORY_CAPACITY = 1024
+
+// AnnotationKind describes what happened at the time of an annotation
+type AnnotationKind string
+
+const (
+	AnnotationAlert        AnnotationKind = "alert"
+	AnnotationBenchmark    AnnotationKind = "benchmark"
+	AnnotationConfigReload AnnotationKind = "config_reload"
+	AnnotationNote         AnnotationKind = "note"
+)
+
+// Severity is how urgent an annotation is. Only alerts usually have one
+type Severity string
+
+const (
+	SeverityInfo     Severity = "info"
+	SeverityWarning  Severity = "warning"
+	SeverityCritical Severity = "critical"
+)
+
+// Annotation marks a point (or a span, when End is set) on the history timeline, so a
+// graph can explain its own spikes, ie. "alert fired: GPU temperature > 85" or "started
+// benchmark". Metrics are the metric names (see GetMetricHistoryNames) it applies to;
+// an annotation without metrics applies to every metric
+type Annotation struct {
+	Timestamp time.Time      `json:"timestamp"`
+	End       *time.Time     `json:"end,omitempty"`
+	Kind      AnnotationKind `json:"kind"`
+	Severity  Severity       `json:"severity,omitempty"`
+	// Source is whoever added the annotation, ie. "ui" or the name of a sink
+	Source  string   `json:"source,omitempty"`
+	Text    string   `json:"text"`
+	Metrics []string `json:"metrics,omitempty"`
+}
+
+var (
+	annotations      []Annotation
+	annotationEvents = NewBroadcaster[Annotation]()
+	annotationMut    sync.Mutex
+)
+
+// Annotate adds an annotation to the history timeline and publishes it to
+// WatchAnnotations subscribers. A zero Timestamp is set to now, and an empty Kind to
+// AnnotationNote
+func Annotate(annotation Annotation) Annotation {
+	if annotation.Timestamp.IsZero() {
+		annotation.Timestamp = GetClock().Now()
+	}
+	if annotation.Kind == "" {
+		annotation.Kind = AnnotationNote
+	}
+	annotation.Metrics = slices.Clone(annotation.Metrics)
+
+	annotationMut.Lock()
+	// ringbuffer only holds scalar values, so annotations are kept in a capped slice
+	if len(annotations) >= ANNOTATION_HISTORY_CAPACITY {
+		annotations = slices.Delete(annotations, 0, 1)
+	}
+	annotations = append(annotations, annotation)
+	annotationMut.Unlock()
+
+	logger().Debug("Annotation (" + string(annotation.Kind) + "): " + annotation.Text)
+	annotationEvents.Publish(annotation)
+	return annotation
+}
+
+// WatchAnnotations subscribes to new annotations. Call the returned function to
+// unsubscribe
+func WatchAnnotations() (*Subscriber[Annotation], func()) {
+	return annotationEvents.Subscribe(0, 0)
+}
+
+// GetAnnotations returns the annotations newer than `window` (every stored annotation
+// when 0 or less) that apply to any of `metrics`, oldest first. Without metrics every
+// annotation is returned. A span is included while any part of it is inside the window
+func GetAnnotations(window time.Duration, metrics ...string) []Annotation {
+	annotationMut.Lock()
+	defer annotationMut.Unlock()
+
+	var cutoff time.Time
+	if window > 0 {
+		cutoff = GetClock().Now().Add(-window)
+	}
+	var result []Annotation
+	for _, annotation := range annotations {
+		end := annotation.Timestamp
+		if annotation.End != nil {
+			end = *annotation.End
+		}
+		if end.Before(cutoff) {
+			continue
+		}
+		if len(metrics) > 0 && !annotation.AppliesToAny(metrics) {
+			continue
+		}
+		result = append(result, annotation)
+	}
+	slices.SortStableFunc(result, func(a, b Annotation) int {
+		return a.Timestamp.Compare(b.Timestamp)
+	})
+	return result
+}
+
+// IsCritical reports whether the annotation is a critical alert
+func (a Annotation) IsCritical() bool {
+	return a.Kind == AnnotationAlert && a.Severity == SeverityCritical
+}
+
+// AppliesTo reports whether the annotation applies to a metric
+func (a Annotation) AppliesTo(metric string) bool {
+	return len(a.Metrics) == 0 || slices.Contains(a.Metrics, metric)
+}
+
+// AppliesToAny reports whether the annotation applies to any of `metrics`
+func (a Annotation) AppliesToAny(metrics []string) bool {
+	for _, metric := range metrics {
+		if a.AppliesTo(metric) {
+			return true
+		}
+	}
+	return false
+}
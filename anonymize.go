@@ -0,0 +1,79 @@
+package gtm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+)
+
+// AnonymizeIdentifier returns a stable, non-reversible stand-in for `value` such as
+// "host-3f7a9c01d2e4". The same value (and salt) always produces the same identifier,
+// so anonymized exports can still be correlated with each other. Set ANONYMIZE_SALT in
+// the config to keep identifiers from being brute forced from a list of common names
+func AnonymizeIdentifier(kind string, value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(Cfg.AnonymizeSalt + kind + ":" + value))
+	return kind + "-" + hex.EncodeToString(sum[:6])
+}
+
+// AnonymizeUsername anonymizes a username when ANONYMIZE is enabled in the config,
+// otherwise it is returned unchanged
+func AnonymizeUsername(username string) string {
+	if !Cfg.Anonymize {
+		return username
+	}
+	return AnonymizeIdentifier("user", username)
+}
+
+// displayHostname applies the hostname override, then anonymization, to the hostname
+// reported by the OS
+func displayHostname(hostname string) string {
+	if Cfg.HostnameOverride != "" {
+		return Cfg.HostnameOverride
+	}
+	if Cfg.Anonymize {
+		return AnonymizeIdentifier("host", hostname)
+	}
+	return hostname
+}
+
+// ExportHostInfo returns a copy of the host info that is safe to share. The hostname is
+// overridden and/or anonymized like GetHostname(), and the host ID (which is unique per
+// machine) is anonymized when ANONYMIZE is enabled
+func ExportHostInfo() (HostInfo, error) {
+	info, err := GetHostInfo()
+	return exportHostInfo(info), err
+}
+
+// exportHostInfo makes a copy of the host info safe to share, see ExportHostInfo. Every
+// export of the host info (snapshots, the REST API, ...) goes through it
+func exportHostInfo(info HostInfo) HostInfo {
+	info.Hostname = displayHostname(info.Hostname)
+	if Cfg.Anonymize {
+		info.HostID = AnonymizeIdentifier("hostid", info.HostID)
+	}
+	return info
+}
+
+// ExportProcesses returns a copy of the processes that is safe to share. Their users are
+// anonymized when ANONYMIZE is enabled, see AnonymizeUsername
+func ExportProcesses() ([]ProcStats, error) {
+	procs, err := GetProcesses()
+	return exportProcesses(procs), err
+}
+
+// exportProcesses makes a copy of the processes safe to share, see ExportProcesses.
+// Every export of the processes (snapshots, the REST API, ...) goes through it
+func exportProcesses(procs []ProcStats) []ProcStats {
+	if !Cfg.Anonymize || procs == nil {
+		return procs
+	}
+	// the processes are shared with the cache, so they're copied before anonymizing
+	exported := slices.Clone(procs)
+	for i := range exported {
+		exported[i].User = AnonymizeUsername(exported[i].User)
+	}
+	return exported
+}
@@ -0,0 +1,116 @@
+package gtm
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+// API_PATH is where the REST API serves the stats, ie. /api/v1/cpu
+const API_PATH = "/api/v1/"
+
+// apiEndpoint is an endpoint of the REST API under API_PATH. `response` is the type of
+// the JSON it returns, for the paths of OpenAPISchema
+type apiEndpoint struct {
+	name        string
+	operationID string
+	summary     string
+	response    any
+	get         func(m *Monitor) (any, error)
+}
+
+var apiEndpoints = []apiEndpoint{
+	{"cpu", "getCPU", "The CPU usage", CPUStats{},
+		func(m *Monitor) (any, error) { return m.CPUStats() }},
+	{"memory", "getMemory", "The memory usage", MemoryStats{},
+		func(m *Monitor) (any, error) {
+			stats, err := m.MemoryStats()
+			if stats == nil {
+				return nil, err
+			}
+			return newMemoryStats(stats), err
+		}},
+	{"disks", "getDisks", "The partitions and their usage", []DiskStats{},
+		func(m *Monitor) (any, error) { return nilIfEmpty(m.DisksStats()) }},
+	{"network", "getNetwork", "The network interfaces and their rates", []NetStats{},
+		func(m *Monitor) (any, error) { return nilIfEmpty(m.NetworkStats()) }},
+	{"gpu", "getGPU", "The stats of every GPU", []GPUStats{},
+		func(m *Monitor) (any, error) { return nilIfEmpty(m.GPUStats()) }},
+	{"host", "getHost", "The host info", HostInfo{},
+		func(m *Monitor) (any, error) {
+			info, err := m.HostInfo()
+			return exportHostInfo(info), err
+		}},
+	// processes aren't per Monitor, see Snapshot
+	{"processes", "getProcesses", "The running processes", []ProcStats{},
+		func(m *Monitor) (any, error) { return nilIfEmpty(ExportProcesses()) }},
+	{"snapshot", "getSnapshot", "The stats of every subsystem", Snapshot{},
+		func(m *Monitor) (any, error) { return m.Snapshot(), nil }},
+}
+
+// APIHandler serves the stats of the Monitor as JSON, so other tools and scripts can
+// query a running gtm:
+//
+//	curl http://host:port/api/v1/cpu
+//
+// The endpoints are cpu, memory, disks, network, gpu, host, processes and snapshot
+// under API_PATH, and only answer GET. They return the same structs as the Get
+// functions, with the host and the users of the processes anonymized like GetSnapshot
+// and REDACTION_PROFILE applied like captures. A disabled collector returns 404, a
+// collector that failed without cached stats returns 503. OpenAPISchema describes
+// every endpoint
+func (m *Monitor) APIHandler() http.Handler {
+	mux := http.NewServeMux()
+	for _, endpoint := range apiEndpoints {
+		name := endpoint.name
+		mux.HandleFunc("GET "+API_PATH+name, func(w http.ResponseWriter, r *http.Request) {
+			stats, err := endpoint.get(m)
+			if errors.Is(err, ErrCollectorDisabled) || errors.Is(err, ErrNoGPU) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			} else if err != nil && stats == nil {
+				http.Error(w, err.Error(), http.StatusServiceUnavailable)
+				return
+			} else if err != nil {
+				// the stats of the last successful fetch are still served
+				m.log().Debug("Serving cached " + name + " stats, the fetch failed! " +
+					err.Error())
+			}
+
+			data, err := GetConfiguredRedactionProfile().Redact(stats)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(data)
+		})
+	}
+
+	return mux
+}
+
+// APIHandler serves the stats of the default Monitor, see Monitor.APIHandler
+func APIHandler() http.Handler { return defaultMonitor.APIHandler() }
+
+// StartAPIServer serves the REST API of the default Monitor on `addr`, along with its
+// live updates at WEBSOCKET_PATH (see WebSocketHandler), until the server fails, or
+// returns nil once the default Monitor is shut down
+func StartAPIServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(API_PATH, APIHandler())
+	mux.Handle(WEBSOCKET_PATH, WebSocketHandler())
+	logger().Info("Serving the REST API on http://" + addr + API_PATH + " and ws://" +
+		addr + WEBSOCKET_PATH)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return defaultMonitor.serve(server)
+}
+
+// nilIfEmpty returns nil stats when a fetch failed without any cached stats, so the
+// handler can tell them apart from stats that are empty (ie. no disks)
+func nilIfEmpty[T any](stats []T, err error) (any, error) {
+	if stats == nil && err != nil {
+		return nil, err
+	}
+	return stats, err
+}
@@ -0,0 +1,134 @@
+package gtm
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// BATTERY_UPDATE_INTERVAL is how often batteries are read. Their charge changes slowly
+const BATTERY_UPDATE_INTERVAL = 5 * time.Second
+
+// BatteryState is whether a battery is charging
+type BatteryState string
+
+const (
+	BatteryCharging    BatteryState = "charging"
+	BatteryDischarging BatteryState = "discharging"
+	BatteryFull        BatteryState = "full"
+	// BatteryNotCharging is on AC but not charging, ie. held below 100% to save the
+	// battery
+	BatteryNotCharging BatteryState = "not_charging"
+	BatteryUnknown     BatteryState = "unknown"
+)
+
+// BatteryStats is a single battery. TimeRemaining is the time until empty while
+// discharging and until full while charging. HealthPercent is the full charge capacity
+// relative to the design capacity. Watts is the rate the battery (dis)charges at. Every
+// field the OS doesn't report is 0, ie. health, cycles and watts on Windows
+type BatteryStats struct {
+	Name          string        `json:"name"`
+	Percent       float64       `json:"percent"`
+	State         BatteryState  `json:"state"`
+	TimeRemaining time.Duration `json:"time_remaining"`
+	HealthPercent float64       `json:"health_percent"`
+	CycleCount    int           `json:"cycle_count"`
+	Watts         float64       `json:"watts"`
+	Voltage       float64       `json:"voltage"`
+}
+
+// ACState is whether the machine runs on an AC adapter
+type ACState string
+
+const (
+	ACOnline  ACState = "online"
+	ACOffline ACState = "offline"
+	ACUnknown ACState = "unknown"
+)
+
+// LidState is whether the lid of a laptop is open
+type LidState string
+
+const (
+	LidOpen    LidState = "open"
+	LidClosed  LidState = "closed"
+	LidUnknown LidState = "unknown"
+)
+
+// PowerState is whether the machine runs on AC or battery, and whether its lid is open.
+// Machines without batteries are always on AC. The lid is unknown on machines without
+// one, and on Windows, which only notifies about lid changes
+type PowerState struct {
+	AC  ACState  `json:"ac"`
+	Lid LidState `json:"lid"`
+}
+
+var (
+	batteryStats     []BatteryStats
+	lastFetchBattery time.Time
+	batteryMut       sync.Mutex
+
+	powerState          PowerState
+	lastFetchPowerState time.Time
+	powerStateMut       sync.Mutex
+)
+
+// GetBatteryStats returns every battery, sorted by name. It's empty on machines
+// without batteries
+func GetBatteryStats() ([]BatteryStats, error) {
+	batteryMut.Lock()
+	defer batteryMut.Unlock()
+
+	if GetClock().Since(lastFetchBattery) < BATTERY_UPDATE_INTERVAL && batteryStats != nil {
+		return batteryStats, nil
+	}
+	if !IsCollectorEnabled(CollectorBattery) {
+		return nil, ErrCollectorDisabled
+	}
+	lastFetchBattery = GetClock().Now()
+
+	batteries, err := readBatteries()
+	if err != nil {
+		collectorError(CollectorBattery, "Failed to retrieve the batteries!", err)
+		return batteryStats, err
+	}
+	for i := range batteries {
+		batteries[i].Percent = RoundStat(batteries[i].Percent)
+		batteries[i].HealthPercent = RoundStat(batteries[i].HealthPercent)
+		batteries[i].Watts = RoundStat(batteries[i].Watts)
+		batteries[i].Voltage = RoundStat(batteries[i].Voltage)
+		batteries[i].TimeRemaining = batteries[i].TimeRemaining.Truncate(time.Minute)
+	}
+	sort.Slice(batteries, func(i, j int) bool { return batteries[i].Name < batteries[j].Name })
+	batteryStats = batteries
+	return batteryStats, nil
+}
+
+// GetPowerState returns whether the machine runs on AC or battery, and whether its lid is
+// open, ie. to poll less often while on battery
+func GetPowerState() (PowerState, error) {
+	powerStateMut.Lock()
+	defer powerStateMut.Unlock()
+
+	if GetClock().Since(lastFetchPowerState) < BATTERY_UPDATE_INTERVAL &&
+		powerState.AC != "" {
+		return powerState, nil
+	}
+	if !IsCollectorEnabled(CollectorBattery) {
+		return PowerState{AC: ACUnknown, Lid: LidUnknown}, ErrCollectorDisabled
+	}
+	lastFetchPowerState = GetClock().Now()
+
+	state, err := readPowerState()
+	if err != nil {
+		collectorError(CollectorBattery, "Failed to retrieve the power state!", err)
+		return PowerState{AC: ACUnknown, Lid: LidUnknown}, err
+	}
+	powerState = state
+	return powerState, nil
+}
+
+// OnBattery reports whether the machine runs on battery
+func (p PowerState) OnBattery() bool {
+	return p.AC == ACOffline
+}
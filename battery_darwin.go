@@ -0,0 +1,98 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readBatteries reads the AppleSmartBattery registry entry, the same data IOKit's
+// IOPowerSources API returns
+func readBatteries() ([]BatteryStats, error) {
+	out, err := runCommand("ioreg", "-r", "-c", "AppleSmartBattery")
+	if err != nil {
+		return nil, err
+	}
+	if len(bytes.TrimSpace(out)) == 0 {
+		return []BatteryStats{}, nil
+	}
+	return []BatteryStats{parseAppleSmartBattery(out)}, nil
+}
+
+// parseAppleSmartBattery parses the `"Key" = Value` lines of ioreg. Capacities are in
+// mAh, the voltage in mV and the amperage in mA (negative while discharging).
+// TimeRemaining is in minutes, 65535 while it's still being estimated
+func parseAppleSmartBattery(out []byte) BatteryStats {
+	values := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), " = ")
+		if ok {
+			values[strings.Trim(key, `"`)] = value
+		}
+	}
+	number := func(key string) float64 {
+		value, _ := strconv.ParseFloat(values[key], 64)
+		if value > 1<<63 {
+			// negative values are printed as unsigned 64 bit integers
+			value -= 1 << 64
+		}
+		return value
+	}
+
+	battery := BatteryStats{Name: "InternalBattery-0", State: BatteryUnknown,
+		CycleCount: int(number("CycleCount")), Voltage: number("Voltage") / 1000}
+	// MaxCapacity is a percentage on Apple silicon, so the raw capacities are preferred
+	current, full := number("AppleRawCurrentCapacity"), number("AppleRawMaxCapacity")
+	if full == 0 {
+		current, full = number("CurrentCapacity"), number("MaxCapacity")
+	}
+	if full > 0 {
+		battery.Percent = current / full * 100
+	}
+	if design := number("DesignCapacity"); design > 0 && full > 0 {
+		battery.HealthPercent = full / design * 100
+	}
+	amperage := number("Amperage")
+	battery.Watts = max(amperage, -amperage) / 1000 * battery.Voltage
+
+	switch {
+	case values["FullyCharged"] == "Yes":
+		battery.State = BatteryFull
+	case values["IsCharging"] == "Yes":
+		battery.State = BatteryCharging
+	case values["ExternalConnected"] == "Yes":
+		battery.State = BatteryNotCharging
+	case values["ExternalConnected"] == "No":
+		battery.State = BatteryDischarging
+	}
+	if minutes := number("TimeRemaining"); minutes > 0 && minutes < 65535 {
+		battery.TimeRemaining = time.Duration(minutes) * time.Minute
+	}
+	return battery
+}
+
+// readPowerState reads whether a charger is connected to the battery, and the clamshell
+// state of laptops. Macs without a battery are always on AC
+func readPowerState() (PowerState, error) {
+	state := PowerState{AC: ACOnline, Lid: LidUnknown}
+	out, err := runCommand("ioreg", "-r", "-c", "AppleSmartBattery")
+	if err != nil {
+		return PowerState{AC: ACUnknown, Lid: LidUnknown}, err
+	}
+	if bytes.Contains(out, []byte(`"ExternalConnected" = No`)) {
+		state.AC = ACOffline
+	}
+
+	out, err = runCommand("ioreg", "-r", "-k", "AppleClamshellState", "-d", "1")
+	switch {
+	case err != nil:
+	case bytes.Contains(out, []byte(`"AppleClamshellState" = Yes`)):
+		state.Lid = LidClosed
+	case bytes.Contains(out, []byte(`"AppleClamshellState" = No`)):
+		state.Lid = LidOpen
+	}
+	return state, nil
+}
@@ -0,0 +1,145 @@
+package gtm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// POWER_SUPPLY_PATH is where linux exposes batteries and AC adapters
+const POWER_SUPPLY_PATH = "/sys/class/power_supply"
+
+// LID_PATH holds the ACPI lid buttons, ie. LID0/state
+const LID_PATH = "/proc/acpi/button/lid"
+
+// readBatteries reads every power supply of type "Battery". Peripherals (ie. the
+// battery of a wireless mouse) have scope "Device" and are skipped
+func readBatteries() ([]BatteryStats, error) {
+	supplies, err := os.ReadDir(POWER_SUPPLY_PATH)
+	if os.IsNotExist(err) {
+		return []BatteryStats{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	batteries := []BatteryStats{}
+	for _, supply := range supplies {
+		dir := filepath.Join(POWER_SUPPLY_PATH, supply.Name())
+		if readSysfsString(filepath.Join(dir, "type")) != "Battery" ||
+			readSysfsString(filepath.Join(dir, "scope")) == "Device" {
+			continue
+		}
+		batteries = append(batteries, readBattery(dir, supply.Name()))
+	}
+	return batteries, nil
+}
+
+// readBattery reads a battery that reports either energy (µWh) and power (µW), or
+// charge (µAh) and current (µA)
+func readBattery(dir string, name string) BatteryStats {
+	read := func(attribute string) float64 {
+		value, _ := strconv.ParseFloat(readSysfsString(filepath.Join(dir, attribute)), 64)
+		return value
+	}
+	battery := BatteryStats{Name: name, Percent: read("capacity"), State: BatteryUnknown,
+		CycleCount: int(read("cycle_count")), Voltage: read("voltage_now") / 1e6}
+	switch strings.ToLower(readSysfsString(filepath.Join(dir, "status"))) {
+	case "charging":
+		battery.State = BatteryCharging
+	case "discharging":
+		battery.State = BatteryDischarging
+	case "full":
+		battery.State = BatteryFull
+	case "not charging":
+		battery.State = BatteryNotCharging
+	}
+
+	now, full, design, rate := read("energy_now"), read("energy_full"),
+		read("energy_full_design"), read("power_now")
+	if full == 0 {
+		// charge in µAh and current in µA, so the rate is in µA instead of µW
+		now, full, design, rate = read("charge_now"), read("charge_full"),
+			read("charge_full_design"), read("current_now")
+		battery.Watts = rate * battery.Voltage / 1e6
+	} else {
+		battery.Watts = rate / 1e6
+	}
+	// some drivers report a negative rate while discharging
+	rate = max(rate, -rate)
+	battery.Watts = max(battery.Watts, -battery.Watts)
+
+	if design > 0 && full > 0 {
+		battery.HealthPercent = full / design * 100
+	}
+	if battery.Percent == 0 && full > 0 {
+		battery.Percent = now / full * 100
+	}
+	if rate > 0 {
+		switch battery.State {
+		case BatteryDischarging:
+			battery.TimeRemaining = time.Duration(now / rate * float64(time.Hour))
+		case BatteryCharging:
+			battery.TimeRemaining = time.Duration(max(full-now, 0) / rate *
+				float64(time.Hour))
+		}
+	}
+	return battery
+}
+
+// readPowerState reads the AC adapters ("Mains", and "USB" for USB-C chargers) and the
+// ACPI lid. Without an AC adapter, the machine is on AC unless a battery discharges
+func readPowerState() (PowerState, error) {
+	state := PowerState{AC: ACUnknown, Lid: LidUnknown}
+	supplies, err := os.ReadDir(POWER_SUPPLY_PATH)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return state, err
+	}
+	hasAdapter, discharging := false, false
+	for _, supply := range supplies {
+		dir := filepath.Join(POWER_SUPPLY_PATH, supply.Name())
+		if readSysfsString(filepath.Join(dir, "scope")) == "Device" {
+			continue
+		}
+		switch readSysfsString(filepath.Join(dir, "type")) {
+		case "Mains", "USB":
+			online := readSysfsString(filepath.Join(dir, "online"))
+			if online == "" {
+				continue
+			}
+			hasAdapter = true
+			if online == "1" {
+				state.AC = ACOnline
+			} else if state.AC != ACOnline {
+				state.AC = ACOffline
+			}
+		case "Battery":
+			status := readSysfsString(filepath.Join(dir, "status"))
+			discharging = discharging || strings.EqualFold(status, "discharging")
+		}
+	}
+	if !hasAdapter {
+		state.AC = ACOnline
+		if discharging {
+			state.AC = ACOffline
+		}
+	}
+
+	lids, _ := os.ReadDir(LID_PATH)
+	for _, lid := range lids {
+		// ie. "state:      open"
+		data, err := os.ReadFile(filepath.Join(LID_PATH, lid.Name(), "state"))
+		fields := strings.Fields(string(data))
+		if err != nil || len(fields) == 0 {
+			continue
+		}
+		switch fields[len(fields)-1] {
+		case "open":
+			state.Lid = LidOpen
+		case "closed":
+			state.Lid = LidClosed
+		}
+	}
+	return state, nil
+}
@@ -0,0 +1,11 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+func readBatteries() ([]BatteryStats, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func readPowerState() (PowerState, error) {
+	return PowerState{}, ErrUnsupportedPlatform
+}
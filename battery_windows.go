@@ -0,0 +1,87 @@
+package gtm
+
+import (
+	"time"
+	"unsafe"
+)
+
+var procGetSystemPowerStatus = kernel32.NewProc("GetSystemPowerStatus")
+
+// systemPowerStatus is SYSTEM_POWER_STATUS
+type systemPowerStatus struct {
+	ACLineStatus        byte
+	BatteryFlag         byte
+	BatteryLifePercent  byte
+	SystemStatusFlag    byte
+	BatteryLifeTime     uint32
+	BatteryFullLifeTime uint32
+}
+
+// Values of systemPowerStatus
+const (
+	AC_LINE_OFFLINE           = 0
+	AC_LINE_ONLINE            = 1
+	BATTERY_FLAG_CHARGING     = 8
+	BATTERY_FLAG_NO_BATTERY   = 128
+	BATTERY_FLAG_UNKNOWN      = 255
+	BATTERY_PERCENT_UNKNOWN   = 255
+	BATTERY_LIFE_TIME_UNKNOWN = 0xFFFFFFFF
+)
+
+// readBatteries reads GetSystemPowerStatus, which sums up every battery as one. Health,
+// cycles and watts need the battery device IOCTLs, and are left at 0
+func readBatteries() ([]BatteryStats, error) {
+	status, err := getSystemPowerStatus()
+	if err != nil {
+		return nil, err
+	}
+	if status.BatteryFlag&BATTERY_FLAG_NO_BATTERY != 0 ||
+		status.BatteryFlag == BATTERY_FLAG_UNKNOWN {
+		return []BatteryStats{}, nil
+	}
+
+	battery := BatteryStats{Name: "Battery", State: BatteryDischarging}
+	if status.BatteryLifePercent != BATTERY_PERCENT_UNKNOWN {
+		battery.Percent = float64(status.BatteryLifePercent)
+	}
+	switch {
+	case status.BatteryFlag&BATTERY_FLAG_CHARGING != 0:
+		battery.State = BatteryCharging
+	case status.ACLineStatus == AC_LINE_ONLINE && battery.Percent >= 100:
+		battery.State = BatteryFull
+	case status.ACLineStatus == AC_LINE_ONLINE:
+		battery.State = BatteryNotCharging
+	}
+	if battery.State == BatteryDischarging &&
+		status.BatteryLifeTime != BATTERY_LIFE_TIME_UNKNOWN {
+		battery.TimeRemaining = time.Duration(status.BatteryLifeTime) * time.Second
+	}
+	return []BatteryStats{battery}, nil
+}
+
+// readPowerState reads the AC line status. The lid is unknown, since Windows only
+// notifies about lid changes (GUID_LIDSWITCH_STATE_CHANGE) to windows and services
+func readPowerState() (PowerState, error) {
+	status, err := getSystemPowerStatus()
+	if err != nil {
+		return PowerState{}, err
+	}
+	state := PowerState{AC: ACUnknown, Lid: LidUnknown}
+	switch {
+	case status.ACLineStatus == AC_LINE_ONLINE,
+		status.BatteryFlag&BATTERY_FLAG_NO_BATTERY != 0:
+		state.AC = ACOnline
+	case status.ACLineStatus == AC_LINE_OFFLINE:
+		state.AC = ACOffline
+	}
+	return state, nil
+}
+
+func getSystemPowerStatus() (systemPowerStatus, error) {
+	var status systemPowerStatus
+	ok, _, err := procGetSystemPowerStatus.Call(uintptr(unsafe.Pointer(&status)))
+	if ok == 0 {
+		return status, err
+	}
+	return status, nil
+}
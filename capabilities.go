@@ -0,0 +1,215 @@
+package gtm
+
+import (
+	"errors"
+	"log/slog"
+	"sync"
+)
+
+// Collector names a group of stats that gtm fetches on an interval
+type Collector string
+
+const (
+	CollectorBattery        Collector = "battery"
+	CollectorCPU            Collector = "cpu"
+	CollectorDisk           Collector = "disk"
+	CollectorDiskIO         Collector = "disk_io"
+	CollectorGPU            Collector = "gpu"
+	CollectorHost           Collector = "host"
+	CollectorMemory         Collector = "memory"
+	CollectorNetwork        Collector = "network"
+	CollectorPower          Collector = "power"
+	CollectorProcesses      Collector = "processes"
+	CollectorProcessNetwork Collector = "process_network"
+	CollectorSMART          Collector = "smart"
+	CollectorSensors        Collector = "sensors"
+	CollectorTimeSync       Collector = "time_sync"
+	CollectorUPS            Collector = "ups"
+	CollectorWiFi           Collector = "wifi"
+)
+
+// MAX_REMEMBERED_ERRORS is the number of distinct errors remembered per collector to
+// only log each of them once
+const MAX_REMEMBERED_ERRORS = 64
+
+var allCollectors = []Collector{
+	CollectorBattery,
+	CollectorCPU,
+	CollectorDisk,
+	CollectorDiskIO,
+	CollectorGPU,
+	CollectorHost,
+	CollectorMemory,
+	CollectorNetwork,
+	CollectorPower,
+	CollectorProcesses,
+	CollectorProcessNetwork,
+	CollectorSMART,
+	CollectorSensors,
+	CollectorTimeSync,
+	CollectorUPS,
+	CollectorWiFi,
+}
+
+type CapabilityStatus int
+
+const (
+	// CapabilityAvailable means the collector is expected to work normally
+	CapabilityAvailable CapabilityStatus = iota
+	// CapabilityDegraded means the collector runs, but some data is missing or partial
+	CapabilityDegraded
+	// CapabilityDisabled means the collector is skipped entirely
+	CapabilityDisabled
+)
+
+func (s CapabilityStatus) String() string {
+	switch s {
+	case CapabilityAvailable:
+		return "available"
+	case CapabilityDegraded:
+		return "degraded"
+	case CapabilityDisabled:
+		return "disabled"
+	default:
+		return "unknown"
+	}
+}
+
+type Capability struct {
+	Collector Collector        `json:"collector"`
+	Status    CapabilityStatus `json:"status"`
+	Reason    string           `json:"reason,omitempty"`
+}
+
+// Environment describes restrictions placed on the gtm process by the operating system
+type Environment struct {
+	// Sandbox is the confinement gtm runs in: "snap", "flatpak", "app-sandbox" or ""
+	Sandbox string `json:"sandbox,omitempty"`
+	// SELinuxEnforcing is true when SELinux is enforcing AND gtm runs in a confined domain
+	SELinuxEnforcing bool `json:"selinux_enforcing"`
+	// IsElevated is true when running as root (unix) or as an elevated admin (windows)
+	IsElevated bool `json:"is_elevated"`
+}
+
+var (
+	environment   Environment
+	capabilities  = map[Collector]Capability{}
+	collectorErrs = map[Collector]map[string]struct{}{}
+	capabilityMut sync.RWMutex
+	detectEnvOnce sync.Once
+)
+
+// DetectEnvironment probes for sandboxes, SELinux and missing privileges, then disables
+// or degrades the collectors that cannot work in this environment. It only probes once;
+// later calls return the cached result
+func DetectEnvironment() Environment {
+	detectEnvOnce.Do(func() {
+		env, restricted := detectPlatformEnvironment()
+
+		capabilityMut.Lock()
+		environment = env
+		for _, c := range allCollectors {
+			capabilities[c] = Capability{Collector: c, Status: CapabilityAvailable}
+		}
+		for _, c := range restricted {
+			if current := capabilities[c.Collector]; c.Status > current.Status {
+				capabilities[c.Collector] = c
+			}
+		}
+		capabilityMut.Unlock()
+
+		logger().Info("Detected environment", "sandbox", env.Sandbox,
+			"selinuxEnforcing", env.SELinuxEnforcing, "isElevated", env.IsElevated)
+		for _, c := range GetCapabilities() {
+			if c.Status != CapabilityAvailable {
+				logger().Warn("Collector " + string(c.Collector) + " is " + c.Status.String() +
+					": " + c.Reason)
+			}
+		}
+	})
+	return GetEnvironment()
+}
+
+// GetEnvironment returns the environment found by DetectEnvironment
+func GetEnvironment() Environment {
+	capabilityMut.RLock()
+	defer capabilityMut.RUnlock()
+	return environment
+}
+
+// GetCapabilities returns the status of every collector
+func GetCapabilities() []Capability {
+	capabilityMut.RLock()
+	defer capabilityMut.RUnlock()
+
+	result := make([]Capability, 0, len(allCollectors))
+	for _, c := range allCollectors {
+		if capability, ok := capabilities[c]; ok {
+			result = append(result, capability)
+		} else {
+			result = append(result, Capability{Collector: c, Status: CapabilityAvailable})
+		}
+	}
+	return result
+}
+
+// IsCollectorEnabled returns false only when the collector has been disabled
+func IsCollectorEnabled(c Collector) bool {
+	capabilityMut.RLock()
+	defer capabilityMut.RUnlock()
+	return capabilities[c].Status != CapabilityDisabled
+}
+
+func setCapability(c Collector, status CapabilityStatus, reason string) {
+	capabilityMut.Lock()
+	defer capabilityMut.Unlock()
+	if capabilities[c].Status >= status {
+		return
+	}
+	capabilities[c] = Capability{Collector: c, Status: status, Reason: reason}
+	logger().Warn("Collector " + string(c) + " is now " + status.String() + ": " + reason)
+}
+
+// collectorError logs a failed fetch. The first occurrence of an error is logged at the
+// ERROR level, and identical repeats are logged at DEBUG so a collector that fails every
+// interval (or a single mountpoint out of many) doesn't flood the log. Transient errors
+// (see IsTransient) are logged at WARN. Permission errors degrade the collector, and
+// errors of platforms that aren't supported disable it
+func collectorError(c Collector, msg string, err error) {
+	logCollectorError(logger(), c, msg, err)
+}
+
+// logCollectorError is collectorError logging to `logger`
+func logCollectorError(logger *slog.Logger, c Collector, msg string, err error) {
+	text := msg
+	if err != nil {
+		text += " " + err.Error()
+	}
+
+	capabilityMut.Lock()
+	seen, ok := collectorErrs[c]
+	if !ok || len(seen) >= MAX_REMEMBERED_ERRORS {
+		// forget old errors once in a while, so the log shows they are still happening
+		seen = map[string]struct{}{}
+		collectorErrs[c] = seen
+	}
+	_, repeated := seen[text]
+	seen[text] = struct{}{}
+	capabilityMut.Unlock()
+
+	switch {
+	case repeated:
+		logger.Debug(text)
+	case IsTransient(err):
+		logger.Warn(text)
+	default:
+		logger.Error(text)
+	}
+
+	switch {
+	case errors.Is(err, ErrUnsupportedPlatform):
+		setCapability(c, CapabilityDisabled, err.Error())
+	case errors.Is(err, ErrPermissionDenied):
+		setCapability(c, CapabilityDegraded, "permission denied: "+err.Error())
+	}
+}
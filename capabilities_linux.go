@@ -0,0 +1,73 @@
+package gtm
+
+import (
+	"os"
+	"strings"
+)
+
+func detectPlatformEnvironment() (env Environment, restricted []Capability) {
+	env.IsElevated = os.Geteuid() == 0
+
+	switch {
+	case os.Getenv("SNAP") != "":
+		env.Sandbox = "snap"
+		// Strict confinement only exposes host data through connected interfaces (plugs)
+		plugs := []struct {
+			plug      string
+			collector Collector
+		}{
+			{"mount-observe", CollectorDisk},
+			{"hardware-observe", CollectorGPU},
+			{"network-observe", CollectorNetwork},
+			{"system-observe", CollectorProcesses},
+		}
+		for _, p := range plugs {
+			if _, err := runCommand("snapctl", "is-connected", p.plug); err != nil {
+				restricted = append(restricted, Capability{
+					Collector: p.collector,
+					Status:    CapabilityDegraded,
+					Reason:    "snap interface `" + p.plug + "` is not connected",
+				})
+			}
+		}
+	case os.Getenv("FLATPAK_ID") != "" || fileExists("/.flatpak-info"):
+		env.Sandbox = "flatpak"
+		restricted = append(restricted,
+			Capability{
+				Collector: CollectorGPU,
+				Status:    CapabilityDisabled,
+				Reason:    "host GPU utilities (nvidia-smi/rocm-smi) are not visible in a flatpak",
+			},
+			Capability{
+				Collector: CollectorProcesses,
+				Status:    CapabilityDegraded,
+				Reason:    "only processes inside the flatpak sandbox are visible",
+			},
+			Capability{
+				Collector: CollectorDisk,
+				Status:    CapabilityDegraded,
+				Reason:    "only mountpoints exposed to the flatpak sandbox are visible",
+			})
+	}
+
+	// SELinux only restricts gtm when enforcing AND running in a confined domain
+	enforce, err := os.ReadFile("/sys/fs/selinux/enforce")
+	if err == nil && strings.TrimSpace(string(enforce)) == "1" {
+		context, _ := os.ReadFile("/proc/self/attr/current")
+		if !strings.Contains(string(context), "unconfined") {
+			env.SELinuxEnforcing = true
+			restricted = append(restricted, Capability{
+				Collector: CollectorProcesses,
+				Status:    CapabilityDegraded,
+				Reason: "SELinux is enforcing for context `" +
+					strings.Trim(string(context), "\x00\n") + "`",
+			})
+		}
+	}
+	return env, restricted
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
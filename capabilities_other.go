@@ -0,0 +1,26 @@
+//go:build !linux && !windows
+
+package gtm
+
+import "os"
+
+func detectPlatformEnvironment() (env Environment, restricted []Capability) {
+	env.IsElevated = os.Geteuid() == 0
+
+	// macOS sets this for apps running in the App Sandbox
+	if os.Getenv("APP_SANDBOX_CONTAINER_ID") != "" {
+		env.Sandbox = "app-sandbox"
+		restricted = append(restricted,
+			Capability{
+				Collector: CollectorProcesses,
+				Status:    CapabilityDegraded,
+				Reason:    "the App Sandbox hides details of processes outside the sandbox",
+			},
+			Capability{
+				Collector: CollectorGPU,
+				Status:    CapabilityDisabled,
+				Reason:    "the App Sandbox does not allow running GPU utilities",
+			})
+	}
+	return env, restricted
+}
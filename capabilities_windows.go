@@ -0,0 +1,16 @@
+package gtm
+
+import "golang.org/x/sys/windows"
+
+func detectPlatformEnvironment() (env Environment, restricted []Capability) {
+	env.IsElevated = windows.GetCurrentProcessToken().IsElevated()
+	if !env.IsElevated {
+		restricted = append(restricted, Capability{
+			Collector: CollectorProcesses,
+			Status:    CapabilityDegraded,
+			Reason: "not running as administrator; processes owned by other users and " +
+				"services cannot be inspected",
+		})
+	}
+	return env, restricted
+}
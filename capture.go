@@ -0,0 +1,247 @@
+package gtm
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// CAPTURE_HISTORY_WINDOW is how much history a capture includes by default
+	CAPTURE_HISTORY_WINDOW = 15 * time.Minute
+	// CAPTURE_UPLOAD_TIMEOUT limits how long an upload to CAPTURE_UPLOAD_URL may take
+	CAPTURE_UPLOAD_TIMEOUT = 30 * time.Second
+	// CAPTURE_PATH is the path of the capture webhook
+	CAPTURE_PATH = "/capture"
+)
+
+// Capture is an on-demand, full resolution dump of every stat plus recent history,
+// meant to be attached to an incident or a CI run. It is redacted with the configured
+// redaction profile when written or uploaded
+type Capture struct {
+	// SchemaVersion is SCHEMA_VERSION of the gtm that took the capture
+	SchemaVersion int           `json:"schema_version"`
+	Timestamp     time.Time     `json:"timestamp"`
+	HistoryWindow time.Duration `json:"history_window"`
+	Reason        string        `json:"reason,omitempty"`
+	Environment   Environment   `json:"environment"`
+	Capabilities  []Capability  `json:"capabilities"`
+	Host          HostInfo      `json:"host"`
+	CPU           []CPU         `json:"cpu"`
+	// CPUStats is the CPU usage over the history window, oldest first
+	CPUStats    []CPUStats     `json:"cpu_stats"`
+	Memory      *MemoryStats   `json:"memory"`
+	Disks       []DiskStats    `json:"disks"`
+	DiskHistory []DiskHistory  `json:"disk_history"`
+	GPU         []GPUStats     `json:"gpu,omitempty"`
+	Network     []NetStats     `json:"network"`
+	Interfaces  []NetInterface `json:"interfaces"`
+	WiFi        []WiFiStats    `json:"wifi,omitempty"`
+	Connections []Connection   `json:"connections"`
+	Annotations []Annotation   `json:"annotations,omitempty"`
+	// Errors holds the collectors that failed while capturing
+	Errors []string `json:"errors,omitempty"`
+}
+
+// CaptureSnapshot collects every stat right now, plus `window` worth of history
+// (CAPTURE_HISTORY_WINDOW when 0)
+func CaptureSnapshot(window time.Duration, reason string) Capture {
+	if window <= 0 {
+		window = CAPTURE_HISTORY_WINDOW
+	}
+	capture := Capture{
+		SchemaVersion: SCHEMA_VERSION,
+		Timestamp:     GetClock().Now(),
+		HistoryWindow: window,
+		Reason:        reason,
+		Environment:   GetEnvironment(),
+		Capabilities:  GetCapabilities(),
+		Annotations:   GetAnnotations(window),
+	}
+	addError := func(err error) {
+		// disabled collectors didn't fail, they're listed in the capabilities
+		if err != nil && !errors.Is(err, ErrCollectorDisabled) {
+			capture.Errors = append(capture.Errors, err.Error())
+		}
+	}
+
+	var err error
+	capture.Host, err = ExportHostInfo()
+	addError(err)
+	capture.CPU, err = GetCPUInfo()
+	addError(err)
+	memory, err := GetMemoryStats()
+	capture.Memory = newMemoryStats(memory)
+	addError(err)
+	capture.Network, err = GetAllNetworkStats()
+	addError(err)
+
+	_, err = GetCPUStats()
+	addError(err)
+	if history, ok := GetMetricHistory(MetricCPUUsage, window); ok {
+		capture.CPUStats = make([]CPUStats, 0, len(history.Values))
+		for _, usage := range history.Values {
+			capture.CPUStats = append(capture.CPUStats, CPUStats{UsagePercent: usage})
+		}
+	}
+
+	capture.Disks, err = GetAllDisksStats()
+	addError(err)
+	for _, mountpoint := range GetDiskHistoryMountpoints() {
+		if history, ok := GetDiskHistory(mountpoint, window); ok {
+			capture.DiskHistory = append(capture.DiskHistory, history)
+		}
+	}
+
+	if hasGPU() {
+		capture.GPU, err = GetGPUStats()
+		addError(err)
+	}
+	capture.Interfaces, err = GetNetworkInterfaces()
+	addError(err)
+	capture.WiFi, err = GetWiFiStats()
+	addError(err)
+	capture.Connections, err = GetConnections(ConnectionFilter{})
+	addError(err)
+
+	return capture
+}
+
+// JSON marshals the capture, redacted with the configured redaction profile
+func (c Capture) JSON() ([]byte, error) {
+	return GetConfiguredRedactionProfile().Redact(c)
+}
+
+// WriteCapture writes a capture to `dir` as gtm-capture-<timestamp>.json and returns
+// the path of the file
+func WriteCapture(capture Capture, dir string) (string, error) {
+	data, err := capture.JSON()
+	if err != nil {
+		return "", err
+	}
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	name := "gtm-capture-" + capture.Timestamp.UTC().Format("20060102T150405Z") + ".json"
+	path := filepath.Join(dir, name)
+	if err = os.WriteFile(path, data, 0o644); err != nil {
+		return "", err
+	}
+	logger().Info("Wrote capture to " + path)
+	return path, nil
+}
+
+// UploadCapture POSTs a capture as JSON to `url`
+func UploadCapture(ctx context.Context, capture Capture, url string) error {
+	data, err := capture.JSON()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, CAPTURE_UPLOAD_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("upload of capture failed: " + resp.Status)
+	}
+	logger().Info("Uploaded capture to " + url)
+	return nil
+}
+
+// CaptureHandler is the webhook that triggers a capture, ie. from a CI job or an
+// incident bot:
+//
+//	curl -X POST -H "Authorization: Bearer $TOKEN" "http://host:port/capture?minutes=30"
+//
+// The capture is written to CAPTURE_DIR and/or uploaded to CAPTURE_UPLOAD_URL. Without
+// either of them configured, the capture is returned in the response body instead.
+// Requests must carry CAPTURE_TOKEN as a bearer token when one is configured
+func CaptureHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if Cfg.CaptureToken != "" {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(token), []byte(Cfg.CaptureToken)) != 1 {
+				http.Error(w, "unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+
+		var window time.Duration
+		if minutes := r.URL.Query().Get("minutes"); minutes != "" {
+			m, err := strconv.ParseUint(minutes, 10, 32)
+			if err != nil {
+				http.Error(w, "invalid minutes: "+minutes, http.StatusBadRequest)
+				return
+			}
+			window = time.Duration(m) * time.Minute
+		}
+		capture := CaptureSnapshot(window, r.URL.Query().Get("reason"))
+
+		if Cfg.CaptureDir == "" && Cfg.CaptureUploadURL == "" {
+			data, err := capture.JSON()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write(data)
+			return
+		}
+
+		result := map[string]string{}
+		status := http.StatusOK
+		if Cfg.CaptureDir != "" {
+			if path, err := WriteCapture(capture, Cfg.CaptureDir); err != nil {
+				logger().Error("Failed to write capture! " + err.Error())
+				result["write_error"] = err.Error()
+				status = http.StatusInternalServerError
+			} else {
+				result["file"] = path
+			}
+		}
+		if Cfg.CaptureUploadURL != "" {
+			if err := UploadCapture(r.Context(), capture, Cfg.CaptureUploadURL); err != nil {
+				logger().Error("Failed to upload capture! " + err.Error())
+				result["upload_error"] = err.Error()
+				status = http.StatusBadGateway
+			} else {
+				result["uploaded"] = "true"
+			}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(result)
+	})
+}
+
+// StartCaptureServer serves the capture webhook on `addr` until the server fails, or
+// returns nil once the default Monitor is shut down (see Shutdown)
+func StartCaptureServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(CAPTURE_PATH, CaptureHandler())
+	logger().Info("Serving the capture webhook on http://" + addr + CAPTURE_PATH)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return defaultMonitor.serve(server)
+}
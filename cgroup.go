@@ -0,0 +1,24 @@
+package gtm
+
+import "time"
+
+// CgroupThrottling is the CPU bandwidth limit of the cgroup a process runs in (ie. a
+// container with `--cpus=2`) and how often it has been hit. A process that spends a
+// lot of periods throttled is being limited by its container, not slow on its own.
+// The counters are totals since the cgroup was created
+type CgroupThrottling struct {
+	PID    int32  `json:"pid"`
+	Cgroup string `json:"cgroup"`
+	// QuotaCPUs is the number of CPUs the cgroup may use per period, 0 when unlimited
+	QuotaCPUs        float64       `json:"quota_cpus"`
+	Periods          uint64        `json:"periods"`
+	ThrottledPeriods uint64        `json:"throttled_periods"`
+	ThrottledTime    time.Duration `json:"throttled_time"`
+	// ThrottledPercent is the share of periods in which the cgroup was throttled
+	ThrottledPercent float64 `json:"throttled_percent"`
+}
+
+// IsThrottled returns true when the cgroup has a CPU limit and has hit it at least once
+func (c CgroupThrottling) IsThrottled() bool {
+	return c.ThrottledPeriods > 0
+}
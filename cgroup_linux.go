@@ -0,0 +1,176 @@
+package gtm
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CGROUP_ROOT is where the cgroup filesystem is mounted
+const CGROUP_ROOT = "/sys/fs/cgroup"
+
+// GetProcessCPUThrottling reads the CPU limit and throttling counters of the cgroup a
+// process runs in. Both the unified (v2) and the legacy (v1) cpu controller are
+// supported
+func GetProcessCPUThrottling(pid int32) (CgroupThrottling, error) {
+	throttling := CgroupThrottling{PID: pid}
+
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.FormatInt(int64(pid), 10),
+		"cgroup"))
+	if err != nil {
+		return throttling, err
+	}
+	dir, v2, ok := findCPUCgroup(string(data))
+	if !ok {
+		return throttling, errors.New("no cpu cgroup found for pid " +
+			strconv.FormatInt(int64(pid), 10))
+	}
+	throttling.Cgroup = dir
+
+	if v2 {
+		dir = filepath.Join(CGROUP_ROOT, dir)
+	} else {
+		dir = findCgroupV1CPUDir(dir)
+	}
+
+	stat, err := os.ReadFile(filepath.Join(dir, "cpu.stat"))
+	if err != nil {
+		return throttling, err
+	}
+	for _, line := range strings.Split(string(stat), "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		number, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "nr_periods":
+			throttling.Periods = number
+		case "nr_throttled":
+			throttling.ThrottledPeriods = number
+		case "throttled_usec": // v2
+			throttling.ThrottledTime = time.Duration(number) * time.Microsecond
+		case "throttled_time": // v1, in nanoseconds
+			throttling.ThrottledTime = time.Duration(number)
+		}
+	}
+	if throttling.Periods > 0 {
+		throttling.ThrottledPercent = RoundStat(float64(throttling.ThrottledPeriods) /
+			float64(throttling.Periods) * 100)
+	}
+	throttling.QuotaCPUs = RoundStat(readCPUQuota(dir, v2))
+	return throttling, nil
+}
+
+// findCPUCgroup finds the cgroup of the cpu controller in /proc/<pid>/cgroup:
+//
+//	0::/system.slice/docker-0123.scope             (v2)
+//	4:cpu,cpuacct:/docker/0123                     (v1)
+func findCPUCgroup(procCgroup string) (dir string, v2 bool, ok bool) {
+	for _, line := range strings.Split(procCgroup, "\n") {
+		fields := strings.SplitN(line, ":", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		for _, controller := range strings.Split(fields[1], ",") {
+			if controller == "cpu" {
+				return fields[2], false, true
+			}
+		}
+		if fields[0] == "0" && fields[1] == "" {
+			// keep looking, a v1 cpu controller takes precedence in hybrid setups
+			dir, v2, ok = fields[2], true, true
+		}
+	}
+	return dir, v2, ok
+}
+
+// findCgroupV1CPUDir returns the directory of a v1 cpu cgroup, which is mounted at
+// cpu,cpuacct on most distros and at cpu on some
+func findCgroupV1CPUDir(dir string) string {
+	for _, mount := range []string{"cpu,cpuacct", "cpu", "cpuacct,cpu"} {
+		path := filepath.Join(CGROUP_ROOT, mount, dir)
+		if fileExists(filepath.Join(path, "cpu.stat")) {
+			return path
+		}
+	}
+	return filepath.Join(CGROUP_ROOT, "cpu", dir)
+}
+
+// readCPUQuota returns the CPU limit of a cgroup in CPUs, 0 when it is unlimited
+func readCPUQuota(dir string, v2 bool) float64 {
+	var quota, period float64
+	if v2 {
+		// cpu.max: "$MAX $PERIOD", where $MAX is "max" when unlimited
+		data, err := os.ReadFile(filepath.Join(dir, "cpu.max"))
+		if err != nil {
+			return 0
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) != 2 || fields[0] == "max" {
+			return 0
+		}
+		quota, _ = strconv.ParseFloat(fields[0], 64)
+		period, _ = strconv.ParseFloat(fields[1], 64)
+	} else {
+		// cpu.cfs_quota_us is -1 when unlimited
+		q, err := os.ReadFile(filepath.Join(dir, "cpu.cfs_quota_us"))
+		if err != nil {
+			return 0
+		}
+		p, err := os.ReadFile(filepath.Join(dir, "cpu.cfs_period_us"))
+		if err != nil {
+			return 0
+		}
+		quota, _ = strconv.ParseFloat(strings.TrimSpace(string(q)), 64)
+		period, _ = strconv.ParseFloat(strings.TrimSpace(string(p)), 64)
+	}
+	if quota <= 0 || period <= 0 {
+		return 0
+	}
+	return quota / period
+}
+
+// readProcessCgroup returns the container and systemd service of a process, from the
+// cgroups in /proc/<pid>/cgroup. The unified (v2) cgroup is tried first, since the
+// named v1 hierarchies (ie. name=systemd) don't always follow the container
+func readProcessCgroup(pid int32) processCgroup {
+	var cgroup processCgroup
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.FormatInt(int64(pid), 10),
+		"cgroup"))
+	if err != nil {
+		return cgroup
+	}
+	lines := strings.Split(string(data), "\n")
+	for _, v2 := range []bool{true, false} {
+		for _, line := range lines {
+			fields := strings.SplitN(line, ":", 3)
+			if len(fields) != 3 || (fields[0] == "0" && fields[1] == "") != v2 {
+				continue
+			}
+			if cgroup.ContainerID == "" {
+				cgroup.ContainerID, cgroup.ContainerRuntime = parseContainerCgroup(fields[2])
+			}
+			if cgroup.Service == "" {
+				cgroup.Service = parseServiceCgroup(fields[2])
+			}
+		}
+	}
+	return cgroup
+}
+
+// parseServiceCgroup returns the systemd service of a cgroup path, the innermost unit
+// ending in .service: "nginx" for /system.slice/nginx.service, and "app" for
+// /user.slice/user-1000.slice/user@1000.service/app.slice/app.service
+func parseServiceCgroup(path string) string {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		if service, ok := strings.CutSuffix(segments[i], ".service"); ok {
+			return service
+		}
+	}
+	return ""
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package gtm
+
+// GetProcessCPUThrottling is only implemented on linux, since cgroups are linux only
+func GetProcessCPUThrottling(pid int32) (CgroupThrottling, error) {
+	return CgroupThrottling{PID: pid}, ErrUnsupportedPlatform
+}
+
+// readProcessCgroup is empty outside of linux: containers run in a VM there (ie. Docker
+// Desktop) whose processes aren't visible to the host, and services come from the
+// service manager instead, see getServicePIDs
+func readProcessCgroup(pid int32) processCgroup {
+	return processCgroup{}
+}
@@ -0,0 +1,189 @@
+package gtm
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock is the time source used by every interval cache and UI poller in gtm. The
+// default is the system clock; swap it with SetClock for deterministic tests
+// (ManualClock) or to replay recorded data faster than real time (ScaledClock). The
+// times of the system clock carry a monotonic reading, so intervals measured with Since
+// (or Sub between two times of Now) don't jump when the system clock is changed
+type Clock interface {
+	Now() time.Time
+	Since(t time.Time) time.Duration
+	Sleep(d time.Duration)
+	After(d time.Duration) <-chan time.Time
+}
+
+var (
+	clock    Clock = realClock{}
+	clockMut sync.RWMutex
+)
+
+// SetClock replaces the time source. Passing nil restores the system clock
+func SetClock(c Clock) {
+	clockMut.Lock()
+	defer clockMut.Unlock()
+	if c == nil {
+		c = realClock{}
+	}
+	clock = c
+}
+
+// GetClock returns the time source currently in use
+func GetClock() Clock {
+	clockMut.RLock()
+	defer clockMut.RUnlock()
+	return clock
+}
+
+// CLOCK_JUMP_THRESHOLD is how far the wall clock and the monotonic clock can drift apart
+// between two samples before the time between them is considered unknown, see
+// sampleSeconds
+const CLOCK_JUMP_THRESHOLD = 2 * time.Second
+
+// sampleSeconds returns the seconds between two samples of a counter, for computing its
+// rate. It's 0 (so ratePerSecond returns 0) when there is no previous sample, or when
+// the wall clock and the monotonic clock disagree about the time in between: the system
+// clock was changed, or the machine was suspended (the monotonic clock stops during
+// suspend on most OSes). Counters can be reset on resume, ie. by a NIC driver, so the
+// sample only becomes the baseline of the next rate. Times without a monotonic reading
+// (ie. of a ManualClock) are never considered to jump
+func sampleSeconds(previous time.Time, now time.Time) float64 {
+	if previous.IsZero() {
+		return 0
+	}
+	elapsed := now.Sub(previous)
+	wall := now.Round(0).Sub(previous.Round(0))
+	if drift := wall - elapsed; drift > CLOCK_JUMP_THRESHOLD ||
+		drift < -CLOCK_JUMP_THRESHOLD {
+		logger().Debug("The clock jumped by " + drift.String() + " since the previous " +
+			"sample, skipping its rates")
+		return 0
+	}
+	return elapsed.Seconds()
+}
+
+//// System clock ////####################################################################
+
+type realClock struct{}
+
+func (realClock) Now() time.Time                         { return time.Now() }
+func (realClock) Since(t time.Time) time.Duration        { return time.Since(t) }
+func (realClock) Sleep(d time.Duration)                  { time.Sleep(d) }
+func (realClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+//// Scaled clock ////####################################################################
+
+// ScaledClock runs a fixed number of times faster than the system clock. A replay
+// running at speed 10 sleeps 100ms of real time for every second of recorded
+// time
+type ScaledClock struct {
+	start     time.Time
+	realStart time.Time
+	speed     float64
+}
+
+// NewScaledClock returns a clock that starts at `start` and advances `speed` times
+// faster than real time. A speed of 0 (or less) is treated as 1
+func NewScaledClock(start time.Time, speed float64) *ScaledClock {
+	if speed <= 0 {
+		speed = 1
+	}
+	return &ScaledClock{start: start, realStart: time.Now(), speed: speed}
+}
+
+func (c *ScaledClock) Now() time.Time {
+	elapsed := float64(time.Since(c.realStart)) * c.speed
+	return c.start.Add(time.Duration(elapsed))
+}
+
+func (c *ScaledClock) Since(t time.Time) time.Duration { return c.Now().Sub(t) }
+
+func (c *ScaledClock) Sleep(d time.Duration) { time.Sleep(c.realDuration(d)) }
+
+func (c *ScaledClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	time.AfterFunc(c.realDuration(d), func() { ch <- c.Now() })
+	return ch
+}
+
+func (c *ScaledClock) realDuration(d time.Duration) time.Duration {
+	return time.Duration(float64(d) / c.speed)
+}
+
+//// Manual clock ////####################################################################
+
+// ManualClock only moves when Advance or Set is called, which makes interval caching
+// deterministic in tests. Sleep and After block until the clock is advanced far enough
+type ManualClock struct {
+	mut     sync.Mutex
+	now     time.Time
+	waiters []manualWaiter
+}
+
+type manualWaiter struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// NewManualClock returns a clock frozen at `now`
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
+
+func (c *ManualClock) Now() time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	return c.now
+}
+
+func (c *ManualClock) Since(t time.Time) time.Duration { return c.Now().Sub(t) }
+
+func (c *ManualClock) Sleep(d time.Duration) { <-c.After(d) }
+
+func (c *ManualClock) After(d time.Duration) <-chan time.Time {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.waiters = append(c.waiters, manualWaiter{deadline: c.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by `d` and wakes every sleeper whose deadline passed
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set moves the clock to `now` and wakes every sleeper whose deadline passed
+func (c *ManualClock) Set(now time.Time) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	c.set(now)
+}
+
+func (c *ManualClock) set(now time.Time) {
+	c.now = now
+	sort.Slice(c.waiters, func(i, j int) bool {
+		return c.waiters[i].deadline.Before(c.waiters[j].deadline)
+	})
+	remaining := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.deadline.After(now) {
+			remaining = append(remaining, w)
+			continue
+		}
+		w.ch <- now
+	}
+	c.waiters = remaining
+}
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
 	"gtm"
@@ -27,6 +30,7 @@ type LayoutMain struct {
 	GPU       *GPUBox
 	Memory    *tview.TextView
 	Network   *tview.TextView
+	Pinned    *tview.TextView
 	Processes *tview.Table
 }
 
@@ -34,6 +38,13 @@ var (
 	fMain  *tview.Flex
 	layout *LayoutMain
 	hasGPU bool
+	mini   = flag.Bool("mini", false, "print the pinned metrics (PINS) as a single line "+
+		"every update interval instead of starting the UI")
+	status = flag.Bool("status", false, "print a status line (ie. for tmux or i3blocks) "+
+		"every update interval instead of starting the UI")
+	statusTemplate = flag.String("status-template", "", "text/template for -status, "+
+		"overrides STATUS_LINE_TEMPLATE in the config")
+	once = flag.Bool("once", false, "with -mini or -status, print a single line and exit")
 )
 
 func init() {
@@ -53,7 +64,75 @@ func init() {
 			log.Println(http.ListenAndServe("localhost:6060", nil))
 		}()
 	}
-	hasGPU = gtm.HasGPU()
+	if gtm.Cfg.APIListenAddr != "" {
+		go func() {
+			if err := gtm.StartAPIServer(gtm.Cfg.APIListenAddr); err != nil {
+				slog.Error("Failed to serve the REST API! " + err.Error())
+			}
+		}()
+	}
+	if gtm.Cfg.GRPCListenAddr != "" {
+		go func() {
+			if err := gtm.StartGRPCServer(gtm.Cfg.GRPCListenAddr, gtm.Cfg.GRPCTLSCert,
+				gtm.Cfg.GRPCTLSKey); err != nil {
+				slog.Error("Failed to serve gRPC! " + err.Error())
+			}
+		}()
+	}
+	if gtm.Cfg.CaptureListenAddr != "" {
+		go func() {
+			if err := gtm.StartCaptureServer(gtm.Cfg.CaptureListenAddr); err != nil {
+				slog.Error("Failed to serve the capture webhook! " + err.Error())
+			}
+		}()
+	}
+	if gtm.Cfg.PrometheusListenAddr != "" {
+		go func() {
+			if err := gtm.StartPrometheusServer(gtm.Cfg.PrometheusListenAddr); err != nil {
+				slog.Error("Failed to serve the Prometheus metrics! " + err.Error())
+			}
+		}()
+	}
+	if gtm.Cfg.StatsDAddr != "" {
+		_, err := gtm.StartStatsD(gtm.Cfg.StatsDAddr, gtm.StatsDOptions{
+			Prefix:    gtm.Cfg.StatsDPrefix,
+			DogStatsD: gtm.Cfg.StatsDDogStatsD,
+			Tags:      gtm.Cfg.StatsDTags,
+		})
+		if err != nil {
+			slog.Error("Failed to send metrics to StatsD! " + err.Error())
+		}
+	}
+	if gtm.Cfg.GraphiteAddr != "" {
+		_, err := gtm.StartGraphite(gtm.Cfg.GraphiteAddr,
+			gtm.GraphiteOptions{Prefix: gtm.Cfg.GraphitePrefix})
+		if err != nil {
+			slog.Error("Failed to push metrics to Graphite! " + err.Error())
+		}
+	}
+	if gtm.Cfg.OTLPEndpoint != "" {
+		_, err := gtm.StartOTLP(gtm.Cfg.OTLPEndpoint, gtm.OTLPOptions{
+			Headers:  gtm.Cfg.OTLPHeaders,
+			Interval: gtm.Cfg.OTLPInterval,
+		})
+		if err != nil {
+			slog.Error("Failed to push metrics to the OpenTelemetry collector! " +
+				err.Error())
+		}
+	}
+	// Probe for sandboxes, missing privileges and hardware before any collector runs, so
+	//	collectors that cannot work here are disabled instead of failing every interval
+	hasGPU = gtm.Init().Has(gtm.CollectorGPU)
+	gtm.RegisterConfigPlugins()
+
+	// The exporters and listeners send the stats whenever a collector updates, so poll
+	//	every collector instead of only the ones the UI (or -mini, -status) fetches.
+	//	gtm.Shutdown stops the polling
+	if exporting() {
+		if err := gtm.DefaultMonitor().Start(context.Background()); err != nil {
+			slog.Error("Failed to start polling the collectors! " + err.Error())
+		}
+	}
 
 	// Seed the initial values & data before setting up the rest of the app
 	gtm.GetHostInfo()
@@ -67,6 +146,9 @@ func init() {
 	gtm.GetMemoryStats()
 	gtm.GetNetworkStats()
 
+	// Pick up hot-plugged disks, NICs and GPUs without a restart
+	gtm.StartDeviceDiscovery(gtm.DISCOVERY_INTERVAL)
+
 	// Initialize the main layout ASAP
 	layout = &LayoutMain{
 		CPU: &CPUBox{
@@ -76,6 +158,7 @@ func init() {
 		Disk:      tview.NewTextView(),
 		Memory:    tview.NewTextView(),
 		Network:   tview.NewTextView(),
+		Pinned:    tview.NewTextView(),
 		Processes: tview.NewTable(),
 	}
 	if hasGPU {
@@ -86,6 +169,14 @@ func init() {
 	}
 }
 
+// exporting reports whether an exporter or a listener is configured
+func exporting() bool {
+	return gtm.Cfg.APIListenAddr != "" || gtm.Cfg.GRPCListenAddr != "" ||
+		gtm.Cfg.CaptureListenAddr != "" || gtm.Cfg.PrometheusListenAddr != "" ||
+		gtm.Cfg.StatsDAddr != "" || gtm.Cfg.GraphiteAddr != "" ||
+		gtm.Cfg.OTLPEndpoint != ""
+}
+
 func setupLayout() {
 	slog.Info("Setting up layout ...")
 
@@ -132,6 +223,11 @@ func setupLayout() {
 	}
 	fMain.AddItem(flexRow2, 0, 40, false)
 
+	if len(gtm.GetPins()) > 0 {
+		/// Pinned metrics, between the panels and the key bindings
+		fMain.AddItem(layout.Pinned, 3, 0, false)
+	}
+
 	/// Row 3
 	flexRow3 := tview.NewFlex()
 	flexRow3.AddItem(tview.NewTextView().
@@ -140,7 +236,57 @@ func setupLayout() {
 	fMain.AddItem(flexRow3, 0, 1, false)
 }
 
+// runMini prints the pinned metrics every update interval until killed
+func runMini() {
+	for {
+		fmt.Println(gtm.FormatPinnedMetrics())
+		if *once {
+			return
+		}
+		gtm.GetClock().Sleep(gtm.Cfg.UpdateInterval)
+	}
+}
+
+// runStatusLine prints the status line every update interval until killed
+func runStatusLine() {
+	text := *statusTemplate
+	if text == "" {
+		text = gtm.Cfg.StatusLineTemplate
+	}
+	tmpl, err := gtm.ParseStatusLineTemplate(text)
+	if err != nil {
+		slog.Error("Failed to parse the status line template! " + err.Error())
+		log.Fatal(err)
+	}
+	if *once {
+		// rates are computed between two fetches, so wait for a second sample first
+		gtm.GetNetworkStats()
+		gtm.GetClock().Sleep(gtm.NET_STATS_UPDATE_INTERVAL)
+	}
+	for {
+		line, err := gtm.RenderStatusLine(tmpl)
+		if err != nil {
+			slog.Error("Failed to render the status line! " + err.Error())
+		}
+		fmt.Println(line)
+		if *once {
+			return
+		}
+		gtm.GetClock().Sleep(gtm.Cfg.UpdateInterval)
+	}
+}
+
 func main() {
+	flag.Parse()
+	if *mini {
+		runMini()
+		return
+	}
+	if *status {
+		runStatusLine()
+		return
+	}
+
 	// Scaffold the FlexBox `Main` and layout
 	setupLayout()
 
@@ -154,6 +300,13 @@ func main() {
 		switch event.Key() {
 		case tcell.KeyCtrlC:
 			app.Stop()
+		case tcell.KeyRune:
+			if event.Rune() == 'm' {
+				// Mark the timeline, ie. right before starting a benchmark
+				gtm.Annotate(gtm.Annotation{Kind: gtm.AnnotationNote, Source: "ui",
+					Text: "marked from the UI"})
+				return nil
+			}
 		default:
 			return event
 		}
@@ -172,15 +325,33 @@ func main() {
 	}
 	go gtm.UpdateMemory(app, layout.Memory, true)
 	go gtm.UpdateNetwork(app, layout.Network, true)
+	if len(gtm.GetPins()) > 0 {
+		go gtm.UpdatePinned(app, layout.Pinned, true)
+	}
 	go gtm.UpdateProcesses(app, layout.Processes, true)
+	go gtm.NotifyCriticalAlerts(app)
 
 	slog.Info("Waiting for goroutines to start up ...")
 	time.Sleep(20 * time.Millisecond) // wait to start up all the goroutines
 
 	// START APP
 	slog.Info("Starting the app ...")
+	gtm.StartSession()
 	if err := app.Run(); err != nil {
 		slog.Error("Failed to run the app! " + err.Error())
 		panic(err)
 	}
+	// Stop the collectors and the capture webhook, and keep the traffic counted since the
+	//	last periodic save
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := gtm.Shutdown(ctx); err != nil {
+		slog.Error("Failed to shut down cleanly! " + err.Error())
+	}
+	if gtm.Cfg.SessionSummary != "" {
+		err := gtm.WriteSessionSummary(gtm.GetSessionSummary(), gtm.Cfg.SessionSummary)
+		if err != nil {
+			slog.Error("Failed to write the session summary! " + err.Error())
+		}
+	}
 }
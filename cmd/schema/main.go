@@ -0,0 +1,21 @@
+// Command schema prints the OpenAPI document describing gtm's JSON types, so clients
+// can be generated for other languages:
+//
+//	go run ./cmd/schema > openapi.json
+package main
+
+import (
+	"gtm"
+	"log"
+	"os"
+)
+
+func main() {
+	schema, err := gtm.OpenAPISchema()
+	if err != nil {
+		log.Fatalln("Failed to generate the OpenAPI schema! " + err.Error())
+	}
+	if _, err = os.Stdout.Write(append(schema, '\n')); err != nil {
+		log.Fatalln("Failed to write the OpenAPI schema! " + err.Error())
+	}
+}
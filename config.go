@@ -2,28 +2,120 @@ package gtm
 
 import (
 	"github.com/joho/godotenv"
-	"log/slog"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type ConfigVars struct {
+	APIListenAddr        string
+	AlertBell            bool
+	AlertFlash           bool
+	Anonymize            bool
+	AnonymizeSalt        string
+	BandwidthFile        string
+	CaptureDir           string
+	CaptureListenAddr    string
+	CaptureToken         string
+	CaptureUploadURL     string
 	Celsius              bool
 	DeleteOldLogs        bool
 	Debug                bool
+	DiskAliases          Aliases
+	DiskAllPartitions    bool
+	DiskExclude          []string
+	DiskInclude          []string
+	GPUAliases           Aliases
+	GRPCListenAddr       string
+	GRPCTLSCert          string
+	GRPCTLSKey           string
+	GraphiteAddr         string
+	GraphitePrefix       string
+	HostnameOverride     string
+	Language             string
+	NetAliases           Aliases
+	NetExclude           []string
+	NetInclude           []string
+	NetUnits             NetUnit
+	OTLPEndpoint         string
+	OTLPHeaders          Aliases
+	OTLPInterval         time.Duration
 	PerformanceLogging   bool
+	Pins                 []Pin
+	Plugins              map[string]string
+	Precision            int
+	PrometheusListenAddr string
+	PublicIPLookup       bool
+	PublicIPURL          string
+	RedactionProfile     string
+	Rounding             RoundingMode
+	SessionSummary       string
+	StatsDAddr           string
+	StatsDDogStatsD      bool
+	StatsDPrefix         string
+	StatsDTags           []string
+	StatusLineTemplate   string
+	TBWRatings           map[string]string
 	TraceFunctionLogging bool
+	UPSAddrs             []string
 	UpdateInterval       time.Duration
+	WebSocketOrigins     []string
 }
 
 var CFG_DEFAULT = ConfigVars{
+	APIListenAddr:        "",
+	AlertBell:            false,
+	AlertFlash:           false,
+	Anonymize:            false,
+	AnonymizeSalt:        "",
+	BandwidthFile:        "",
+	CaptureDir:           "",
+	CaptureListenAddr:    "",
+	CaptureToken:         "",
+	CaptureUploadURL:     "",
 	Celsius:              true,
 	DeleteOldLogs:        false,
 	Debug:                false,
+	DiskAliases:          nil,
+	DiskAllPartitions:    false,
+	DiskExclude:          nil,
+	DiskInclude:          nil,
+	GPUAliases:           nil,
+	GRPCListenAddr:       "",
+	GRPCTLSCert:          "",
+	GRPCTLSKey:           "",
+	GraphiteAddr:         "",
+	GraphitePrefix:       GRAPHITE_DEFAULT_PREFIX,
+	HostnameOverride:     "",
+	Language:             DEFAULT_LANGUAGE,
+	NetAliases:           nil,
+	NetExclude:           NET_DEFAULT_EXCLUDE,
+	NetInclude:           nil,
+	NetUnits:             NetUnitBytes,
+	OTLPEndpoint:         "",
+	OTLPHeaders:          nil,
+	OTLPInterval:         OTLP_INTERVAL,
 	PerformanceLogging:   false,
+	Pins:                 nil,
+	Plugins:              nil,
+	Precision:            DEFAULT_PRECISION,
+	PrometheusListenAddr: "",
+	PublicIPLookup:       false,
+	PublicIPURL:          PUBLIC_IP_URL,
+	RedactionProfile:     "none",
+	Rounding:             RoundHalfEven,
+	SessionSummary:       "",
+	StatsDAddr:           "",
+	StatsDDogStatsD:      false,
+	StatsDPrefix:         STATSD_DEFAULT_PREFIX,
+	StatsDTags:           nil,
+	StatusLineTemplate:   STATUS_LINE_TEMPLATE,
+	TBWRatings:           nil,
 	TraceFunctionLogging: false,
+	UPSAddrs:             nil,
 	UpdateInterval:       500 * time.Millisecond,
+	WebSocketOrigins:     nil,
 }
 
 var Cfg ConfigVars
@@ -31,10 +123,16 @@ var Cfg ConfigVars
 func ReadConfig() {
 	var (
 		err                  error
+		alertBell            bool
+		alertFlash           bool
+		anonymize            bool
 		celsius              bool
 		deleteOldLogs        bool
 		debug                bool
+		diskAllPartitions    bool
 		performanceLogging   bool
+		precision            int64
+		publicIPLookup       bool
 		traceFunctionLogging bool
 		updateInterval       int64
 	)
@@ -45,54 +143,231 @@ func ReadConfig() {
 
 	err = godotenv.Load()
 	if err != nil {
-		slog.Error("Failed to read config vars from `.env` ... using defaults")
+		logger().Error("Failed to read config vars from `.env` ... using defaults")
 	} else {
 		// Reading .env was successful ... populate the values from .env file
 
+		// The REST API is disabled unless API_LISTEN_ADDR is set, ie. localhost:9102
+		Cfg.APIListenAddr = os.Getenv("API_LISTEN_ADDR")
+
+		if alertBell, err = strconv.ParseBool(os.Getenv("ALERT_BELL")); err == nil {
+			Cfg.AlertBell = alertBell
+		} else {
+			logger().Error("Failed to parse boolean: ALERT_BELL ... using default value: " +
+				strconv.FormatBool(CFG_DEFAULT.AlertBell))
+		}
+		if alertFlash, err = strconv.ParseBool(os.Getenv("ALERT_FLASH")); err == nil {
+			Cfg.AlertFlash = alertFlash
+		} else {
+			logger().Error("Failed to parse boolean: ALERT_FLASH ... using default value: " +
+				strconv.FormatBool(CFG_DEFAULT.AlertFlash))
+		}
+
+		if anonymize, err = strconv.ParseBool(os.Getenv("ANONYMIZE")); err == nil {
+			Cfg.Anonymize = anonymize
+		} else {
+			logger().Error("Failed to parse boolean: ANONYMIZE ... using default value: " +
+				strconv.FormatBool(CFG_DEFAULT.Anonymize))
+		}
+		Cfg.AnonymizeSalt = os.Getenv("ANONYMIZE_SALT")
+
+		// Defaults to bandwidth.json in the user config dir when empty
+		Cfg.BandwidthFile = os.Getenv("BANDWIDTH_FILE")
+
+		// The capture webhook is disabled unless CAPTURE_LISTEN_ADDR is set
+		Cfg.CaptureDir = os.Getenv("CAPTURE_DIR")
+		Cfg.CaptureListenAddr = os.Getenv("CAPTURE_LISTEN_ADDR")
+		Cfg.CaptureToken = os.Getenv("CAPTURE_TOKEN")
+		Cfg.CaptureUploadURL = os.Getenv("CAPTURE_UPLOAD_URL")
+
 		celsius, err = strconv.ParseBool(os.Getenv("CELSIUS"))
 		if err == nil {
 			Cfg.Celsius = celsius
 		} else {
-			slog.Error("Failed to parse boolean: CELSIUS ... " +
+			logger().Error("Failed to parse boolean: CELSIUS ... " +
 				"using default value: " + strconv.FormatBool(CFG_DEFAULT.Celsius))
 		}
 
 		if deleteOldLogs, err = strconv.ParseBool(os.Getenv("DELETE_OLD_LOGS")); err == nil {
 			Cfg.DeleteOldLogs = deleteOldLogs
 		} else {
-			slog.Error("Failed to parse boolean: deleteOldLogs ... " +
+			logger().Error("Failed to parse boolean: deleteOldLogs ... " +
 				"using default value: " + strconv.FormatBool(CFG_DEFAULT.DeleteOldLogs))
 		}
 
 		if debug, err = strconv.ParseBool(os.Getenv("DEBUG")); err == nil {
 			Cfg.Debug = debug
 		} else {
-			slog.Error("Failed to parse boolean: DEBUG ... using default value: " +
+			logger().Error("Failed to parse boolean: DEBUG ... using default value: " +
 				strconv.FormatBool(CFG_DEFAULT.Debug))
 		}
 
+		Cfg.DiskAliases = parseAliases("DISK_ALIASES", os.Getenv("DISK_ALIASES"))
+
+		if diskAllPartitions, err = strconv.ParseBool(os.Getenv("DISK_ALL_PARTITIONS")); err == nil {
+			Cfg.DiskAllPartitions = diskAllPartitions
+		} else {
+			logger().Error("Failed to parse boolean: DISK_ALL_PARTITIONS ... using default value: " +
+				strconv.FormatBool(CFG_DEFAULT.DiskAllPartitions))
+		}
+		Cfg.DiskExclude = parseList(os.Getenv("DISK_EXCLUDE"))
+		Cfg.DiskInclude = parseList(os.Getenv("DISK_INCLUDE"))
+
+		Cfg.GPUAliases = parseAliases("GPU_ALIASES", os.Getenv("GPU_ALIASES"))
+
+		// The gRPC service is disabled unless GRPC_LISTEN_ADDR is set, ie. :9103. gRPC
+		//	needs HTTP/2, which is only served over TLS, so it also needs the PEM files
+		//	of a certificate and its key
+		Cfg.GRPCListenAddr = os.Getenv("GRPC_LISTEN_ADDR")
+		Cfg.GRPCTLSCert = os.Getenv("GRPC_TLS_CERT")
+		Cfg.GRPCTLSKey = os.Getenv("GRPC_TLS_KEY")
+
+		// Metrics are only pushed to Graphite when GRAPHITE_ADDR is set, ie. graphite:2003
+		Cfg.GraphiteAddr = os.Getenv("GRAPHITE_ADDR")
+		if graphitePrefix, ok := os.LookupEnv("GRAPHITE_PREFIX"); ok {
+			Cfg.GraphitePrefix = graphitePrefix
+		}
+
+		// Not named HOSTNAME, since most shells already export that variable
+		Cfg.HostnameOverride = os.Getenv("HOSTNAME_OVERRIDE")
+
+		if language := os.Getenv("LANGUAGE"); language != "" {
+			Cfg.Language = language
+		}
+
+		Cfg.NetAliases = parseAliases("NET_ALIASES", os.Getenv("NET_ALIASES"))
+		// An empty NET_EXCLUDE shows every interface, so it's only replaced when it's set
+		if netExclude, ok := os.LookupEnv("NET_EXCLUDE"); ok {
+			Cfg.NetExclude = parseList(netExclude)
+		}
+		Cfg.NetInclude = parseList(os.Getenv("NET_INCLUDE"))
+		if netUnits, ok := ParseNetUnit(os.Getenv("NET_UNITS")); ok {
+			Cfg.NetUnits = netUnits
+		} else {
+			logger().Error("Failed to parse network unit (bytes, bits): NET_UNITS ... " +
+				"using default value: " + string(CFG_DEFAULT.NetUnits))
+		}
+
+		// The standard variables of the OpenTelemetry SDKs, metrics are only pushed when
+		//	OTEL_EXPORTER_OTLP_ENDPOINT is set, ie. http://localhost:4318
+		Cfg.OTLPEndpoint = os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+		// ie. OTEL_EXPORTER_OTLP_HEADERS=api-key=secret
+		Cfg.OTLPHeaders = parseAliases("OTEL_EXPORTER_OTLP_HEADERS",
+			os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"))
+		if otlpInterval := os.Getenv("OTEL_METRIC_EXPORT_INTERVAL"); otlpInterval != "" {
+			if milliseconds, err := strconv.ParseInt(otlpInterval, 10, 64); err == nil &&
+				milliseconds > 0 {
+				Cfg.OTLPInterval = time.Duration(milliseconds) * time.Millisecond
+			} else {
+				logger().Error("Failed to parse integer: OTEL_METRIC_EXPORT_INTERVAL ... " +
+					"using default value: " + CFG_DEFAULT.OTLPInterval.String())
+			}
+		}
+
 		if performanceLogging, err = strconv.ParseBool(os.Getenv("PERFORMANCE_LOGGING")); err == nil {
 			Cfg.PerformanceLogging = performanceLogging
 		} else {
-			slog.Error("Failed to parse boolean: PERFORMANCE_LOGGING ... using default: " +
+			logger().Error("Failed to parse boolean: PERFORMANCE_LOGGING ... using default: " +
 				strconv.FormatBool(CFG_DEFAULT.PerformanceLogging))
 		}
 
+		Cfg.Pins = parsePins(os.Getenv("PINS"))
+
+		// ie. PLUGINS=router=/usr/local/bin/router-stats --json,app=/opt/app/metrics
+		Cfg.Plugins = parseAliases("PLUGINS", os.Getenv("PLUGINS"))
+
+		precision, err = strconv.ParseInt(os.Getenv("PRECISION"), 10, 32)
+		if err == nil && precision >= 0 && precision <= 15 {
+			Cfg.Precision = int(precision)
+		} else {
+			logger().Error("Failed to parse integer (0-15): PRECISION ... " +
+				"using default value: " + strconv.Itoa(CFG_DEFAULT.Precision))
+		}
+
+		// The Prometheus exporter is disabled unless PROMETHEUS_LISTEN_ADDR is set
+		Cfg.PrometheusListenAddr = os.Getenv("PROMETHEUS_LISTEN_ADDR")
+
+		// Looking up the public IP sends a request to PUBLIC_IP_URL, so it's opt-in
+		if publicIPLookup, err = strconv.ParseBool(os.Getenv("PUBLIC_IP_LOOKUP")); err == nil {
+			Cfg.PublicIPLookup = publicIPLookup
+		} else {
+			logger().Error("Failed to parse boolean: PUBLIC_IP_LOOKUP ... using default value: " +
+				strconv.FormatBool(CFG_DEFAULT.PublicIPLookup))
+		}
+		if publicIPURL := os.Getenv("PUBLIC_IP_URL"); publicIPURL != "" {
+			Cfg.PublicIPURL = publicIPURL
+		}
+
+		if rounding, ok := ParseRoundingMode(os.Getenv("ROUNDING")); ok {
+			Cfg.Rounding = rounding
+		} else {
+			logger().Error("Failed to parse rounding mode: ROUNDING ... " +
+				"using default value: " + CFG_DEFAULT.Rounding.String())
+		}
+
+		if redactionProfile := os.Getenv("REDACTION_PROFILE"); redactionProfile != "" {
+			Cfg.RedactionProfile = redactionProfile
+		}
+
+		// "stdout" prints the session summary on exit, any other value is a file path
+		Cfg.SessionSummary = os.Getenv("SESSION_SUMMARY")
+
+		// Metrics are only sent to StatsD when STATSD_ADDR is set, ie. localhost:8125
+		Cfg.StatsDAddr = os.Getenv("STATSD_ADDR")
+		if dogStatsD := os.Getenv("STATSD_DOGSTATSD"); dogStatsD != "" {
+			if Cfg.StatsDDogStatsD, err = strconv.ParseBool(dogStatsD); err != nil {
+				logger().Error("Failed to parse boolean: STATSD_DOGSTATSD ... using default " +
+					"value: " + strconv.FormatBool(CFG_DEFAULT.StatsDDogStatsD))
+			}
+		}
+		if statsDPrefix, ok := os.LookupEnv("STATSD_PREFIX"); ok {
+			Cfg.StatsDPrefix = statsDPrefix
+		}
+		// ie. STATSD_TAGS=env:prod,team:infra, only sent with STATSD_DOGSTATSD=true
+		Cfg.StatsDTags = parseList(os.Getenv("STATSD_TAGS"))
+
+		if statusLineTemplate := os.Getenv("STATUS_LINE_TEMPLATE"); statusLineTemplate != "" {
+			Cfg.StatusLineTemplate = statusLineTemplate
+		}
+
+		// ie. TBW_RATINGS="Samsung SSD 990 PRO 2TB=1200", in TB written
+		Cfg.TBWRatings = parseAliases("TBW_RATINGS", os.Getenv("TBW_RATINGS"))
+
 		traceFunctionLogging, err = strconv.ParseBool(os.Getenv("TRACE_FUNCTION_LOGGING"))
 		if err == nil {
 			Cfg.TraceFunctionLogging = traceFunctionLogging
 		} else {
-			slog.Error("Failed to parse boolean: traceFunctionLogging ... " +
+			logger().Error("Failed to parse boolean: traceFunctionLogging ... " +
 				"using default value: " + strconv.FormatBool(CFG_DEFAULT.TraceFunctionLogging))
 		}
 
+		// ie. UPS_ADDRS=apcupsd://localhost:3551,nut://myups@nas.lan:3493
+		Cfg.UPSAddrs = parseList(os.Getenv("UPS_ADDRS"))
+
 		updateInterval, err = strconv.ParseInt(os.Getenv("UPDATE_INTERVAL"), 10, 64)
 		if err == nil {
 			Cfg.UpdateInterval = time.Duration(updateInterval) * time.Millisecond
 		} else {
-			slog.Error("Failed to parse integer: UPDATE_INTERVAL ... " +
+			logger().Error("Failed to parse integer: UPDATE_INTERVAL ... " +
 				"using default value: " + CFG_DEFAULT.UpdateInterval.String())
 		}
 
+		// Browsers may only stream from other origins listed in WEBSOCKET_ORIGINS, ie.
+		//	https://dashboard.example.com, or "*" for any origin
+		Cfg.WebSocketOrigins = parseList(os.Getenv("WEBSOCKET_ORIGINS"))
+
+	}
+	SetLanguage(Cfg.Language)
+	SetPins(Cfg.Pins)
+}
+
+// parseList splits a comma separated config value, ie. "/boot/*, tmpfs" into
+// ["/boot/*", "tmpfs"]. An empty value returns nil
+func parseList(value string) (list []string) {
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
 	}
+	return list
 }
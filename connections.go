@@ -0,0 +1,243 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/net"
+	"github.com/shirou/gopsutil/v4/process"
+	"iter"
+	"net/netip"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// CONNECTIONS_UPDATE_INTERVAL limits how often the connection table is read, since
+// every read walks /proc/*/fd (linux) or the whole TCP/UDP table (windows)
+const CONNECTIONS_UPDATE_INTERVAL = 2 * time.Second
+
+// ConnState is the state of a connection. The different names every OS uses for the
+// same state are mapped to a single ConnState
+type ConnState string
+
+const (
+	ConnEstablished ConnState = "ESTABLISHED"
+	ConnListen      ConnState = "LISTEN"
+	ConnSynSent     ConnState = "SYN_SENT"
+	ConnSynRecv     ConnState = "SYN_RECV"
+	ConnFinWait1    ConnState = "FIN_WAIT1"
+	ConnFinWait2    ConnState = "FIN_WAIT2"
+	ConnTimeWait    ConnState = "TIME_WAIT"
+	ConnClose       ConnState = "CLOSE"
+	ConnCloseWait   ConnState = "CLOSE_WAIT"
+	ConnLastAck     ConnState = "LAST_ACK"
+	ConnClosing     ConnState = "CLOSING"
+	// ConnNone is the state of every UDP socket, since UDP is connectionless
+	ConnNone ConnState = "NONE"
+)
+
+// Protocol names used by Connection and ConnectionFilter
+const (
+	ProtoTCP = "tcp"
+	ProtoUDP = "udp"
+)
+
+// Endpoint is one side of a connection. IP is empty for unconnected sockets
+type Endpoint struct {
+	IP   string `json:"ip"`
+	Port uint32 `json:"port"`
+}
+
+// Connection is a single socket, like a line of `ss` or `netstat`. PID is 0 (and
+// ProcessName is empty) when the owner can't be read, ie. sockets of other users
+// without root/admin
+type Connection struct {
+	Protocol    string    `json:"protocol"`
+	IPv6        bool      `json:"ipv6"`
+	State       ConnState `json:"state"`
+	Local       Endpoint  `json:"local"`
+	Remote      Endpoint  `json:"remote"`
+	PID         int32     `json:"pid"`
+	ProcessName string    `json:"process_name"`
+}
+
+// ConnectionFilter selects connections. An empty list matches everything
+type ConnectionFilter struct {
+	Protocols []string
+	States    []ConnState
+}
+
+var (
+	connections        []Connection
+	lastFetchConn      time.Time
+	connMut            sync.Mutex
+	connectionStateMap = map[string]ConnState{
+		"ESTABLISHED":  ConnEstablished,
+		"LISTEN":       ConnListen,
+		"SYN_SENT":     ConnSynSent,
+		"SYN_RECV":     ConnSynRecv,
+		"SYN_RECEIVED": ConnSynRecv,
+		"FIN_WAIT1":    ConnFinWait1,
+		"FIN_WAIT_1":   ConnFinWait1,
+		"FIN_WAIT2":    ConnFinWait2,
+		"FIN_WAIT_2":   ConnFinWait2,
+		"TIME_WAIT":    ConnTimeWait,
+		"CLOSE":        ConnClose,
+		"CLOSED":       ConnClose,
+		"DELETE":       ConnClose,
+		"CLOSE_WAIT":   ConnCloseWait,
+		"LAST_ACK":     ConnLastAck,
+		"CLOSING":      ConnClosing,
+	}
+)
+
+// GetConnections returns every TCP and UDP socket (IPv4 and IPv6) that matches the
+// filter
+func GetConnections(filter ConnectionFilter) ([]Connection, error) {
+	all, err := getAllConnections()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]Connection, 0, len(all))
+	for _, conn := range all {
+		if filter.Match(conn) {
+			result = append(result, conn)
+		}
+	}
+	return result, nil
+}
+
+// Connections returns an iterator over the connections that match the filter, like
+// GetConnections but without copying the cached connection table, which can hold tens
+// of thousands of sockets on busy servers. The table is read when the iteration starts;
+// read errors are only logged, use GetConnections to handle them
+func Connections(filter ConnectionFilter) iter.Seq[Connection] {
+	return func(yield func(Connection) bool) {
+		all, _ := getAllConnections()
+		for _, conn := range all {
+			if filter.Match(conn) && !yield(conn) {
+				return
+			}
+		}
+	}
+}
+
+// Match returns true when the connection passes the filter
+func (f ConnectionFilter) Match(conn Connection) bool {
+	if len(f.Protocols) > 0 && !slices.ContainsFunc(f.Protocols, func(p string) bool {
+		return strings.EqualFold(p, conn.Protocol)
+	}) {
+		return false
+	}
+	if len(f.States) > 0 && !slices.Contains(f.States, conn.State) {
+		return false
+	}
+	return true
+}
+
+func getAllConnections() ([]Connection, error) {
+	connMut.Lock()
+	defer connMut.Unlock()
+
+	if GetClock().Since(lastFetchConn) < CONNECTIONS_UPDATE_INTERVAL && connections != nil {
+		return connections, nil
+	}
+
+	stats, err := net.Connections("inet")
+	if err != nil {
+		collectorError(CollectorNetwork, "Failed to retrieve net.Connections()!", err)
+		return nil, err
+	}
+
+	// Many sockets belong to the same few processes, so only look each name up once
+	names := map[int32]string{}
+	result := make([]Connection, 0, len(stats))
+	for _, stat := range stats {
+		conn := Connection{
+			Protocol: ProtoTCP,
+			IPv6:     stat.Family == syscall.AF_INET6,
+			State:    convertConnState(stat.Status),
+			Local:    Endpoint{IP: stat.Laddr.IP, Port: stat.Laddr.Port},
+			Remote:   Endpoint{IP: stat.Raddr.IP, Port: stat.Raddr.Port},
+			PID:      stat.Pid,
+		}
+		if stat.Type == syscall.SOCK_DGRAM {
+			conn.Protocol = ProtoUDP
+			conn.State = ConnNone
+		}
+		if conn.PID > 0 {
+			name, ok := names[conn.PID]
+			if !ok {
+				if proc, err := process.NewProcess(conn.PID); err == nil {
+					name, _ = proc.Name()
+				}
+				names[conn.PID] = name
+			}
+			conn.ProcessName = name
+		}
+		result = append(result, conn)
+	}
+
+	connections = result
+	lastFetchConn = GetClock().Now()
+	return connections, nil
+}
+
+func convertConnState(status string) ConnState {
+	if state, ok := connectionStateMap[strings.ToUpper(status)]; ok {
+		return state
+	}
+	return ConnNone
+}
+
+// ListeningPort is a socket accepting connections (TCP) or datagrams (UDP). Exposed is
+// true when it's bound to a wildcard or non-loopback address, so other machines can
+// reach it (firewalls aside)
+type ListeningPort struct {
+	Protocol    string `json:"protocol"`
+	IPv6        bool   `json:"ipv6"`
+	Address     string `json:"address"`
+	Port        uint32 `json:"port"`
+	Exposed     bool   `json:"exposed"`
+	PID         int32  `json:"pid"`
+	ProcessName string `json:"process_name"`
+}
+
+// GetListeningPorts returns every listening TCP socket and unconnected UDP socket,
+// sorted by port, to review what the machine is exposing. Without root/admin the owner
+// of sockets of other users is unknown
+func GetListeningPorts() ([]ListeningPort, error) {
+	all, err := getAllConnections()
+	if err != nil {
+		return nil, err
+	}
+	ports := []ListeningPort{}
+	for _, conn := range all {
+		isListening := conn.Protocol == ProtoTCP && conn.State == ConnListen
+		isUnconnectedUDP := conn.Protocol == ProtoUDP && conn.Remote.Port == 0
+		if !isListening && !isUnconnectedUDP {
+			continue
+		}
+		addr, err := netip.ParseAddr(conn.Local.IP)
+		ports = append(ports, ListeningPort{
+			Protocol:    conn.Protocol,
+			IPv6:        conn.IPv6,
+			Address:     conn.Local.IP,
+			Port:        conn.Local.Port,
+			Exposed:     err != nil || !addr.IsLoopback(),
+			PID:         conn.PID,
+			ProcessName: conn.ProcessName,
+		})
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		if ports[i].Port != ports[j].Port {
+			return ports[i].Port < ports[j].Port
+		}
+		if ports[i].Protocol != ports[j].Protocol {
+			return ports[i].Protocol < ports[j].Protocol
+		}
+		return ports[i].Address < ports[j].Address
+	})
+	return ports, nil
+}
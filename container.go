@@ -0,0 +1,88 @@
+package gtm
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// Container runtimes of ProcStats.ContainerRuntime
+const (
+	RuntimeDocker     = "docker"
+	RuntimeContainerd = "containerd"
+	RuntimeCRIO       = "cri-o"
+	RuntimePodman     = "podman"
+	RuntimeKubernetes = "kubernetes"
+	RuntimeLXC        = "lxc"
+)
+
+// containerCgroupPrefixes are the prefixes of the scope names systemd gives container
+// cgroups, ie. "docker-<id>.scope"
+var containerCgroupPrefixes = map[string]string{
+	"docker-":         RuntimeDocker,
+	"cri-containerd-": RuntimeContainerd,
+	"containerd-":     RuntimeContainerd,
+	"crio-":           RuntimeCRIO,
+	"libpod-":         RuntimePodman,
+}
+
+// processCgroup is the container and systemd service of a process, which come from its
+// cgroup. It's cached until the PID is reused, since the cgroup of a process doesn't
+// change
+type processCgroup struct {
+	CreateTime       int64
+	ContainerID      string
+	ContainerRuntime string
+	Service          string
+}
+
+// parseContainerCgroup finds the container a cgroup path belongs to, like:
+//
+//	/docker/<id>                                               (docker, v1)
+//	/system.slice/docker-<id>.scope                            (docker, v2)
+//	/kubepods.slice/kubepods-pod<uid>.slice/cri-containerd-<id>.scope
+//	/machine.slice/libpod-<id>.scope                           (podman)
+//	/lxc.payload.<name>                                        (lxc)
+//
+// The ID is empty for processes that don't run in a container
+func parseContainerCgroup(path string) (id string, runtime string) {
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	for i := len(segments) - 1; i >= 0; i-- {
+		segment := strings.TrimSuffix(segments[i], ".scope")
+		if name, ok := strings.CutPrefix(segment, "lxc.payload."); ok {
+			return name, RuntimeLXC
+		}
+		if i > 0 && segments[i-1] == "lxc" {
+			return segment, RuntimeLXC
+		}
+		for prefix, prefixRuntime := range containerCgroupPrefixes {
+			if trimmed, ok := strings.CutPrefix(segment, prefix); ok {
+				segment, runtime = trimmed, prefixRuntime
+				break
+			}
+		}
+		if !isContainerID(segment) {
+			runtime = ""
+			continue
+		}
+		switch {
+		case strings.Contains(path, "kubepods"):
+			runtime = RuntimeKubernetes
+		case runtime == "" && strings.Contains(path, "docker"):
+			runtime = RuntimeDocker
+		case runtime == "" && strings.Contains(path, "libpod"):
+			runtime = RuntimePodman
+		}
+		return segment, runtime
+	}
+	return "", ""
+}
+
+// isContainerID reports whether `s` looks like a container ID, which every OCI runtime
+// makes 64 hex characters
+func isContainerID(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,112 @@
+package gtm
+
+import (
+	"context"
+	"github.com/shirou/gopsutil/v4/mem"
+	"sync"
+)
+
+// collectCall is a collector run that callers with a context wait on
+type collectCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+var (
+	collectCalls   = map[string]*collectCall{}
+	collectCallMut sync.Mutex
+)
+
+// collectContext runs a collector until it returns or ctx is done, whichever comes
+// first. A collector that is still running when ctx is done (ie. stuck on a stale NFS
+// mount) is left to finish in the background and fills the cache when it does. Callers
+// arriving meanwhile wait on the same run, so a hung collector doesn't pile up
+// goroutines
+func collectContext[T any](ctx context.Context, key string,
+	collect func() (T, error)) (T, error) {
+	var zero T
+	if err := ctx.Err(); err != nil {
+		return zero, err
+	}
+
+	collectCallMut.Lock()
+	call, running := collectCalls[key]
+	if !running {
+		call = &collectCall{done: make(chan struct{})}
+		collectCalls[key] = call
+		go func() {
+			value, err := collect()
+			collectCallMut.Lock()
+			call.value, call.err = value, err
+			delete(collectCalls, key)
+			collectCallMut.Unlock()
+			close(call.done)
+		}()
+	}
+	collectCallMut.Unlock()
+
+	select {
+	case <-call.done:
+		value, _ := call.value.(T)
+		return value, call.err
+	case <-ctx.Done():
+		return zero, ctx.Err()
+	}
+}
+
+// GetCPUStatsContext is GetCPUStats, but returns ctx.Err() when ctx is done first
+func GetCPUStatsContext(ctx context.Context) (CPUStats, error) {
+	return collectContext(ctx, "cpu_stats", GetCPUStats)
+}
+
+// GetMemoryStatsContext is GetMemoryStats, but returns ctx.Err() when ctx is done first
+func GetMemoryStatsContext(ctx context.Context) (*mem.VirtualMemoryStat, error) {
+	return collectContext(ctx, "memory_stats", GetMemoryStats)
+}
+
+// GetDisksStatsContext is GetDisksStats, but returns ctx.Err() when ctx is done first,
+// ie. when a network mount doesn't answer
+func GetDisksStatsContext(ctx context.Context) ([]DiskStats, error) {
+	return collectContext(ctx, "disks_stats", GetDisksStats)
+}
+
+// GetAllDisksStatsContext is GetAllDisksStats, but returns ctx.Err() when ctx is done
+// first
+func GetAllDisksStatsContext(ctx context.Context) ([]DiskStats, error) {
+	return collectContext(ctx, "all_disks_stats", GetAllDisksStats)
+}
+
+// GetNetworkStatsContext is GetNetworkStats, but returns ctx.Err() when ctx is done
+// first
+func GetNetworkStatsContext(ctx context.Context) ([]NetStats, error) {
+	return collectContext(ctx, "network_stats", GetNetworkStats)
+}
+
+// GetAllNetworkStatsContext is GetAllNetworkStats, but returns ctx.Err() when ctx is
+// done first
+func GetAllNetworkStatsContext(ctx context.Context) ([]NetStats, error) {
+	return collectContext(ctx, "all_network_stats", GetAllNetworkStats)
+}
+
+// GetGPUStatsContext is GetGPUStats, but returns ctx.Err() when ctx is done first, ie.
+// when nvidia-smi hangs on a wedged driver
+func GetGPUStatsContext(ctx context.Context) ([]GPUStats, error) {
+	return collectContext(ctx, "gpu_stats", GetGPUStats)
+}
+
+// GetHostInfoContext is GetHostInfo, but returns ctx.Err() when ctx is done first
+func GetHostInfoContext(ctx context.Context) (HostInfo, error) {
+	return collectContext(ctx, "host_info", GetHostInfo)
+}
+
+// GetProcessesContext is GetProcesses, but returns ctx.Err() when ctx is done first
+func GetProcessesContext(ctx context.Context) ([]ProcStats, error) {
+	return collectContext(ctx, "processes", GetProcesses)
+}
+
+// GetDiskHealthContext is GetDiskHealth, but returns ctx.Err() when ctx is done first,
+// ie. when smartctl waits on a drive that is spinning up
+func GetDiskHealthContext(ctx context.Context) ([]DiskHealth, error) {
+	return collectContext(ctx, "disk_health", GetDiskHealth)
+}
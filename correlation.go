@@ -0,0 +1,104 @@
+package gtm
+
+import (
+	"errors"
+	"time"
+)
+
+// CORRELATION_MAX_POINTS caps the number of points on the common time axis, so a tiny
+// step over a long window can't allocate millions of points
+const CORRELATION_MAX_POINTS = 10_000
+
+// Resample decides how the samples of a metric inside one step are combined
+type Resample string
+
+const (
+	ResampleMean Resample = "mean"
+	ResampleMax  Resample = "max"
+	ResampleMin  Resample = "min"
+	ResampleLast Resample = "last"
+)
+
+// Correlation holds several metric histories resampled onto the same time axis, so
+// Series[name][i] is the value of each metric at Timestamps[i]. A nil value means the
+// metric has no samples in that step. Annotations are the ones that apply to any of the
+// metrics within the window
+type Correlation struct {
+	Timestamps  []time.Time           `json:"timestamps"`
+	Step        time.Duration         `json:"step"`
+	Resample    Resample              `json:"resample"`
+	Series      map[string][]*float64 `json:"series"`
+	Annotations []Annotation          `json:"annotations,omitempty"`
+}
+
+// GetCorrelation resamples the history of every metric (see GetMetricHistoryNames) in
+// `names` over the last `window` onto a common time axis with one point per `step`,
+// ie. GPU temperature vs power over the same 10 minutes. Every point is the start of
+// its step. Unknown metrics are returned as a series of nil values
+func GetCorrelation(names []string, window time.Duration, step time.Duration,
+	resample Resample) (Correlation, error) {
+
+	if len(names) == 0 {
+		return Correlation{}, errors.New("no metrics to correlate")
+	}
+	if window <= 0 || step <= 0 {
+		return Correlation{}, errors.New("window and step must be positive")
+	}
+	if window/step > CORRELATION_MAX_POINTS {
+		return Correlation{}, errors.New("too many points, use a larger step")
+	}
+	if resample == "" {
+		resample = ResampleMean
+	}
+
+	end := GetClock().Now().Truncate(step)
+	start := end.Add(-window)
+	points := int(window / step)
+
+	correlation := Correlation{
+		Timestamps:  make([]time.Time, points),
+		Step:        step,
+		Resample:    resample,
+		Series:      make(map[string][]*float64, len(names)),
+		Annotations: GetAnnotations(window, names...),
+	}
+	for i := range points {
+		correlation.Timestamps[i] = start.Add(time.Duration(i) * step)
+	}
+
+	for _, name := range names {
+		series := make([]*float64, points)
+		counts := make([]int, points)
+		// the window is extended by one step, since `end` was truncated
+		history, _ := GetMetricHistory(name, window+step)
+		for i, ts := range history.Timestamps {
+			if ts.Before(start) || !ts.Before(end) {
+				continue
+			}
+			point := int(ts.Sub(start) / step)
+			series[point] = resampleValue(series[point], counts[point], history.Values[i],
+				resample)
+			counts[point]++
+		}
+		correlation.Series[name] = series
+	}
+	return correlation, nil
+}
+
+func resampleValue(current *float64, count int, value float64, resample Resample) *float64 {
+	if current == nil {
+		return &value
+	}
+	switch resample {
+	case ResampleMax:
+		*current = max(*current, value)
+	case ResampleMin:
+		*current = min(*current, value)
+	case ResampleLast:
+		*current = value
+	default:
+		// running mean
+		*current += (value - *current) / float64(count+1)
+	}
+	return current
+}
@@ -2,20 +2,18 @@ package gtm
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/euheimr/ringbuffer"
-	"github.com/shirou/gopsutil/v4/cpu"
 	"github.com/shirou/gopsutil/v4/disk"
 	"github.com/shirou/gopsutil/v4/host"
 	"github.com/shirou/gopsutil/v4/mem"
 	"github.com/shirou/gopsutil/v4/net"
-	"golang.org/x/sys/windows"
-	"log/slog"
 	"math"
-	"os/exec"
-	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -49,6 +47,11 @@ const (
 	PROCS_UPDATE_INTERVAL      = time.Second
 )
 
+// NET_DEFAULT_EXCLUDE hides loopback and virtual interfaces (docker, libvirt, Hyper-V
+// and WSL switches) from the network stats unless NET_EXCLUDE is set in the config
+var NET_DEFAULT_EXCLUDE = []string{"lo", "lo0", "Loopback*", "docker*", "veth*", "br-*",
+	"virbr*", "vEthernet*", "cni*", "flannel*"}
+
 type CPU struct {
 	Id            int    `json:"id"`
 	Name          string `json:"name"`
@@ -57,33 +60,166 @@ type CPU struct {
 	CountLogical  int    `json:"count_logical"`
 }
 
+// Every percentage in gtm uses a 0-100 scale and is rounded with RoundStat
 type CPUStats struct {
-	UsagePercent float64
+	UsagePercent float64 `json:"usage_percent"`
+	// CoreUsagePercent is the usage of every logical core, nil when the CPU source
+	//	can't read them (see CPUCoreSource)
+	CoreUsagePercent []float64 `json:"core_usage_percent,omitempty"`
+}
+
+// MemoryStats is the memory usage in the JSON of Snapshot and Capture. It's gtm's own
+// copy of the fields of gopsutil's mem.VirtualMemoryStat that every OS reports, so the
+// JSON doesn't change with gopsutil
+type MemoryStats struct {
+	Total       uint64  `json:"total"`
+	Available   uint64  `json:"available"`
+	Used        uint64  `json:"used"`
+	UsedPercent float64 `json:"used_percent"`
+	Free        uint64  `json:"free"`
+	SwapTotal   uint64  `json:"swap_total"`
+	SwapFree    uint64  `json:"swap_free"`
+}
+
+// newMemoryStats copies the memory usage, nil stays nil
+func newMemoryStats(stats *mem.VirtualMemoryStat) *MemoryStats {
+	if stats == nil {
+		return nil
+	}
+	return &MemoryStats{
+		Total:       stats.Total,
+		Available:   stats.Available,
+		Used:        stats.Used,
+		UsedPercent: RoundStat(stats.UsedPercent),
+		Free:        stats.Free,
+		SwapTotal:   stats.SwapTotal,
+		SwapFree:    stats.SwapFree,
+	}
 }
 
 type DiskStats struct {
 	Mountpoint    string         `json:"mountpoint"`
+	Alias         string         `json:"alias,omitempty"`
 	Device        string         `json:"device"`
 	FSType        FileSystemType `json:"fs_type"`
 	IsVirtualDisk bool           `json:"is_virtual_disk"`
+	IsEncrypted   bool           `json:"is_encrypted"`
 	Free          uint64         `json:"free"`
 	Used          uint64         `json:"used"`
 	UsedPercent   float64        `json:"used_percent"`
 	Total         uint64         `json:"total"`
+	// ReadBytesPerSec and WriteBytesPerSec are computed from the IO counters of the
+	//	previous fetch, so they are always 0 on the first fetch
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec"`
+	// GrowthBytesPerDay is projected from the disk history (see GetDiskHistory). A
+	//	negative value means the disk is being freed up
+	GrowthBytesPerDay float64 `json:"growth_bytes_per_day"`
+	// DaysUntilFull is -1 when the disk isn't growing or there isn't enough history yet
+	DaysUntilFull float64 `json:"days_until_full"`
+	// Error is set when the usage of this mountpoint could not be read. Every other
+	//	field except Mountpoint, Device & FSType is zero when Error is set
+	Error string `json:"error,omitempty"`
+}
+
+// DiskError is returned (joined with any others) by GetDisksStats for every mountpoint
+// that failed to be read
+type DiskError struct {
+	Mountpoint string
+	Err        error
 }
 
+func (e *DiskError) Error() string {
+	return "disk " + e.Mountpoint + ": " + e.Err.Error()
+}
+
+func (e *DiskError) Unwrap() error { return e.Err }
+
+// GPUError is returned (joined with any others) by GetGPUStats for every GPU whose stats
+// failed to be read
+type GPUError struct {
+	Id  int32
+	Err error
+}
+
+func (e *GPUError) Error() string {
+	return "gpu " + strconv.FormatInt(int64(e.Id), 10) + ": " + e.Err.Error()
+}
+
+func (e *GPUError) Unwrap() error { return e.Err }
+
 type GPU struct {
 	Name   string
 	Vendor string
 }
 
 type GPUStats struct {
-	Id          int32   `json:"card-id"`
-	Load        float64 `json:"load"`
-	MemoryUsage float64 `json:"memoryUsage"`
-	MemoryTotal float64 `json:"memoryTotal"`
+	Id          int32   `json:"id"`
+	Alias       string  `json:"alias,omitempty"`
+	Load        float64 `json:"load"` // percent, 0-100
+	MemoryUsage float64 `json:"memory_usage"`
+	MemoryTotal float64 `json:"memory_total"`
 	Power       float64 `json:"power"`
 	Temperature int32   `json:"temperature"`
+	// Error is set when some stats of this GPU could not be read, those stats are 0
+	Error string `json:"error,omitempty"`
+}
+
+// HostInfo describes the host and its OS. It's gtm's own copy of gopsutil's
+// host.InfoStat, so callers don't depend on gopsutil, and is never nil
+type HostInfo struct {
+	Hostname        string `json:"hostname"`
+	OS              string `json:"os"`               // ie. linux, windows
+	Platform        string `json:"platform"`         // ie. ubuntu, Windows 11 Pro
+	PlatformFamily  string `json:"platform_family"`  // ie. debian, rhel
+	PlatformVersion string `json:"platform_version"` // version of the complete OS
+	KernelVersion   string `json:"kernel_version"`
+	KernelArch      string `json:"kernel_arch"` // ie. x86_64, arm64
+	// OSName and OSVersion are normalized across OSes, ie. "Ubuntu" "24.04", "Windows 11"
+	//	"23H2" or "macOS" "14.5". OSPrettyName is the full name, ie. "Ubuntu 24.04.1 LTS"
+	//	or "Windows 11 Pro 23H2". OSBuild is the Windows or macOS build, ie. "22631.3880"
+	OSName       string        `json:"os_name"`
+	OSVersion    string        `json:"os_version"`
+	OSPrettyName string        `json:"os_pretty_name"`
+	OSBuild      string        `json:"os_build,omitempty"`
+	BootTime     time.Time     `json:"boot_time"`
+	Uptime       time.Duration `json:"uptime"`
+	Procs        uint64        `json:"procs"`
+	// VirtualizationSystem and VirtualizationRole ("guest" or "host") are what gopsutil
+	//	detected, see GetPlatform for a more thorough detection
+	VirtualizationSystem string `json:"virtualization_system"`
+	VirtualizationRole   string `json:"virtualization_role"`
+	HostID               string `json:"host_id"`
+}
+
+// NetStats are the counters of a single network interface. The rates are computed from
+// the counters of the previous fetch, so they are always 0 on the first fetch
+type NetStats struct {
+	Name                  string  `json:"name"`
+	Alias                 string  `json:"alias,omitempty"`
+	BytesSent             uint64  `json:"bytes_sent"`
+	BytesRecv             uint64  `json:"bytes_recv"`
+	PacketsSent           uint64  `json:"packets_sent"`
+	PacketsRecv           uint64  `json:"packets_recv"`
+	ErrIn                 uint64  `json:"err_in"`
+	ErrOut                uint64  `json:"err_out"`
+	DropIn                uint64  `json:"drop_in"`
+	DropOut               uint64  `json:"drop_out"`
+	UploadBytesPerSec     float64 `json:"upload_bytes_per_sec"`
+	DownloadBytesPerSec   float64 `json:"download_bytes_per_sec"`
+	UploadPacketsPerSec   float64 `json:"upload_packets_per_sec"`
+	DownloadPacketsPerSec float64 `json:"download_packets_per_sec"`
+	// Rising error or drop rates are the usual sign of a failing NIC, a bad cable or a
+	//	saturated switch port
+	ErrInPerSec   float64 `json:"err_in_per_sec"`
+	ErrOutPerSec  float64 `json:"err_out_per_sec"`
+	DropInPerSec  float64 `json:"drop_in_per_sec"`
+	DropOutPerSec float64 `json:"drop_out_per_sec"`
+	// The bit rates are the byte rates * 8, whatever NET_UNITS is in the config
+	UploadBitsPerSec   float64 `json:"upload_bits_per_sec"`
+	DownloadBitsPerSec float64 `json:"download_bits_per_sec"`
+	// IPSplit is nil where the OS doesn't count IPv6 traffic per interface
+	IPSplit *IPSplit `json:"ip_split,omitempty"`
 }
 
 type GPURingBuffer struct {
@@ -94,36 +230,63 @@ type GPURingBuffer struct {
 	Temperature *ringbuffer.RingBuffer[float32]
 }
 
-var (
-	cpuInfo    []CPU
-	cpuStats   []CPUStats
-	disksStats []DiskStats
-	gpuInfo    *GPU
-	gpuStats   []GPUStats
-	hostInfo   *host.InfoStat
-	memInfo    *mem.VirtualMemoryStat
-	netInfo    []net.IOCountersStat
-)
+// The caches of a Monitor. Every collector guards its cache with its own mutex, so the
+// Get functions are safe to call from any goroutine. Returned slices and pointers are
+// shared with the cache and other callers, so treat them as read-only
+type cpuCache struct {
+	mut  sync.Mutex
+	info []CPU
+	// stats is the latest sample only, the usage over time is in the metric history
+	stats     CPUStats
+	lastFetch time.Time
+}
 
-var (
-	lastFetchCPU  time.Time
-	lastFetchDisk time.Time
-	lastFetchGPU  time.Time
-	lastFetchHost time.Time
-	lastFetchMem  time.Time
-	lastFetchNet  time.Time
+type gpuCache struct {
+	mut  sync.Mutex
+	info GPU
+	// stats is the latest read of every GPU, it's replaced by every fetch
+	stats     []GPUStats
+	lastFetch time.Time
+	// found is set once probeGPU found the SMI tool of a vendor
+	found atomic.Bool
+	// flight coalesces the fetches of callers that found the stats expired together
+	flight flight[[]GPUStats]
+
+	procMut       sync.Mutex
+	procMemory    map[int32]uint64
 	lastFetchProc time.Time
-)
+	procFlight    flight[map[int32]uint64]
+}
 
-var (
-	hasGPU   bool
-	hostname string
-)
+type hostCache struct {
+	mut       sync.Mutex
+	info      HostInfo
+	hostname  string
+	lastFetch time.Time
+}
 
-func init() {
-	gpuInfo = &GPU{}
+type memCache struct {
+	mut       sync.Mutex
+	stats     *mem.VirtualMemoryStat
+	lastFetch time.Time
 }
 
+type netCache struct {
+	mut       sync.Mutex
+	stats     []NetStats
+	counters  map[string]net.IOCountersStat
+	ipSplits  map[string]*IPSplit
+	lastFetch time.Time
+	// expired forces the next fetch. lastFetch can't be reset instead, since the rates
+	//	are calculated from it
+	expired bool
+}
+
+var (
+	lastFetchProc time.Time
+	procMut       sync.Mutex
+)
+
 func ConvertBytesToGB(bytes uint64, rounded bool) (result float64) {
 	result = float64(bytes) / GIGABYTE
 	if rounded {
@@ -142,17 +305,26 @@ func ConvertBytesToGiB(bytes uint64, rounded bool) (result float64) {
 	return result
 }
 
-func GetCPUInfo() []CPU {
-	if cpuInfo != nil {
-		return cpuInfo
+// GetCPUInfo returns the CPU sockets. They're only read once, unless reading them
+// failed
+func GetCPUInfo() ([]CPU, error) { return defaultMonitor.CPUInfo() }
+
+// CPUInfo is GetCPUInfo for the Monitor
+func (m *Monitor) CPUInfo() ([]CPU, error) {
+	m.cpu.mut.Lock()
+	defer m.cpu.mut.Unlock()
+
+	if m.cpu.info != nil {
+		return m.cpu.info, nil
 	}
 
-	cInfo, err := cpu.Info()
+	cInfo, err := m.sources.cpu.Info()
 	if err != nil {
-		slog.Error("Failed to retrieve cpu.Info()! " + err.Error())
+		m.collectorError(CollectorCPU, "Failed to retrieve the CPU info!", err)
+		return nil, err
 	}
 	for _, c := range cInfo {
-		slog.Debug("cpu.Info(): "+c.String(), "socketCount", len(cInfo))
+		m.log().Debug("cpu.Info(): "+c.String(), "socketCount", len(cInfo))
 		info := &CPU{
 			// model name doesn't change with each syscall... so cache it here
 			Name:          c.ModelName,
@@ -160,13 +332,14 @@ func GetCPUInfo() []CPU {
 			CountPhysical: int(c.Cores),
 			CountLogical:  0,
 		}
-		cpuInfo = append(cpuInfo, *info)
+		m.cpu.info = append(m.cpu.info, *info)
 	}
-	return cpuInfo
+	return m.cpu.info, nil
 }
 
-func formatCPUModelName(cpuName string) string {
-	if cpuInfo[0].Vendor == "GenuineIntel" {
+func formatCPUModelName(c CPU) string {
+	cpuName := c.Name
+	if c.Vendor == "GenuineIntel" {
 		cpuName = strings.ReplaceAll(cpuName, "(R)", "")
 		cpuName = strings.ReplaceAll(cpuName, "(TM)", "")
 		cpuName = strings.ReplaceAll(cpuName, "CPU @ ", "@")
@@ -177,11 +350,17 @@ func formatCPUModelName(cpuName string) string {
 	return cpuName
 }
 
-func GetCPUModelName() string {
-	if cpuInfo == nil {
-		GetCPUInfo()
+// GetCPUModelName returns the shortened model name of the first CPU, or "" when the
+// CPUs can't be read
+func GetCPUModelName() string { return defaultMonitor.CPUModelName() }
+
+// CPUModelName is GetCPUModelName for the Monitor
+func (m *Monitor) CPUModelName() string {
+	info, err := m.CPUInfo()
+	if err != nil || len(info) == 0 {
+		return ""
 	}
-	return formatCPUModelName(cpuInfo[0].Name)
+	return formatCPUModelName(info[0])
 }
 
 func (c CPU) String() string {
@@ -194,35 +373,61 @@ func (c CPU) JSON(indent bool) string {
 	if indent {
 		out, err := json.MarshalIndent(c, "", "  ")
 		if err != nil {
-			slog.Error("Failed to marshal indent JSON from struct CPU{} !" + err.Error())
+			logger().Error("Failed to marshal indent JSON from struct CPU{} !" + err.Error())
 		}
 		return string(out)
 	} else {
 		out, err := json.Marshal(c)
 		if err != nil {
-			slog.Error("Failed to marshal JSON from struct CPU{} !" + err.Error())
+			logger().Error("Failed to marshal JSON from struct CPU{} !" + err.Error())
 		}
 		return string(out)
 	}
 }
 
-func GetCPUStats() []CPUStats {
-	if len(cpuStats) > 0 && time.Since(lastFetchCPU) < CPU_STATS_UPDATE_INTERVAL {
-		return cpuStats
+// GetCPUStats returns the current CPU usage. When the usage can't be read, the last
+// sample is returned with the error. The usage over time is in the metric history, see
+// GetMetricHistory(MetricCPUUsage, ...)
+func GetCPUStats() (CPUStats, error) { return defaultMonitor.CPUStats() }
+
+// CPUStats is GetCPUStats for the Monitor
+func (m *Monitor) CPUStats() (CPUStats, error) {
+	m.cpu.mut.Lock()
+	defer m.cpu.mut.Unlock()
+
+	if !m.cpu.lastFetch.IsZero() &&
+		GetClock().Since(m.cpu.lastFetch) < m.interval(CollectorCPU) {
+		return m.cpu.stats, nil
+	}
+	start := m.startCollect(CollectorCPU)
+	cpuPct, err := m.sources.cpu.Percent()
+	if err == nil && len(cpuPct) == 0 {
+		err = errors.New("cpu.Percent() returned no usage")
 	}
-	cpuPct, err := cpu.Percent(0, false)
 	if err != nil {
-		slog.Error("Failed to fetch cpu.Percent() !" + err.Error())
+		m.collectorError(CollectorCPU, "Failed to fetch cpu.Percent() !", err)
+		m.collected(CollectorCPU, start, nil, err)
+		return m.cpu.stats, err
 	}
-	lastFetchCPU = time.Now()
-
-	stats := CPUStats{
-		UsagePercent: cpuPct[0],
+	m.cpu.lastFetch = GetClock().Now()
+	m.cpu.stats = CPUStats{
+		UsagePercent: RoundStat(cpuPct[0]),
+	}
+	m.recordMetric(MetricCPUUsage, m.cpu.lastFetch, m.cpu.stats.UsagePercent)
+	if source, ok := m.sources.cpu.(CPUCoreSource); ok {
+		cores, err := source.PercentPerCore()
+		if err != nil {
+			m.log().Debug("Failed to fetch cpu.Percent() per core! " + err.Error())
+		}
+		for core, usage := range cores {
+			m.cpu.stats.CoreUsagePercent = append(m.cpu.stats.CoreUsagePercent,
+				RoundStat(usage))
+			m.recordMetric(CPUCoreMetric(core), m.cpu.lastFetch, RoundStat(usage))
+		}
 	}
-	// TODO: fetch cpu usage and append to data
-	cpuStats = append(cpuStats, stats)
+	m.collected(CollectorCPU, start, m.cpu.stats, nil)
 
-	return cpuStats
+	return m.cpu.stats, nil
 }
 
 func convertFSType(fsType string) FileSystemType {
@@ -255,111 +460,236 @@ func convertFSType(fsType string) FileSystemType {
 	}
 }
 
-func isVirtualDisk(path string) bool {
-	switch runtime.GOOS {
-	case "windows":
-		d, err := windows.UTF16PtrFromString(path)
-		if err != nil {
-			slog.Error("Failed to get UTF16 pointer from string: " + path + "! " +
-				err.Error())
-		}
-		driveType := windows.GetDriveType(d)
-
-		// 2: DRIVE_REMOVABLE 3: DRIVE_FIXED 4: DRIVE_REMOTE 5: DRIVE_CDROM 6: DRIVE_RAMDISK
-		switch driveType {
-		case windows.DRIVE_RAMDISK:
-			slog.Debug(path + " is a RAMDISK")
-			return true
-		case windows.DRIVE_FIXED:
-			// disk.IOCounters(C:) ALWAYS errors out on Windows, HOWEVER, we do not get an
-			//	empty struct on a valid DRIVE_FIXED device
-			io, _ := disk.IOCounters(path)
-			switch len(io) {
-			case 0:
-				// This is a VERY hacky way of working around detecting Google Drive.
-				//	GDrive is seen as a "real" drive in Windows for some reason, and
-				//	not as a RAMDISK (Virtual Hard Disk; aka. VHD).
-				// But if we try to call disk.IOCounters() on it, we will just get an
-				//	empty struct (length of 0) back, which indicates it IS a RAMDISK.
-				// This is the only way I've been able to detect a mounted Google
-				//	Drive :(
-				slog.Debug("drive " + path + " IS a RAMDISK")
-				return true
-			default:
-				// Any other case that is len(io) > 0 means it is not a RAMDISK
-				slog.Debug("disk.IOCounters(" + path + "): " + io[path].String())
-				return false
-			}
-		default:
-			slog.Debug(path + " is not a RAMDISK")
-			return false
-		}
-	default:
-		// TODO: do RAMDISK checks for macOS & Linux !
-		slog.Debug("Not on windows... ignoring RAMDISK check ...")
-		return false
+// diskCollection holds the cache of one of the two disk collection modes: physical
+// partitions only (GetDisksStats), or every partition (GetAllDisksStats)
+type diskCollection struct {
+	mut       sync.Mutex
+	all       bool
+	stats     []DiskStats
+	err       error
+	io        map[string]disk.IOCountersStat
+	lastFetch time.Time
+	// expired forces the next fetch. lastFetch can't be reset instead, since the IO
+	//	rates are calculated from it
+	expired bool
+}
+
+// GetDisksStats returns the stats of physical partitions, or every partition when
+// DISK_ALL_PARTITIONS is enabled in the config. DISK_INCLUDE & DISK_EXCLUDE are applied.
+//
+// Reading a single mountpoint can fail without failing the others. Those mountpoints
+// are still returned with DiskStats.Error set, and the returned error joins a *DiskError
+// for each of them. If the partitions can't be listed at all, the stats are nil
+func GetDisksStats() ([]DiskStats, error) { return defaultMonitor.DisksStats() }
+
+// GetAllDisksStats is like GetDisksStats, but also includes pseudo filesystems, bind
+// mounts, and other partitions that are not backed by a physical device
+func GetAllDisksStats() ([]DiskStats, error) { return defaultMonitor.AllDisksStats() }
+
+// DisksStats is GetDisksStats for the Monitor
+func (m *Monitor) DisksStats() ([]DiskStats, error) {
+	if Cfg.DiskAllPartitions {
+		return m.AllDisksStats()
 	}
+	return m.disks.fetch(m)
+}
+
+// AllDisksStats is GetAllDisksStats for the Monitor
+func (m *Monitor) AllDisksStats() ([]DiskStats, error) {
+	return m.allDisks.fetch(m)
+}
+
+// expire makes the next fetch read the disks again
+func (c *diskCollection) expire() {
+	c.mut.Lock()
+	c.expired = true
+	c.mut.Unlock()
 }
 
-func GetDisksStats() []DiskStats {
-	if time.Since(lastFetchDisk) < DISK_STATS_UPDATE_INTERVAL && len(disksStats) > 0 {
-		return disksStats
+func (c *diskCollection) fetch(m *Monitor) ([]DiskStats, error) {
+	c.mut.Lock()
+	defer c.mut.Unlock()
+
+	if !c.expired && GetClock().Since(c.lastFetch) < m.interval(CollectorDisk) &&
+		len(c.stats) > 0 {
+		return c.stats, c.err
 	}
 
-	dInfo, err := disk.Partitions(false)
+	start := c.startCollect(m)
+	var errs []error
+	dInfo, err := m.sources.disk.Partitions(c.all)
 	if err != nil {
-		slog.Error("Failed to retrieve disk.Partitions()! " + err.Error())
+		m.collectorError(CollectorDisk, "Failed to retrieve disk.Partitions()!", err)
+		if len(dInfo) == 0 {
+			c.collected(m, start, nil, err)
+			return nil, err
+		}
+		// Some platforms return the partitions they could list alongside the error
+		errs = append(errs, err)
+	}
+	fetchTime := GetClock().Now()
+
+	// IO counters are keyed by device name (ie. "sda1" on linux or "C:" on windows)
+	var ioCounters map[string]disk.IOCountersStat
+	if IsCollectorEnabled(CollectorDiskIO) {
+		if ioCounters, err = m.sources.disk.IOCounters(); err != nil {
+			m.log().Debug("Failed to retrieve all disk.IOCounters()! " + err.Error())
+		}
 	}
-	lastFetchDisk = time.Now()
+	ioElapsed := sampleSeconds(c.lastFetch, fetchTime)
 
-	disksStats = make([]DiskStats, len(dInfo))
-	for i, dsk := range dInfo {
-		usage, err := disk.Usage(dsk.Mountpoint)
+	filter := m.diskFilter()
+	stats := make([]DiskStats, 0, len(dInfo))
+	for _, dsk := range dInfo {
+		if !filter.Match(dsk.Mountpoint, dsk.Device, dsk.Fstype) {
+			continue
+		}
+
+		usage, err := m.sources.disk.Usage(dsk.Mountpoint)
 		if err != nil {
-			slog.Error("Failed to retrieve disk.Usage(" + dsk.Mountpoint + ")! " +
-				err.Error())
+			m.collectorError(CollectorDisk,
+				"Failed to retrieve disk.Usage("+dsk.Mountpoint+")!",
+				err)
+			errs = append(errs, &DiskError{Mountpoint: dsk.Mountpoint, Err: err})
+			stats = append(stats, DiskStats{
+				Mountpoint:    dsk.Mountpoint,
+				Alias:         diskAlias(dsk.Mountpoint, dsk.Device),
+				Device:        dsk.Device,
+				FSType:        convertFSType(dsk.Fstype),
+				DaysUntilFull: -1,
+				Error:         err.Error(),
+			})
+			continue
 		}
-		slog.Debug("disk: " + dsk.String())
-		slog.Debug("usage: " + usage.String())
+		m.log().Debug("disk: " + dsk.String())
+		m.log().Debug("usage: " + usage.String())
 
 		// convert filesystem type to integer
 		fsType := convertFSType(usage.Fstype)
 		isVDisk := isVirtualDisk(dsk.Mountpoint)
-		usedPercent := math.Round((usage.UsedPercent*100)/100) / 100
 
-		stats := DiskStats{
+		stat := DiskStats{
 			Mountpoint:    dsk.Mountpoint,
+			Alias:         diskAlias(dsk.Mountpoint, dsk.Device),
 			Device:        dsk.Device,
 			FSType:        fsType,
 			IsVirtualDisk: isVDisk,
+			IsEncrypted:   getDiskEncrypted(dsk.Mountpoint, dsk.Device, dsk.Fstype),
 			Free:          usage.Free,
 			Used:          usage.Used,
-			UsedPercent:   usedPercent,
+			UsedPercent:   RoundStat(usage.UsedPercent),
 			Total:         usage.Total,
 		}
-		disksStats[i] = stats
+
+		deviceName := strings.TrimPrefix(dsk.Device, "/dev/")
+		if current, ok := ioCounters[deviceName]; ok {
+			if previous, ok := c.io[deviceName]; ok && ioElapsed > 0 {
+				stat.ReadBytesPerSec = RoundStat(ratePerSecond(previous.ReadBytes,
+					current.ReadBytes, ioElapsed))
+				stat.WriteBytesPerSec = RoundStat(ratePerSecond(previous.WriteBytes,
+					current.WriteBytes, ioElapsed))
+			}
+		}
+		if m.global {
+			recordDiskHistory(fetchTime, stat)
+			growth, daysUntilFull, _ := projectDiskFull(stat.Mountpoint, stat.Free)
+			stat.GrowthBytesPerDay = RoundStat(growth)
+			stat.DaysUntilFull = RoundStat(daysUntilFull)
+		}
+		m.recordMetric(DiskMetric(stat.Mountpoint, MetricDiskUsed), fetchTime,
+			stat.UsedPercent)
+		m.recordMetric(DiskMetric(stat.Mountpoint, MetricDiskRead), fetchTime,
+			stat.ReadBytesPerSec)
+		m.recordMetric(DiskMetric(stat.Mountpoint, MetricDiskWrite), fetchTime,
+			stat.WriteBytesPerSec)
+		stats = append(stats, stat)
+	}
+	c.stats = stats
+	c.err = errors.Join(errs...)
+	c.io = ioCounters
+	c.lastFetch = fetchTime
+	c.expired = false
+	c.collected(m, start, c.stats, c.err)
+
+	return c.stats, c.err
+}
+
+// startCollect and collected only run the hooks and publish the update of the
+// collection GetDisksStats returns, since both collections are the disk collector
+func (c *diskCollection) startCollect(m *Monitor) time.Time {
+	if c.all == Cfg.DiskAllPartitions {
+		return m.startCollect(CollectorDisk)
 	}
-	return disksStats
+	return GetClock().Now()
 }
 
-func HasGPU() bool {
-	// Booleans initialize to false. If hasGPU is true, then we have checked already.
-	//	Just return the true value instead of calling GPU utils again
-	if hasGPU {
-		return hasGPU
+func (c *diskCollection) collected(m *Monitor, start time.Time, stats []DiskStats,
+	err error) {
+	if c.all == Cfg.DiskAllPartitions {
+		m.collected(CollectorDisk, start, stats, err)
 	}
-	if err := exec.Command("nvidia-smi").Run(); err == nil {
-		gpuInfo.Vendor = "nvidia"
-		hasGPU = true
-		return hasGPU
+}
+
+// ratePerSecond returns the rate of change between two samples of a monotonically
+// increasing counter. A counter that went backwards (ie. a device was re-attached) is
+// treated as a reset and returns 0
+func ratePerSecond(previous uint64, current uint64, seconds float64) float64 {
+	if current < previous || seconds <= 0 {
+		return 0
+	}
+	return float64(current-previous) / seconds
+}
+
+// HasGPU reports whether the SMI tool of a GPU vendor was found. It looks again on every
+// call until a GPU is found
+func HasGPU() bool { return defaultMonitor.HasGPU() }
+
+// hasGPU reports whether the default Monitor found a GPU, without looking again like
+// HasGPU does
+func hasGPU() bool { return defaultMonitor.gpu.found.Load() }
+
+// HasGPU is the package level HasGPU for the Monitor
+func (m *Monitor) HasGPU() bool {
+	// If found is true, then we have checked already. Just return the true value instead
+	//	of calling GPU utils again
+	if m.gpu.found.Load() {
+		return true
+	}
+	if m.noGPU {
+		return false
+	}
+	if !IsCollectorEnabled(CollectorGPU) {
+		m.log().Info("HasGPU(): GPU collector is disabled in this environment")
+		return false
 	}
-	if err := exec.Command("rocm-smi").Run(); err == nil {
-		gpuInfo.Vendor = "amd"
-		hasGPU = true
-		return hasGPU
+	if m.probeGPU() {
+		return true
 	}
-	slog.Error("HasGPU(): Could not find NVIDIA or AMD GPUs installed using SMI")
-	return hasGPU
+	m.log().Error("HasGPU(): Could not find NVIDIA or AMD GPUs installed using SMI")
+	return false
+}
+
+// probeGPU looks for the SMI tool of each vendor and sets gpu.found when one is found
+func (m *Monitor) probeGPU() bool {
+	if m.noGPU {
+		return false
+	}
+	vendor := m.sources.gpu.Probe()
+	if vendor == "" {
+		return false
+	}
+	m.gpu.mut.Lock()
+	m.gpu.info.Vendor = vendor
+	m.gpu.mut.Unlock()
+	m.gpu.found.Store(true)
+	return true
+}
+
+// gpuVendor returns the vendor found by probeGPU, or "" before a GPU was found
+func (m *Monitor) gpuVendor() string {
+	m.gpu.mut.Lock()
+	defer m.gpu.mut.Unlock()
+	return m.gpu.info.Vendor
 }
 
 func (g *GPUStats) String() string {
@@ -370,190 +700,491 @@ func (g *GPUStats) String() string {
 	//memoryUsageGiB = fmt.Sprintf("%.2f", (g.MemoryUsage/g.MemoryTotal))
 
 	return fmt.Sprintf("gfx card #%v, %v%%, %v MiB, %v MiB, %vW, %v°C",
-		g.Id, int(g.Load*100), memoryUsageGiB, memoryTotalGiB, g.Power, g.Temperature)
+		g.Id, int(g.Load), memoryUsageGiB, memoryTotalGiB, g.Power, g.Temperature)
 }
 
 func (g *GPUStats) JSON(indent bool) string {
 	if indent {
 		out, err := json.MarshalIndent(g, "", "  ")
 		if err != nil {
-			slog.Error("Failed to marshal indent JSON from struct GPUStats{} ! " +
+			logger().Error("Failed to marshal indent JSON from struct GPUStats{} ! " +
 				err.Error())
 		}
 		return string(out)
 	} else {
 		out, err := json.Marshal(g)
 		if err != nil {
-			slog.Error("Failed to marshal JSON from struct GPUStats{} ! " + err.Error())
+			logger().Error("Failed to marshal JSON from struct GPUStats{} ! " + err.Error())
 		}
 		return string(out)
 	}
 }
 
-func parseGPUNvidiaStats(output []byte) []GPUStats {
+// parseGPUNvidiaStats parses the nvidia-smi stats into the name of the GPUs and the
+// stats of every GPU. A GPU that can't be fully parsed is still returned with
+// GPUStats.Error set, and the returned error joins a *GPUError for each of them. Stats
+// the GPU doesn't report ("[N/A]", ie. the power of some GeForce cards) are 0
+func parseGPUNvidiaStats(output []byte) (string, []GPUStats, error) {
 	var (
-		id          int64
-		load        int64
-		memoryUsage float64
-		memoryTotal float64
-		power       float64
-		temp        int64
-		err         error
+		name  string
+		stats []GPUStats
+		errs  []error
 	)
+	for i, line := range strings.Split(string(output), "\n") {
+		// on windows, there's a carriage return on the last stat
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		data := strings.Split(line, ", ")
+		if len(data) != 7 {
+			err := &GPUError{Id: int32(i),
+				Err: errors.New("unexpected nvidia-smi output: " + line)}
+			errs = append(errs, err)
+			stats = append(stats, GPUStats{Id: int32(i), Alias: gpuAlias(int32(i)),
+				Error: err.Err.Error()})
+			continue
+		}
 
-	info := strings.Split(string(output), "\n")
-	for _, line := range info {
-		if line != "" {
-			data := strings.Split(line, ", ")
-			gpuInfo.Name = data[1]
-
-			if id, err = strconv.ParseInt(data[0], 10, 32); err != nil {
-				slog.Error("Failed to parse GPU Id from string -> int ! " + err.Error())
-			}
-			if load, err = strconv.ParseInt(data[2], 10, 32); err != nil {
-				slog.Error("Failed to parse GPU Load from string -> int ! " + err.Error())
-			}
-			if memoryUsage, err = strconv.ParseFloat(data[3], 64); err != nil {
-				slog.Error("Failed to parse float: memory.usage !" + err.Error())
-				memoryUsage = 0.0
-			}
-			if memoryTotal, err = strconv.ParseFloat(data[4], 64); err != nil {
-				slog.Error("Failed to parse float: memory.total !" + err.Error())
-				memoryTotal = 0.0
-			}
-			if power, err = strconv.ParseFloat(data[5], 64); err != nil {
-				slog.Error("Failed to parse float: power !" + err.Error())
-			}
-
-			// on windows, there's a carriage return on the last stat
-			t := strings.ReplaceAll(data[6], "\r", "")
-			if temp, err = strconv.ParseInt(t, 10, 32); err != nil {
-				slog.Error("Failed to parse float: temp !" + err.Error())
+		var fieldErrs []error
+		parse := func(field string, value string) float64 {
+			if value == "[N/A]" {
+				return 0
 			}
-
-			gpu := GPUStats{
-				Id:          int32(id),
-				Load:        float64(load) / 100,
-				MemoryUsage: memoryUsage,
-				MemoryTotal: memoryTotal,
-				Power:       power,
-				Temperature: int32(temp),
+			f, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				fieldErrs = append(fieldErrs, fmt.Errorf("%s: %w", field, err))
 			}
-			gpuStats = append(gpuStats, gpu)
+			return f
 		}
+		id, err := strconv.ParseInt(data[0], 10, 32)
+		if err != nil {
+			// the line number is the index nvidia-smi would have reported
+			id = int64(i)
+			fieldErrs = append(fieldErrs, fmt.Errorf("index: %w", err))
+		}
+		name = data[1]
+		gpu := GPUStats{
+			Id:          int32(id),
+			Alias:       gpuAlias(int32(id)),
+			Load:        parse("utilization.gpu", data[2]),
+			MemoryUsage: parse("memory.used", data[3]),
+			MemoryTotal: parse("memory.total", data[4]),
+			Power:       parse("power.draw", data[5]),
+			Temperature: int32(parse("temperature.gpu", data[6])),
+		}
+		if len(fieldErrs) > 0 {
+			err := &GPUError{Id: gpu.Id, Err: errors.Join(fieldErrs...)}
+			errs = append(errs, err)
+			gpu.Error = err.Err.Error()
+		}
+		stats = append(stats, gpu)
 	}
-	return gpuStats
+	return name, stats, errors.Join(errs...)
 }
 
-func GetGPUStats() []GPUStats {
+// GetGPUStats returns the stats of every GPU. It fails with ErrNoGPU until HasGPU found
+// a GPU, and with ErrUnsupported for AMD GPUs. When nvidia-smi fails, the last stats are
+// returned with the error.
+//
+// Reading a single GPU can fail without failing the others. Those GPUs are still
+// returned with GPUStats.Error set, and the returned error joins a *GPUError for each
+// of them
+func GetGPUStats() ([]GPUStats, error) { return defaultMonitor.GPUStats() }
+
+// GPUStats is GetGPUStats for the Monitor
+func (m *Monitor) GPUStats() ([]GPUStats, error) {
+	if m.noGPU {
+		return nil, ErrCollectorDisabled
+	}
+	m.gpu.mut.Lock()
 	// Limit getting device data to just once a second, and NOT with every UI update
-	if time.Since(lastFetchGPU) < GPU_STATS_UPDATE_INTERVAL && gpuStats != nil {
-		return gpuStats
-	}
-
-	switch gpuInfo.Vendor {
-	case "nvidia":
-		cmd := exec.Command(
-			"nvidia-smi",
-			"--query-gpu=index,name,utilization.gpu,memory.used,memory.total,"+
-				"power.draw,temperature.gpu",
-			"--format=csv,noheader,nounits")
-		data, err := cmd.Output()
+	if GetClock().Since(m.gpu.lastFetch) < m.interval(CollectorGPU) &&
+		m.gpu.stats != nil {
+		stats := m.gpu.stats
+		m.gpu.mut.Unlock()
+		return stats, nil
+	}
+	m.gpu.mut.Unlock()
+
+	return m.gpu.flight.do(m.fetchGPUStats)
+}
+
+// fetchGPUStats reads the GPUs and updates the cache. The cache is only locked once the
+// stats are read, since nvidia-smi can take seconds
+func (m *Monitor) fetchGPUStats() ([]GPUStats, error) {
+	vendor := m.gpuVendor()
+	if vendor == "" {
+		return nil, ErrNoGPU
+	}
+	start := m.startCollect(CollectorGPU)
+	name, stats, err := m.sources.gpu.Stats(vendor)
+
+	m.gpu.mut.Lock()
+	defer m.gpu.mut.Unlock()
+	if errors.Is(err, ErrUnsupported) {
+		m.collectorError(CollectorGPU, "The stats of "+vendor+" GPUs are not implemented "+
+			"yet !", nil)
+		m.gpu.lastFetch = GetClock().Now()
+		return nil, err
+	} else if err != nil && len(stats) == 0 {
+		m.collectorError(CollectorGPU, "Failed to retrieve the "+vendor+" GPU stats !", err)
+		m.collected(CollectorGPU, start, nil, err)
+		return m.gpu.stats, err
+	} else if err != nil {
+		// the GPUs that could be read are kept, with the error of the others
+		m.collectorError(CollectorGPU, "Failed to read some "+vendor+" GPU stats !", err)
+	}
+
+	now := GetClock().Now()
+	for _, gpu := range stats {
+		m.recordMetric(GPUMetric(gpu.Id, MetricGPULoad), now, gpu.Load)
+		m.recordMetric(GPUMetric(gpu.Id, MetricGPUMemoryUsed), now, gpu.MemoryUsage)
+		m.recordMetric(GPUMetric(gpu.Id, MetricGPUPower), now, gpu.Power)
+		m.recordMetric(GPUMetric(gpu.Id, MetricGPUTemperature), now,
+			float64(gpu.Temperature))
+	}
+	if m.global {
+		checkGPUAlerts(stats)
+	}
+	m.gpu.info.Name = name
+	m.gpu.stats = stats
+	m.gpu.lastFetch = now
+	m.collected(CollectorGPU, start, m.gpu.stats, err)
+	return m.gpu.stats, err
+}
+
+// gpuProcessMemory returns the GPU memory (in bytes, summed over every GPU) each
+// process uses. Only NVIDIA compute processes (ie. CUDA jobs) are listed by
+// nvidia-smi, so graphics-only processes are missing. Used memory is "[N/A]" for every
+// process on Windows GPUs in WDDM mode, so it's empty there
+func (m *Monitor) gpuProcessMemory() map[int32]uint64 {
+	m.gpu.procMut.Lock()
+	if GetClock().Since(m.gpu.lastFetchProc) < m.interval(CollectorGPU) &&
+		m.gpu.procMemory != nil {
+		memory := m.gpu.procMemory
+		m.gpu.procMut.Unlock()
+		return memory
+	}
+	m.gpu.procMut.Unlock()
+
+	memory, _ := m.gpu.procFlight.do(func() (map[int32]uint64, error) {
+		vendor := m.gpuVendor()
+		if vendor == "" {
+			return nil, nil
+		}
+		memory, err := m.sources.gpu.ProcessMemory(vendor)
+
+		m.gpu.procMut.Lock()
+		defer m.gpu.procMut.Unlock()
+		m.gpu.lastFetchProc = GetClock().Now()
 		if err != nil {
-			slog.Error("Failed to retrieve NVIDIA GPU data from nvidia-smi ! " +
-				err.Error())
-			return nil
+			m.collectorError(CollectorGPU, "Failed to retrieve the "+vendor+
+				" GPU processes !", err)
+			return m.gpu.procMemory, nil
 		}
-		//slog.Debug(data[len(data)-1].String())
-		gpuStats = parseGPUNvidiaStats(data)
-		lastFetchGPU = time.Now()
+		m.gpu.procMemory = memory
+		return m.gpu.procMemory, nil
+	})
+	return memory
+}
 
-	case "amd":
-		// TODO: write rocm-smi code for AMD gpu detection and data parsing
-		slog.Error("AMD GPU not implemented yet !")
-		lastFetchGPU = time.Now()
+// parseGPUNvidiaProcesses parses "pid, used_memory" lines, with the memory in MiB
+func parseGPUNvidiaProcesses(output []byte) map[int32]uint64 {
+	memory := map[int32]uint64{}
+	for _, line := range strings.Split(string(output), "\n") {
+		pid, used, ok := strings.Cut(strings.TrimSpace(line), ", ")
+		if !ok {
+			continue
+		}
+		id, err := strconv.ParseInt(pid, 10, 32)
+		if err != nil {
+			continue
+		}
+		mib, err := strconv.ParseUint(used, 10, 64)
+		if err != nil {
+			// [N/A]
+			continue
+		}
+		memory[int32(id)] += mib * 1024 * 1024
 	}
-	return gpuStats
+	return memory
+}
+
+// GPUName returns the name of the last GPU read by GetGPUStats
+func GPUName() string { return defaultMonitor.GPUName() }
+
+// GPUName is the package level GPUName for the Monitor
+func (m *Monitor) GPUName() string {
+	m.gpu.mut.Lock()
+	defer m.gpu.mut.Unlock()
+	return m.gpu.info.Name
 }
 
-func GPUName() string { return gpuInfo.Name }
+// GetHostInfo returns the host info, which is cached for HOST_INFO_UPDATE_INTERVAL. When
+// it can't be read, the last info (or a zero HostInfo) is returned with the error
+func GetHostInfo() (HostInfo, error) { return defaultMonitor.HostInfo() }
+
+// HostInfo is GetHostInfo for the Monitor
+func (m *Monitor) HostInfo() (HostInfo, error) {
+	m.host.mut.Lock()
+	defer m.host.mut.Unlock()
 
-func GetHostInfo() *host.InfoStat {
-	if time.Since(lastFetchHost) < HOST_INFO_UPDATE_INTERVAL && len(hostInfo.String()) > 0 {
-		return hostInfo
+	if !m.host.lastFetch.IsZero() &&
+		GetClock().Since(m.host.lastFetch) < m.interval(CollectorHost) {
+		return m.host.info, nil
 	}
+	m.host.lastFetch = GetClock().Now()
 
-	hInfo, err := host.Info()
+	start := m.startCollect(CollectorHost)
+	hInfo, err := m.sources.host.Info()
 	if err != nil {
-		slog.Error("Failed to retrieve host.Info()! " + err.Error())
+		m.collectorError(CollectorHost, "Failed to retrieve the host info!", err)
+		m.collected(CollectorHost, start, nil, err)
+		return m.host.info, err
 	}
-	lastFetchHost = time.Now()
 
-	hostInfo = hInfo
-	slog.Debug("host.Info(): " + hostInfo.String())
-	hostname = hostInfo.Hostname
+	m.host.info = newHostInfo(hInfo)
+	m.log().Debug("host.Info(): " + m.host.info.String())
+	m.host.hostname = displayHostname(m.host.info.Hostname)
+	m.collected(CollectorHost, start, m.host.info, nil)
 
+	return m.host.info, nil
+}
+
+func newHostInfo(info *host.InfoStat) HostInfo {
+	osInfo := getOSDetails()
+	hostInfo := HostInfo{
+		Hostname:             info.Hostname,
+		OS:                   info.OS,
+		Platform:             info.Platform,
+		PlatformFamily:       info.PlatformFamily,
+		PlatformVersion:      info.PlatformVersion,
+		KernelVersion:        info.KernelVersion,
+		KernelArch:           info.KernelArch,
+		OSName:               osInfo.Name,
+		OSVersion:            osInfo.Version,
+		OSPrettyName:         osInfo.PrettyName,
+		OSBuild:              osInfo.Build,
+		Uptime:               time.Duration(info.Uptime) * time.Second,
+		Procs:                info.Procs,
+		VirtualizationSystem: info.VirtualizationSystem,
+		VirtualizationRole:   info.VirtualizationRole,
+		HostID:               info.HostID,
+	}
+	if info.BootTime > 0 {
+		hostInfo.BootTime = time.Unix(int64(info.BootTime), 0)
+	}
 	return hostInfo
 }
 
-func GetHostname() string {
-	if hostname != "" {
-		return hostname
+func (h HostInfo) String() string {
+	return fmt.Sprintf(
+		"hostname=%s, os=%s, platform=%s %s (%s), kernel=%s %s, uptime=%s",
+		h.Hostname, h.OS, h.Platform, h.PlatformVersion, h.PlatformFamily,
+		h.KernelVersion, h.KernelArch, FormatUptime(h.Uptime))
+}
+
+func (h HostInfo) JSON(indent bool) string {
+	if indent {
+		out, err := json.MarshalIndent(h, "", "  ")
+		if err != nil {
+			logger().Error("Failed to marshal indent JSON from struct HostInfo{} !" +
+				err.Error())
+		}
+		return string(out)
 	} else {
-		GetHostInfo()
-		return hostname
+		out, err := json.Marshal(h)
+		if err != nil {
+			logger().Error("Failed to marshal JSON from struct HostInfo{} !" + err.Error())
+		}
+		return string(out)
 	}
 }
 
-func GetMemoryStats() *mem.VirtualMemoryStat {
-	if time.Since(lastFetchMem) < MEM_STATS_UPDATE_INTERVAL && len(memInfo.String()) > 0 {
-		return memInfo
+// GetHostname returns the hostname, overridden and/or anonymized per the config. It
+// follows hostname changes, see GetHostIdentity
+func GetHostname() string {
+	GetHostIdentity()
+	if name := defaultMonitor.cachedHostname(); name != "" {
+		return name
+	}
+	defaultMonitor.HostInfo()
+	return defaultMonitor.cachedHostname()
+}
+
+func (m *Monitor) cachedHostname() string {
+	m.host.mut.Lock()
+	defer m.host.mut.Unlock()
+	return m.host.hostname
+}
+
+// setHostname replaces the hostname, and makes the next HostInfo read the host info
+// again
+func (m *Monitor) setHostname(name string) {
+	m.host.mut.Lock()
+	defer m.host.mut.Unlock()
+	m.host.lastFetch = time.Time{}
+	m.host.hostname = displayHostname(name)
+}
+
+// GetMemoryStats returns the memory usage. When it can't be read, the last usage (nil
+// before the first successful read) is returned with the error
+func GetMemoryStats() (*mem.VirtualMemoryStat, error) {
+	return defaultMonitor.MemoryStats()
+}
+
+// MemoryStats is GetMemoryStats for the Monitor
+func (m *Monitor) MemoryStats() (*mem.VirtualMemoryStat, error) {
+	m.mem.mut.Lock()
+	defer m.mem.mut.Unlock()
+
+	if GetClock().Since(m.mem.lastFetch) < m.interval(CollectorMemory) &&
+		m.mem.stats != nil {
+		return m.mem.stats, nil
 	}
 
-	mInfo, err := mem.VirtualMemory()
+	start := m.startCollect(CollectorMemory)
+	mInfo, err := m.sources.mem.VirtualMemory()
 	if err != nil {
-		slog.Error("Failed to retrieve mem.VirtualMemory()! " + err.Error())
+		m.collectorError(CollectorMemory, "Failed to retrieve the memory usage!", err)
+		m.collected(CollectorMemory, start, nil, err)
+		return m.mem.stats, err
 	}
-	lastFetchMem = time.Now()
+	m.mem.lastFetch = GetClock().Now()
+	m.recordMetric(MetricMemoryUsed, m.mem.lastFetch, mInfo.UsedPercent)
+	m.collected(CollectorMemory, start, mInfo, nil)
 
-	if memInfo == nil {
-		// This is the first time getting the memory usage; just populate/init memInfo
-		memInfo = mInfo
-		return memInfo
+	if m.mem.stats == nil {
+		// This is the first time getting the memory usage; just populate/init the cache
+		m.mem.stats = mInfo
+		return m.mem.stats, nil
 	}
 
-	oldUsedPercent := memInfo.UsedPercent
+	oldUsedPercent := m.mem.stats.UsedPercent
 	currentUsedPercent := mInfo.UsedPercent
 
 	if oldUsedPercent == currentUsedPercent {
 		// If we get the same results, just re-send the same data without updates
-		//slog.Debug("gtm.GetMemoryStats(): no changes... return last fetch")
-		return memInfo
+		//m.log().Debug("gtm.GetMemoryStats(): no changes... return last fetch")
+		return m.mem.stats, nil
 	} else {
 		//  If the previous fetch is greater than or less than the last fetch in
 		// 	Gigabytes, return the updated memory usage
-		memInfo = mInfo
-		slog.Debug("mem.VirtualMemory(): " + memInfo.String())
-		return memInfo
+		m.mem.stats = mInfo
+		m.log().Debug("mem.VirtualMemory(): " + m.mem.stats.String())
+		return m.mem.stats, nil
 	}
 }
 
-func GetNetworkStats() []net.IOCountersStat {
-	if time.Since(lastFetchNet) < NET_STATS_UPDATE_INTERVAL && len(netInfo) > 0 {
-		return netInfo
+// GetNetworkStats returns the counters and throughput of the network interfaces that
+// pass NET_INCLUDE and NET_EXCLUDE in the config. By default virtual interfaces (ie.
+// loopback, docker0, veth*) are hidden, see NET_DEFAULT_EXCLUDE
+func GetNetworkStats() ([]NetStats, error) { return defaultMonitor.NetworkStats() }
+
+// NetworkStats is GetNetworkStats for the Monitor
+func (m *Monitor) NetworkStats() ([]NetStats, error) {
+	all, err := m.AllNetworkStats()
+	filter := m.netFilter()
+	stats := make([]NetStats, 0, len(all))
+	for _, stat := range all {
+		if filter.Match(stat.Name, stat.Alias) {
+			stats = append(stats, stat)
+		}
 	}
+	return stats, err
+}
 
-	nInfo, err := net.IOCounters(false)
+// GetAllNetworkStats returns the counters and throughput of every network interface,
+// ignoring the interface filters. When the counters can't be read, the last stats are
+// returned with the error
+func GetAllNetworkStats() ([]NetStats, error) { return defaultMonitor.AllNetworkStats() }
+
+// AllNetworkStats is GetAllNetworkStats for the Monitor
+func (m *Monitor) AllNetworkStats() ([]NetStats, error) {
+	m.net.mut.Lock()
+	defer m.net.mut.Unlock()
+
+	if !m.net.expired &&
+		GetClock().Since(m.net.lastFetch) < m.interval(CollectorNetwork) &&
+		len(m.net.stats) > 0 {
+		return m.net.stats, nil
+	}
+
+	start := m.startCollect(CollectorNetwork)
+	counters, err := m.sources.net.IOCounters()
 	if err != nil {
-		slog.Error("Failed to retrieve net.IOCounters()! " + err.Error())
+		m.collectorError(CollectorNetwork, "Failed to retrieve net.IOCounters()!", err)
+		m.collected(CollectorNetwork, start, nil, err)
+		return m.net.stats, err
 	}
-	lastFetchNet = time.Now()
+	fetchTime := GetClock().Now()
+	elapsed := sampleSeconds(m.net.lastFetch, fetchTime)
 
-	netInfo = nInfo
-	for i, iface := range netInfo {
-		slog.Debug("net.IOCounters(), interface #" + strconv.Itoa(i) + ": " +
-			iface.String())
+	stats := make([]NetStats, 0, len(counters))
+	current := make(map[string]net.IOCountersStat, len(counters))
+	filter := m.netFilter()
+	ipv6 := getIPv6Counters()
+	splits := make(map[string]*IPSplit, len(ipv6))
+	for _, iface := range counters {
+		current[iface.Name] = iface
+		stat := NetStats{
+			Name:        iface.Name,
+			Alias:       netAlias(iface.Name),
+			BytesSent:   iface.BytesSent,
+			BytesRecv:   iface.BytesRecv,
+			PacketsSent: iface.PacketsSent,
+			PacketsRecv: iface.PacketsRecv,
+			ErrIn:       iface.Errin,
+			ErrOut:      iface.Errout,
+			DropIn:      iface.Dropin,
+			DropOut:     iface.Dropout,
+		}
+		if previous, ok := m.net.counters[iface.Name]; ok {
+			if m.global && iface.BytesSent >= previous.BytesSent &&
+				iface.BytesRecv >= previous.BytesRecv {
+				accountBandwidth(fetchTime, iface.Name, iface.BytesSent-previous.BytesSent,
+					iface.BytesRecv-previous.BytesRecv)
+				if filter.Match(stat.Name, stat.Alias) {
+					observeSessionTraffic(iface.BytesSent-previous.BytesSent,
+						iface.BytesRecv-previous.BytesRecv)
+				}
+			}
+			stat.UploadBytesPerSec = RoundStat(ratePerSecond(previous.BytesSent,
+				iface.BytesSent, elapsed))
+			stat.DownloadBytesPerSec = RoundStat(ratePerSecond(previous.BytesRecv,
+				iface.BytesRecv, elapsed))
+			stat.UploadPacketsPerSec = RoundStat(ratePerSecond(previous.PacketsSent,
+				iface.PacketsSent, elapsed))
+			stat.DownloadPacketsPerSec = RoundStat(ratePerSecond(previous.PacketsRecv,
+				iface.PacketsRecv, elapsed))
+			stat.ErrInPerSec = RoundStat(ratePerSecond(previous.Errin, iface.Errin, elapsed))
+			stat.ErrOutPerSec = RoundStat(ratePerSecond(previous.Errout, iface.Errout,
+				elapsed))
+			stat.DropInPerSec = RoundStat(ratePerSecond(previous.Dropin, iface.Dropin,
+				elapsed))
+			stat.DropOutPerSec = RoundStat(ratePerSecond(previous.Dropout, iface.Dropout,
+				elapsed))
+			stat.UploadBitsPerSec = RoundStat(stat.UploadBytesPerSec * 8)
+			stat.DownloadBitsPerSec = RoundStat(stat.DownloadBytesPerSec * 8)
+		}
+		if v6, ok := ipv6[iface.Name]; ok {
+			stat.IPSplit = newIPSplit(iface, v6, m.net.ipSplits[iface.Name], elapsed)
+			splits[iface.Name] = stat.IPSplit
+		}
+		m.log().Debug("net.IOCounters(), interface " + iface.Name + ": " + iface.String())
+		stats = append(stats, stat)
+		m.recordMetric(NetMetric(stat.Name, MetricNetDownload), fetchTime,
+			stat.DownloadBytesPerSec)
+		m.recordMetric(NetMetric(stat.Name, MetricNetUpload), fetchTime,
+			stat.UploadBytesPerSec)
 	}
 
-	return netInfo
+	m.net.stats = stats
+	m.net.counters = current
+	m.net.ipSplits = splits
+	m.net.lastFetch = fetchTime
+	m.net.expired = false
+	m.collected(CollectorNetwork, start, m.net.stats, nil)
+	return m.net.stats, nil
 }
@@ -0,0 +1,140 @@
+package gtm
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestConcurrentGet calls the Get functions and GetSnapshot from many goroutines while
+// the clock moves past every interval, so the caches are refetched and replaced
+// concurrently. Run it with the race detector:
+//
+//	go test -race -run TestConcurrentGet
+func TestConcurrentGet(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	SetClock(clock)
+	defer SetClock(nil)
+
+	gets := []func(){
+		func() { _, _ = GetCPUStats() },
+		func() { _, _ = GetMemoryStats() },
+		func() { _, _ = GetDisksStats() },
+		func() { _, _ = GetNetworkStats() },
+		func() { _, _ = GetGPUStats() },
+		func() { _, _ = GetHostInfo() },
+		func() { _, _ = GetProcesses() },
+		func() { _, _ = GetSensors() },
+		func() { _, _ = GetPowerStats() },
+		func() { _, _ = GetWiFiStats() },
+		func() { _ = GetMetricHistoryNames() },
+		func() { _ = GetSnapshot() },
+	}
+
+	const goroutines, iterations = 4, 5
+	var wg sync.WaitGroup
+	for _, get := range gets {
+		for range goroutines {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range iterations {
+					get()
+				}
+			}()
+		}
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for range iterations {
+			clock.Advance(DISK_STATS_UPDATE_INTERVAL)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+	wg.Wait()
+
+	snapshot := GetSnapshot()
+	if snapshot.SchemaVersion != SCHEMA_VERSION {
+		t.Errorf("schema version %d, want %d", snapshot.SchemaVersion, SCHEMA_VERSION)
+	}
+}
+
+// newHistoryMonitor returns a Monitor that records the metric history like the default
+// Monitor does, which NewMonitor doesn't
+func newHistoryMonitor(opts ...MonitorOption) *Monitor {
+	m := NewMonitor(opts...)
+	m.global = true
+	return m
+}
+
+// TestAllocsBounded fetches the CPU stats until the metric history is full, and checks
+// that fetching keeps allocating the same and the history stays bounded afterwards
+func TestAllocsBounded(t *testing.T) {
+	clock := NewManualClock(time.Now())
+	SetClock(clock)
+	defer SetClock(nil)
+	m := newHistoryMonitor(WithCPUSource(fakeCPUSource{}))
+	fetch := func() {
+		clock.Advance(CPU_STATS_UPDATE_INTERVAL)
+		if _, err := m.CPUStats(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for range METRIC_HISTORY_CAPACITY {
+		fetch()
+	}
+	full := testing.AllocsPerRun(100, fetch)
+	for range METRIC_HISTORY_CAPACITY {
+		fetch()
+	}
+	if later := testing.AllocsPerRun(100, fetch); later > full {
+		t.Errorf("%v allocations per fetch, %v once the history was full", later, full)
+	}
+	history, _ := GetMetricHistory(MetricCPUUsage, 0)
+	if len(history.Values) == 0 {
+		t.Error("the CPU usage wasn't recorded in the history")
+	} else if len(history.Values) > METRIC_HISTORY_CAPACITY {
+		t.Errorf("%d samples in the history, want at most %d", len(history.Values),
+			METRIC_HISTORY_CAPACITY)
+	}
+}
+
+// BenchmarkCPUStats fetches the CPU stats on every iteration, recording the metric
+// history. The allocations per fetch stay the same however long it runs, since only
+// the latest stats are kept and the history is bounded (see TestAllocsBounded)
+func BenchmarkCPUStats(b *testing.B) {
+	clock := NewManualClock(time.Now())
+	SetClock(clock)
+	defer SetClock(nil)
+	m := newHistoryMonitor(WithCPUSource(fakeCPUSource{}))
+
+	b.ReportAllocs()
+	for range b.N {
+		clock.Advance(CPU_STATS_UPDATE_INTERVAL)
+		if _, err := m.CPUStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGPUStats fetches the stats of 2 GPUs on every iteration, see
+// BenchmarkCPUStats
+func BenchmarkGPUStats(b *testing.B) {
+	clock := NewManualClock(time.Now())
+	SetClock(clock)
+	defer SetClock(nil)
+	m := newHistoryMonitor(WithGPUSource(fakeGPUSource{}))
+	if !m.HasGPU() {
+		b.Fatal("the fake GPU wasn't found")
+	}
+
+	b.ReportAllocs()
+	for range b.N {
+		clock.Advance(GPU_STATS_UPDATE_INTERVAL)
+		if _, err := m.GPUStats(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
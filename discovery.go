@@ -0,0 +1,207 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DISCOVERY_INTERVAL is how often devices are re-scanned. It is shorter than
+// DISK_STATS_UPDATE_INTERVAL, so a new disk shows up without waiting for the next
+// disk fetch
+const DISCOVERY_INTERVAL = 10 * time.Second
+
+// DeviceKind is the type of device found by discovery
+type DeviceKind string
+
+const (
+	DeviceDisk    DeviceKind = "disk"
+	DeviceGPU     DeviceKind = "gpu"
+	DeviceNetwork DeviceKind = "net"
+)
+
+// DeviceChange tells whether a device appeared or disappeared
+type DeviceChange string
+
+const (
+	DeviceAdded   DeviceChange = "added"
+	DeviceRemoved DeviceChange = "removed"
+)
+
+// DeviceEvent is published when a re-scan finds a new device, or misses a known one.
+// Name is a mountpoint for disks, a card id for GPUs and an interface name for NICs
+type DeviceEvent struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Kind      DeviceKind   `json:"kind"`
+	Change    DeviceChange `json:"change"`
+	Name      string       `json:"name"`
+}
+
+var (
+	deviceEvents   = NewBroadcaster[DeviceEvent]()
+	knownDevices   = map[DeviceKind][]string{}
+	discoveryMut   sync.Mutex
+	discoveryStop  chan struct{}
+	discoverySeeds sync.Once
+)
+
+// WatchDevices subscribes to device-added and device-removed events. Events are only
+// published while StartDeviceDiscovery is running. Call the returned function to
+// unsubscribe
+func WatchDevices() (*Subscriber[DeviceEvent], func()) {
+	return deviceEvents.Subscribe(0, 0)
+}
+
+// StartDeviceDiscovery re-scans for disks, GPUs and network interfaces every
+// `interval` (DISCOVERY_INTERVAL when 0) in the background, so hot-plugged devices
+// (ie. an eGPU, a USB NIC or a new VM disk) are collected without restarting gtm. The
+// devices present when it starts are not reported as added
+func StartDeviceDiscovery(interval time.Duration) {
+	if interval <= 0 {
+		interval = DISCOVERY_INTERVAL
+	}
+
+	discoveryMut.Lock()
+	if discoveryStop != nil {
+		discoveryMut.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	discoveryStop = stop
+	discoveryMut.Unlock()
+
+	// The first scan only records what is already there
+	discoverySeeds.Do(func() { scanDevices(false) })
+
+	logger().Info("Starting device discovery every " + interval.String() + " ...")
+	go func() {
+		for {
+			select {
+			case <-stop:
+				return
+			case <-GetClock().After(interval):
+				scanDevices(true)
+			}
+		}
+	}()
+}
+
+// StopDeviceDiscovery stops the background re-scan
+func StopDeviceDiscovery() {
+	discoveryMut.Lock()
+	defer discoveryMut.Unlock()
+	if discoveryStop != nil {
+		close(discoveryStop)
+		discoveryStop = nil
+	}
+}
+
+// RescanDevices re-scans for devices right away and publishes any changes
+func RescanDevices() {
+	discoverySeeds.Do(func() { scanDevices(false) })
+	scanDevices(true)
+}
+
+func scanDevices(publish bool) {
+	if names, ok := scanDisks(); ok && updateKnownDevices(DeviceDisk, names, publish) {
+		// fetch the new disk on the next GetDisksStats() instead of a minute later
+		defaultMonitor.expire(CollectorDisk)
+	}
+	if names, ok := scanNetwork(); ok && updateKnownDevices(DeviceNetwork, names, publish) {
+		resetNetworkInterfaces()
+	}
+	if names, ok := scanGPUs(); ok && updateKnownDevices(DeviceGPU, names, publish) {
+		defaultMonitor.expire(CollectorGPU)
+	}
+}
+
+// updateKnownDevices stores the devices of a kind and publishes the differences. It
+// returns true if anything changed
+func updateKnownDevices(kind DeviceKind, names []string, publish bool) bool {
+	slices.Sort(names)
+
+	discoveryMut.Lock()
+	known := knownDevices[kind]
+	knownDevices[kind] = names
+	discoveryMut.Unlock()
+
+	var events []DeviceEvent
+	now := GetClock().Now()
+	for _, name := range names {
+		if !slices.Contains(known, name) {
+			events = append(events, DeviceEvent{Timestamp: now, Kind: kind, Change: DeviceAdded,
+				Name: name})
+		}
+	}
+	for _, name := range known {
+		if !slices.Contains(names, name) {
+			events = append(events, DeviceEvent{Timestamp: now, Kind: kind, Change: DeviceRemoved,
+				Name: name})
+		}
+	}
+	if !publish {
+		return false
+	}
+	for _, event := range events {
+		logger().Info("Device " + string(event.Change) + ": " + string(event.Kind) + " " +
+			event.Name)
+		deviceEvents.Publish(event)
+	}
+	return len(events) > 0
+}
+
+func scanDisks() ([]string, bool) {
+	if !IsCollectorEnabled(CollectorDisk) {
+		return nil, false
+	}
+	partitions, err := disk.Partitions(true)
+	if err != nil {
+		collectorError(CollectorDisk, "Failed to retrieve disk.Partitions()!", err)
+		return nil, false
+	}
+	names := make([]string, 0, len(partitions))
+	for _, partition := range partitions {
+		names = append(names, partition.Mountpoint)
+	}
+	return names, true
+}
+
+func scanNetwork() ([]string, bool) {
+	if !IsCollectorEnabled(CollectorNetwork) {
+		return nil, false
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		collectorError(CollectorNetwork, "Failed to retrieve net.Interfaces()!", err)
+		return nil, false
+	}
+	names := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		names = append(names, iface.Name)
+	}
+	return names, true
+}
+
+// scanGPUs probes for nvidia-smi/rocm-smi again until a GPU is found, so an eGPU
+// attached after start up is picked up too
+func scanGPUs() ([]string, bool) {
+	if !IsCollectorEnabled(CollectorGPU) || (!hasGPU() && !defaultMonitor.probeGPU()) {
+		return []string{}, true
+	}
+	defaultMonitor.expire(CollectorGPU)
+	stats, err := GetGPUStats()
+	if err != nil && stats == nil {
+		return nil, false
+	}
+	names := []string{}
+	for _, gpu := range stats {
+		id := strconv.FormatInt(int64(gpu.Id), 10)
+		if !slices.Contains(names, id) {
+			names = append(names, id)
+		}
+	}
+	return names, true
+}
@@ -0,0 +1,9 @@
+//go:build !windows
+
+package gtm
+
+func isVirtualDisk(path string) bool {
+	// TODO: do RAMDISK checks for macOS & Linux !
+	logger().Debug("Not on windows... ignoring RAMDISK check for " + path + " ...")
+	return false
+}
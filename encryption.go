@@ -0,0 +1,25 @@
+package gtm
+
+import "sync"
+
+// diskEncrypted caches the encryption state of every mountpoint. Volumes are rarely
+// encrypted or decrypted while mounted, so this is only collected the first time a
+// mountpoint is seen (inventory time)
+var (
+	diskEncrypted    = map[string]bool{}
+	diskEncryptedMut sync.Mutex
+)
+
+func getDiskEncrypted(mountpoint string, device string, fsType string) bool {
+	diskEncryptedMut.Lock()
+	encrypted, ok := diskEncrypted[mountpoint]
+	diskEncryptedMut.Unlock()
+	if ok {
+		return encrypted
+	}
+	encrypted = isEncryptedVolume(mountpoint, device, fsType)
+	diskEncryptedMut.Lock()
+	diskEncrypted[mountpoint] = encrypted
+	diskEncryptedMut.Unlock()
+	return encrypted
+}
@@ -0,0 +1,28 @@
+package gtm
+
+import (
+	"strings"
+)
+
+// isEncryptedVolume checks FileVault (and encrypted APFS/HFS+ volumes) using diskutil
+func isEncryptedVolume(mountpoint string, device string, fsType string) bool {
+	out, err := runCommand("diskutil", "info", mountpoint)
+	if err != nil {
+		logger().Debug("Failed to run `diskutil info " + mountpoint + "` ! " + err.Error())
+		return false
+	}
+
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "FileVault", "Encrypted":
+			if strings.HasPrefix(strings.TrimSpace(value), "Yes") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package gtm
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// isEncryptedVolume detects LUKS (dm-crypt) volumes, including LVM volumes stacked on
+// top of LUKS, plus the stacked eCryptfs filesystem
+func isEncryptedVolume(mountpoint string, device string, fsType string) bool {
+	if fsType == "ecryptfs" {
+		return true
+	}
+	// ie. /dev/mapper/cryptroot is a symlink to /dev/dm-0
+	resolved, err := filepath.EvalSymlinks(device)
+	if err != nil {
+		logger().Debug("Failed to resolve device " + device + " for " + mountpoint + " ! " +
+			err.Error())
+		return false
+	}
+	encrypted := isDMCrypt(filepath.Base(resolved), 0)
+	logger().Debug("Disk " + mountpoint + " (" + resolved + ") encrypted: " +
+		strconv.FormatBool(encrypted))
+	return encrypted
+}
+
+// isDMCrypt walks down the device mapper stack (ie. LVM -> LUKS -> partition) looking
+// for a dm-crypt target
+func isDMCrypt(blockDevice string, depth int) bool {
+	if depth > 8 {
+		return false
+	}
+	sysDir := filepath.Join("/sys/class/block", blockDevice)
+	if uuid, err := os.ReadFile(filepath.Join(sysDir, "dm", "uuid")); err == nil &&
+		strings.HasPrefix(string(uuid), "CRYPT-") {
+		return true
+	}
+	slaves, err := os.ReadDir(filepath.Join(sysDir, "slaves"))
+	if err != nil {
+		return false
+	}
+	for _, slave := range slaves {
+		if isDMCrypt(slave.Name(), depth+1) {
+			return true
+		}
+	}
+	return false
+}
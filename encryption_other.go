@@ -0,0 +1,7 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+func isEncryptedVolume(mountpoint string, device string, fsType string) bool {
+	return false
+}
@@ -0,0 +1,27 @@
+package gtm
+
+import (
+	"strings"
+)
+
+// isEncryptedVolume reads the BitLocker protection state of a drive through the shell,
+// which (unlike `manage-bde` and Win32_EncryptableVolume) works without admin rights
+func isEncryptedVolume(mountpoint string, device string, fsType string) bool {
+	drive := strings.TrimSuffix(mountpoint, `\`) + `\`
+	out, err := runCommand("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"(New-Object -ComObject Shell.Application).NameSpace('"+drive+"')."+
+			"Self.ExtendedProperty('System.Volume.BitLockerProtection')")
+	if err != nil {
+		logger().Debug("Failed to query BitLocker state of " + drive + " ! " + err.Error())
+		return false
+	}
+
+	// 1: on, 2: off, 3: encrypting, 4: decrypting, 5: suspended, 6: on (locked),
+	//	8: waiting for activation
+	switch strings.TrimSpace(string(out)) {
+	case "1", "3", "5", "6":
+		return true
+	default:
+		return false
+	}
+}
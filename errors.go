@@ -0,0 +1,54 @@
+package gtm
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"runtime"
+	"syscall"
+)
+
+// Sentinel errors returned (possibly wrapped) by the Get* functions, so callers can
+// tell "there's nothing to collect" from "collecting failed". Check them with errors.Is
+var (
+	// ErrUnsupported is returned for stats the OS or the hardware doesn't provide. It's
+	//	errors.ErrUnsupported, so either one matches
+	ErrUnsupported = errors.ErrUnsupported
+	// ErrUnsupportedPlatform is returned for stats gtm can't read on this OS at all, so
+	//	neither retrying nor more privileges help. It matches ErrUnsupported too
+	ErrUnsupportedPlatform = fmt.Errorf("not supported on %s: %w", runtime.GOOS,
+		ErrUnsupported)
+	// ErrPermissionDenied is returned for stats that need root/admin. It's
+	//	fs.ErrPermission, so the EACCES and EPERM errors of the OS match it
+	ErrPermissionDenied = fs.ErrPermission
+	// ErrCollectorDisabled is returned by collectors that can't work in this environment
+	//	(see GetCapabilities)
+	ErrCollectorDisabled = errors.New("collector is disabled")
+	// ErrNoGPU is returned by the GPU stats when no supported GPU was found
+	ErrNoGPU = errors.New("no supported GPU found")
+)
+
+// TransientError wraps a failure that's likely to go away by itself, ie. a device that
+// was busy, so the fetch is worth retrying on the next interval
+type TransientError struct {
+	Err error
+}
+
+func (e *TransientError) Error() string { return e.Err.Error() }
+
+func (e *TransientError) Unwrap() error { return e.Err }
+
+// IsTransient reports whether a failed fetch is worth retrying: a TransientError, a
+// command killed after EXEC_TIMEOUT, a timeout or a busy device. Errors matching
+// ErrUnsupportedPlatform or ErrPermissionDenied fail the same way every time instead
+func IsTransient(err error) bool {
+	var transient *TransientError
+	if errors.As(err, &transient) || errors.Is(err, ErrExecTimeout) ||
+		errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.EBUSY) {
+		return true
+	}
+	// context.DeadlineExceeded and os.ErrDeadlineExceeded are net.Errors too
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,98 @@
+package gtm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// EXEC_MAX_CONCURRENT is the number of external commands (nvidia-smi, ss, iw, ...)
+	// allowed to run at the same time across every collector
+	EXEC_MAX_CONCURRENT = 2
+	// EXEC_TIMEOUT kills a command that hangs, ie. nvidia-smi on a wedged driver
+	EXEC_TIMEOUT = 10 * time.Second
+	// EXEC_MIN_INTERVAL is the minimum time between two runs of the same command line.
+	// Calls within this interval get the output of the previous run
+	EXEC_MIN_INTERVAL = 500 * time.Millisecond
+)
+
+// ErrExecTimeout is returned (wrapped) when a command was killed after EXEC_TIMEOUT
+var ErrExecTimeout = errors.New("command timed out")
+
+// execResult is the last run of a command line. Runs of the same command line are
+// serialized through its mutex, so overlapping callers wait for (and share) a single
+// run instead of spawning another process
+type execResult struct {
+	mut      sync.Mutex
+	output   []byte
+	err      error
+	finished time.Time
+}
+
+var (
+	execSlots   = make(chan struct{}, EXEC_MAX_CONCURRENT)
+	execResults = map[string]*execResult{}
+	execMut     sync.Mutex
+	// execCtx is canceled by cancelCommands, killing the commands running or waiting
+	//	for a slot
+	execCtx, execCancel = context.WithCancel(context.Background())
+)
+
+// runCommand runs an external command through the shared worker pool and returns its
+// stdout, like exec.Command(name, args...).Output(). Every exec-based backend must use
+// it, so collectors can't pile up heavyweight processes on a struggling system
+func runCommand(name string, args ...string) ([]byte, error) {
+	key := name + "\x00" + strings.Join(args, "\x00")
+
+	execMut.Lock()
+	result, ok := execResults[key]
+	if !ok {
+		result = &execResult{}
+		execResults[key] = result
+	}
+	execMut.Unlock()
+
+	result.mut.Lock()
+	defer result.mut.Unlock()
+	if !result.finished.IsZero() && GetClock().Since(result.finished) < EXEC_MIN_INTERVAL {
+		return result.output, result.err
+	}
+
+	execMut.Lock()
+	parent := execCtx
+	execMut.Unlock()
+	select {
+	case execSlots <- struct{}{}:
+	case <-parent.Done():
+		return nil, fmt.Errorf("%w: not running `%s`", ErrMonitorClosed, name)
+	}
+	defer func() { <-execSlots }()
+
+	ctx, cancel := context.WithTimeout(parent, EXEC_TIMEOUT)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+	if ctx.Err() == context.DeadlineExceeded {
+		logger().Warn("Killed `" + name + "` after " + EXEC_TIMEOUT.String())
+		err = errors.Join(ErrExecTimeout, err)
+	} else if parent.Err() != nil {
+		err = errors.Join(ErrMonitorClosed, err)
+	}
+
+	result.output, result.err = output, err
+	result.finished = GetClock().Now()
+	return output, err
+}
+
+// cancelCommands kills the commands running or waiting for a slot in the pool. Commands
+// started afterwards run again, since other Monitors share the pool
+func cancelCommands() {
+	execMut.Lock()
+	defer execMut.Unlock()
+	execCancel()
+	execCtx, execCancel = context.WithCancel(context.Background())
+}
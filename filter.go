@@ -0,0 +1,53 @@
+package gtm
+
+import (
+	"path"
+	"strings"
+)
+
+// NameFilter includes or excludes devices (disks, interfaces, ...) by name using glob
+// patterns like "/boot/*", "tmpfs" or "veth*" (see path.Match for the syntax). A "*"
+// does not match "/", so a pattern ending with "/**" is added to match everything below
+// a directory (ie. "/snap/**")
+type NameFilter struct {
+	// Include keeps ONLY the devices matching at least one pattern. Empty keeps everything
+	Include []string
+	// Exclude removes devices matching any pattern, even if they are included
+	Exclude []string
+}
+
+func NewNameFilter(include []string, exclude []string) NameFilter {
+	return NameFilter{Include: include, Exclude: exclude}
+}
+
+// Match reports whether a device passes the filter. A device can be known by several
+// names (ie. a disk has a mountpoint, device path and filesystem type), and a pattern
+// matches the device if it matches any one of them
+func (f NameFilter) Match(names ...string) bool {
+	if len(f.Include) > 0 && !matchAny(f.Include, names) {
+		return false
+	}
+	return !matchAny(f.Exclude, names)
+}
+
+func matchAny(patterns []string, names []string) bool {
+	for _, pattern := range patterns {
+		for _, name := range names {
+			if name == "" {
+				continue
+			}
+			if matched, err := path.Match(pattern, name); err == nil && matched {
+				return true
+			}
+			if dir, ok := strings.CutSuffix(pattern, "/**"); ok &&
+				strings.HasPrefix(name, dir+"/") {
+				return true
+			}
+			// Windows paths are case-insensitive
+			if strings.EqualFold(pattern, name) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+package gtm
+
+import "sync"
+
+// flight coalesces concurrent fetches: the first caller runs the fetch, and callers
+// arriving while it runs wait for it and share its result instead of running their own,
+// ie. every goroutine calling GetGPUStats when the cache expired would otherwise spawn
+// nvidia-smi. The cache isn't locked during the fetch, so its readers don't wait for it
+type flight[T any] struct {
+	mut  sync.Mutex
+	call *flightCall[T]
+}
+
+// flightCall is a fetch in progress. `done` is closed once `value` and `err` are set
+type flightCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// do runs `fetch`, or waits for the fetch in progress and returns its result
+func (f *flight[T]) do(fetch func() (T, error)) (T, error) {
+	f.mut.Lock()
+	if call := f.call; call != nil {
+		f.mut.Unlock()
+		<-call.done
+		return call.value, call.err
+	}
+	call := &flightCall[T]{done: make(chan struct{})}
+	f.call = call
+	f.mut.Unlock()
+
+	// the waiters are released even when `fetch` panics
+	defer func() {
+		f.mut.Lock()
+		f.call = nil
+		f.mut.Unlock()
+		close(call.done)
+	}()
+	call.value, call.err = fetch()
+	return call.value, call.err
+}
@@ -0,0 +1,99 @@
+package gtm
+
+import (
+	"bufio"
+	"net"
+	"strconv"
+	"time"
+)
+
+const (
+	// GRAPHITE_DEFAULT_PREFIX prefixes the metrics pushed to Graphite when
+	//	GRAPHITE_PREFIX isn't set
+	GRAPHITE_DEFAULT_PREFIX = "gtm"
+	// GRAPHITE_INTERVAL is how often the metrics are pushed to Graphite by default
+	GRAPHITE_INTERVAL = 10 * time.Second
+	// graphiteTimeout limits connecting to and writing to Graphite, so a server that
+	//	hangs doesn't delay the next push
+	graphiteTimeout = 5 * time.Second
+)
+
+// GraphiteOptions configures the metrics pushed by StartGraphite
+type GraphiteOptions struct {
+	// Prefix is prepended to the path of every metric, before the hostname
+	Prefix string
+	// Interval is how often the metrics are pushed, GRAPHITE_INTERVAL when 0
+	Interval time.Duration
+}
+
+// StartGraphite pushes the current value of every metric of the Monitor (see Samples)
+// to the Graphite server at `addr` (the plaintext protocol over TCP, ie. port 2003)
+// every interval. Metrics are sent as "<prefix>.<host>.<metric path> <value>
+// <timestamp>", ie. "gtm.web1.disk.mnt_data.used_percent 41.2 1700000000". Devices
+// with an alias are tagged with it (Graphite 1.1+), ie.
+// "gtm.web1.disk.mnt_data.used_percent;alias=Backups". The connection is opened again
+// on the next push when it fails. Call the returned function to stop pushing; it also
+// stops once the Monitor is shut down
+func (m *Monitor) StartGraphite(addr string, options GraphiteOptions) (stop func(),
+	err error) {
+	conn, err := net.DialTimeout("tcp", addr, graphiteTimeout)
+	if err != nil {
+		return nil, err
+	}
+	interval := options.Interval
+	if interval <= 0 {
+		interval = GRAPHITE_INTERVAL
+	}
+
+	stop = m.pushEvery(interval, func() {
+		if conn == nil {
+			dialed, err := net.DialTimeout("tcp", addr, graphiteTimeout)
+			if err != nil {
+				m.log().Debug("Failed to connect to Graphite at " + addr + " ! " +
+					err.Error())
+				return
+			}
+			conn = dialed
+		}
+		if err := m.pushGraphite(conn, options.Prefix); err != nil {
+			m.log().Debug("Failed to push metrics to Graphite! " + err.Error())
+			conn.Close()
+			conn = nil
+		}
+	}, func() {
+		if conn != nil {
+			conn.Close()
+		}
+	})
+	m.log().Info("Pushing metrics to Graphite at " + addr + " every " +
+		interval.String() + " ...")
+	return stop, nil
+}
+
+// StartGraphite pushes the metrics of the default Monitor to Graphite, see
+// Monitor.StartGraphite
+func StartGraphite(addr string, options GraphiteOptions) (stop func(), err error) {
+	return defaultMonitor.StartGraphite(addr, options)
+}
+
+// pushGraphite writes every sample to `conn` in the plaintext protocol
+func (m *Monitor) pushGraphite(conn net.Conn, prefix string) error {
+	host, _ := m.HostInfo()
+	path := MetricPathSegment(displayHostname(host.Hostname)) + "."
+	if prefix != "" {
+		path = prefix + "." + path
+	}
+	timestamp := " " + strconv.FormatInt(GetClock().Now().Unix(), 10) + "\n"
+
+	_ = conn.SetWriteDeadline(time.Now().Add(graphiteTimeout))
+	out := bufio.NewWriter(conn)
+	for _, sample := range m.Samples() {
+		out.WriteString(path + samplePath(sample))
+		if sample.Alias != "" {
+			// a tagged series, see https://graphite.readthedocs.io/en/latest/tags.html
+			out.WriteString(";alias=" + MetricPathSegment(sample.Alias))
+		}
+		out.WriteString(" " + strconv.FormatFloat(sample.Value, 'f', -1, 64) + timestamp)
+	}
+	return out.Flush()
+}
@@ -0,0 +1,521 @@
+package gtm
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// GRPC_SERVICE is the full name of the Stats service in proto/gtm/v1/gtm.proto
+	GRPC_SERVICE = "gtm.v1.Stats"
+	// GRPC_MAX_REQUEST_SIZE limits the size of a request message. The requests of the
+	//	Stats service only hold a few numbers
+	GRPC_MAX_REQUEST_SIZE = 4096
+)
+
+// grpcCode is a gRPC status code
+type grpcCode int
+
+const (
+	grpcOK                grpcCode = 0
+	grpcCanceled          grpcCode = 1
+	grpcInvalidArgument   grpcCode = 3
+	grpcResourceExhausted grpcCode = 8
+	grpcUnimplemented     grpcCode = 12
+	grpcInternal          grpcCode = 13
+	grpcUnavailable       grpcCode = 14
+)
+
+// grpcError is the status a call ends with when it fails
+type grpcError struct {
+	code    grpcCode
+	message string
+}
+
+func (e *grpcError) Error() string { return e.message }
+
+// GRPCHandler serves the Stats service of proto/gtm/v1/gtm.proto for the Monitor, so
+// gRPC clients generated from it in any language read the stats as typed protobuf
+// messages:
+//
+//   - Snapshot returns Monitor.Snapshot
+//   - Watch sends a snapshot, then another one every `interval_ms` of the request. When
+//     the interval is 0, a snapshot is sent whenever the collectors of the Monitor
+//     update (see Monitor.Subscribe), which needs the Monitor to poll (see Monitor.Start)
+//
+// The snapshots are anonymized like GetSnapshot and redacted with REDACTION_PROFILE
+// like the REST API. gRPC runs over HTTP/2, which net/http only serves over TLS (see
+// StartGRPCServer). gtm doesn't depend on gRPC: the messages are encoded by hand,
+// field for field with the .proto, and compressed requests aren't supported
+func (m *Monitor) GRPCHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.ProtoMajor != 2 ||
+			!strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			http.Error(w, "gRPC requires a POST over HTTP/2 with an application/grpc "+
+				"content type", http.StatusUnsupportedMediaType)
+			return
+		}
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+
+		status := &grpcError{code: grpcOK}
+		if err := m.serveGRPC(w, r); err != nil && !errors.As(err, &status) {
+			status = &grpcError{code: grpcInternal, message: err.Error()}
+		}
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(status.code)))
+		if status.message != "" {
+			w.Header().Set("Grpc-Message", url.PathEscape(status.message))
+		}
+		if status.code != grpcOK && status.code != grpcCanceled {
+			m.log().Debug("gRPC call " + r.URL.Path + " from " + r.RemoteAddr +
+				" failed! " + status.message)
+		}
+	})
+}
+
+// GRPCHandler serves the Stats service for the default Monitor, see
+// Monitor.GRPCHandler
+func GRPCHandler() http.Handler { return defaultMonitor.GRPCHandler() }
+
+// StartGRPCServer serves the Stats service of the default Monitor on `addr` over TLS,
+// with the certificate and key in the PEM files `certFile` and `keyFile`, until the
+// server fails, or returns nil once the default Monitor is shut down
+func StartGRPCServer(addr string, certFile string, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	logger().Info("Serving gRPC (" + GRPC_SERVICE + ") on " + addr)
+	server := &http.Server{Addr: addr, Handler: GRPCHandler(),
+		ReadHeaderTimeout: 10 * time.Second, TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}}
+	return defaultMonitor.serve(server)
+}
+
+// serveGRPC reads the request message and runs the method
+func (m *Monitor) serveGRPC(w http.ResponseWriter, r *http.Request) error {
+	request, err := readGRPCMessage(r.Body)
+	if err != nil {
+		return err
+	}
+	switch r.URL.Path {
+	case "/" + GRPC_SERVICE + "/Snapshot":
+		return m.sendGRPCSnapshot(w)
+	case "/" + GRPC_SERVICE + "/Watch":
+		interval, err := decodeWatchRequest(request)
+		if err != nil {
+			return err
+		}
+		return m.watchGRPC(w, r, interval)
+	}
+	return &grpcError{code: grpcUnimplemented, message: "unknown method " + r.URL.Path}
+}
+
+// watchGRPC sends snapshots until the client cancels the call or the Monitor is shut
+// down. Without an interval, the updates of a polling round are collected for
+// MIN_UPDATE_INTERVAL, so they're sent as a single snapshot
+func (m *Monitor) watchGRPC(w http.ResponseWriter, r *http.Request,
+	interval time.Duration) error {
+	sub, unsubscribe := m.subscribe()
+	defer unsubscribe()
+	if err := m.sendGRPCSnapshot(w); err != nil {
+		return err
+	}
+
+	var due, pending <-chan time.Time
+	if interval > 0 {
+		due = GetClock().After(interval)
+	}
+	for {
+		select {
+		case <-r.Context().Done():
+			return &grpcError{code: grpcCanceled, message: "the client canceled the call"}
+		case <-m.done():
+			return nil
+		case _, ok := <-sub.C:
+			if !ok {
+				return nil
+			}
+			if interval == 0 && pending == nil {
+				pending = GetClock().After(MIN_UPDATE_INTERVAL)
+			}
+			continue
+		case <-pending:
+			pending = nil
+		case <-due:
+			due = GetClock().After(interval)
+		}
+		if err := m.sendGRPCSnapshot(w); err != nil {
+			return err
+		}
+	}
+}
+
+// sendGRPCSnapshot sends a snapshot as a single message of the response
+func (m *Monitor) sendGRPCSnapshot(w http.ResponseWriter) error {
+	// redacting removes the fields from the JSON, so they're zero values once decoded
+	data, err := GetConfiguredRedactionProfile().Redact(m.Snapshot())
+	if err != nil {
+		return err
+	}
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+
+	message := protoSnapshot(snapshot).buf
+	frame := make([]byte, 5, 5+len(message))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(message)))
+	if _, err := w.Write(append(frame, message...)); err != nil {
+		return &grpcError{code: grpcUnavailable, message: err.Error()}
+	}
+	return http.NewResponseController(w).Flush()
+}
+
+// readGRPCMessage reads the single request message of a call: a compressed flag, the
+// length (big endian) and the message
+func readGRPCMessage(body io.Reader) ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		return nil, &grpcError{code: grpcInvalidArgument,
+			message: "missing request message: " + err.Error()}
+	}
+	if prefix[0] != 0 {
+		return nil, &grpcError{code: grpcUnimplemented,
+			message: "compressed requests are not supported"}
+	}
+	length := binary.BigEndian.Uint32(prefix[1:])
+	if length > GRPC_MAX_REQUEST_SIZE {
+		return nil, &grpcError{code: grpcResourceExhausted,
+			message: "the request message is too large"}
+	}
+	message := make([]byte, length)
+	if _, err := io.ReadFull(body, message); err != nil {
+		return nil, &grpcError{code: grpcInvalidArgument,
+			message: "truncated request message: " + err.Error()}
+	}
+	return message, nil
+}
+
+// decodeWatchRequest returns the interval of a WatchRequest. Unknown fields are skipped
+// like protobuf does
+func decodeWatchRequest(message []byte) (time.Duration, error) {
+	invalid := &grpcError{code: grpcInvalidArgument, message: "invalid WatchRequest"}
+	var intervalMs int64
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		if n <= 0 {
+			return 0, invalid
+		}
+		message = message[n:]
+		switch field, wireType := tag>>3, tag&7; wireType {
+		case protoVarint:
+			value, n := binary.Uvarint(message)
+			if n <= 0 {
+				return 0, invalid
+			}
+			message = message[n:]
+			if field == 1 {
+				intervalMs = int64(value)
+			}
+		case protoFixed64, protoFixed32:
+			size := 8
+			if wireType == protoFixed32 {
+				size = 4
+			}
+			if len(message) < size {
+				return 0, invalid
+			}
+			message = message[size:]
+		case protoBytes:
+			length, n := binary.Uvarint(message)
+			if n <= 0 || uint64(len(message)-n) < length {
+				return 0, invalid
+			}
+			message = message[n+int(length):]
+		default:
+			return 0, invalid
+		}
+	}
+
+	interval := time.Duration(intervalMs) * time.Millisecond
+	if intervalMs < 0 || interval > 0 && interval < MIN_UPDATE_INTERVAL {
+		return 0, &grpcError{code: grpcInvalidArgument,
+			message: "interval_ms must be 0 or at least " +
+				strconv.FormatInt(MIN_UPDATE_INTERVAL.Milliseconds(), 10)}
+	}
+	return interval, nil
+}
+
+//// Protobuf ////########################################################################
+
+// Wire types of the protobuf encoding
+const (
+	protoVarint  = 0
+	protoFixed64 = 1
+	protoBytes   = 2
+	protoFixed32 = 5
+)
+
+// protoEncoder encodes a protobuf message. Like proto3, fields with their zero value
+// are left out, except messages
+type protoEncoder struct {
+	buf []byte
+}
+
+func (e *protoEncoder) tag(field int, wireType int) {
+	e.buf = binary.AppendUvarint(e.buf, uint64(field)<<3|uint64(wireType))
+}
+
+func (e *protoEncoder) uint64(field int, v uint64) {
+	if v != 0 {
+		e.tag(field, protoVarint)
+		e.buf = binary.AppendUvarint(e.buf, v)
+	}
+}
+
+// int64 also encodes int32 fields: negative numbers are sign extended to 10 bytes
+func (e *protoEncoder) int64(field int, v int64) { e.uint64(field, uint64(v)) }
+
+func (e *protoEncoder) bool(field int, v bool) {
+	if v {
+		e.uint64(field, 1)
+	}
+}
+
+func (e *protoEncoder) double(field int, v float64) {
+	if v != 0 {
+		e.tag(field, protoFixed64)
+		e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(v))
+	}
+}
+
+// doubles encodes a repeated double, packed
+func (e *protoEncoder) doubles(field int, v []float64) {
+	if len(v) > 0 {
+		e.tag(field, protoBytes)
+		e.buf = binary.AppendUvarint(e.buf, uint64(8*len(v)))
+		for _, f := range v {
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, math.Float64bits(f))
+		}
+	}
+}
+
+func (e *protoEncoder) string(field int, v string) {
+	if v != "" {
+		e.tag(field, protoBytes)
+		e.buf = binary.AppendUvarint(e.buf, uint64(len(v)))
+		e.buf = append(e.buf, v...)
+	}
+}
+
+func (e *protoEncoder) message(field int, message *protoEncoder) {
+	e.tag(field, protoBytes)
+	e.buf = binary.AppendUvarint(e.buf, uint64(len(message.buf)))
+	e.buf = append(e.buf, message.buf...)
+}
+
+// unixNano returns the time as the *_unix_nano fields, 0 for the zero time
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// The proto* functions encode the messages of proto/gtm/v1/gtm.proto, with the same
+// field numbers
+
+func protoSnapshot(s Snapshot) *protoEncoder {
+	e := &protoEncoder{}
+	e.int64(1, int64(s.SchemaVersion))
+	e.int64(2, unixNano(s.Timestamp))
+	e.message(3, protoHostInfo(s.Host))
+	e.message(4, protoCPUStats(s.CPU))
+	if s.Memory != nil {
+		e.message(5, protoMemoryStats(*s.Memory))
+	}
+	for _, disk := range s.Disks {
+		e.message(6, protoDiskStats(disk))
+	}
+	for _, iface := range s.Network {
+		e.message(7, protoNetStats(iface))
+	}
+	for _, gpu := range s.GPU {
+		e.message(8, protoGPUStats(gpu))
+	}
+	for _, proc := range s.Processes {
+		e.message(9, protoProcStats(proc))
+	}
+	// map entries are messages with the key as field 1 and the value as field 2
+	for _, name := range slices.Sorted(maps.Keys(s.Plugins)) {
+		metrics := &protoEncoder{}
+		for _, metric := range s.Plugins[name] {
+			metrics.message(1, protoPluginMetric(metric))
+		}
+		entry := &protoEncoder{}
+		entry.string(1, name)
+		entry.message(2, metrics)
+		e.message(10, entry)
+	}
+	for _, err := range s.Errors {
+		e.string(11, err)
+	}
+	return e
+}
+
+func protoHostInfo(h HostInfo) *protoEncoder {
+	e := &protoEncoder{}
+	e.string(1, h.Hostname)
+	e.string(2, h.OS)
+	e.string(3, h.Platform)
+	e.string(4, h.PlatformFamily)
+	e.string(5, h.PlatformVersion)
+	e.string(6, h.KernelVersion)
+	e.string(7, h.KernelArch)
+	e.string(8, h.OSName)
+	e.string(9, h.OSVersion)
+	e.string(10, h.OSPrettyName)
+	e.string(11, h.OSBuild)
+	e.int64(12, unixNano(h.BootTime))
+	e.int64(13, int64(h.Uptime))
+	e.uint64(14, h.Procs)
+	e.string(15, h.VirtualizationSystem)
+	e.string(16, h.VirtualizationRole)
+	e.string(17, h.HostID)
+	return e
+}
+
+func protoCPUStats(c CPUStats) *protoEncoder {
+	e := &protoEncoder{}
+	e.double(1, c.UsagePercent)
+	e.doubles(2, c.CoreUsagePercent)
+	return e
+}
+
+func protoMemoryStats(mem MemoryStats) *protoEncoder {
+	e := &protoEncoder{}
+	e.uint64(1, mem.Total)
+	e.uint64(2, mem.Available)
+	e.uint64(3, mem.Used)
+	e.double(4, mem.UsedPercent)
+	e.uint64(5, mem.Free)
+	e.uint64(6, mem.SwapTotal)
+	e.uint64(7, mem.SwapFree)
+	return e
+}
+
+func protoDiskStats(d DiskStats) *protoEncoder {
+	e := &protoEncoder{}
+	e.string(1, d.Mountpoint)
+	e.string(2, d.Alias)
+	e.string(3, d.Device)
+	e.int64(4, int64(d.FSType))
+	e.bool(5, d.IsVirtualDisk)
+	e.bool(6, d.IsEncrypted)
+	e.uint64(7, d.Free)
+	e.uint64(8, d.Used)
+	e.double(9, d.UsedPercent)
+	e.uint64(10, d.Total)
+	e.double(11, d.ReadBytesPerSec)
+	e.double(12, d.WriteBytesPerSec)
+	e.double(13, d.GrowthBytesPerDay)
+	e.double(14, d.DaysUntilFull)
+	e.string(15, d.Error)
+	return e
+}
+
+func protoNetStats(n NetStats) *protoEncoder {
+	e := &protoEncoder{}
+	e.string(1, n.Name)
+	e.string(2, n.Alias)
+	e.uint64(3, n.BytesSent)
+	e.uint64(4, n.BytesRecv)
+	e.uint64(5, n.PacketsSent)
+	e.uint64(6, n.PacketsRecv)
+	e.uint64(7, n.ErrIn)
+	e.uint64(8, n.ErrOut)
+	e.uint64(9, n.DropIn)
+	e.uint64(10, n.DropOut)
+	e.double(11, n.UploadBytesPerSec)
+	e.double(12, n.DownloadBytesPerSec)
+	e.double(13, n.UploadPacketsPerSec)
+	e.double(14, n.DownloadPacketsPerSec)
+	e.double(15, n.ErrInPerSec)
+	e.double(16, n.ErrOutPerSec)
+	e.double(17, n.DropInPerSec)
+	e.double(18, n.DropOutPerSec)
+	e.double(19, n.UploadBitsPerSec)
+	e.double(20, n.DownloadBitsPerSec)
+	if split := n.IPSplit; split != nil {
+		s := &protoEncoder{}
+		s.uint64(1, split.IPv4BytesSent)
+		s.uint64(2, split.IPv4BytesRecv)
+		s.uint64(3, split.IPv6BytesSent)
+		s.uint64(4, split.IPv6BytesRecv)
+		s.double(5, split.IPv4UploadBytesPerSec)
+		s.double(6, split.IPv4DownloadBytesPerSec)
+		s.double(7, split.IPv6UploadBytesPerSec)
+		s.double(8, split.IPv6DownloadBytesPerSec)
+		e.message(21, s)
+	}
+	return e
+}
+
+func protoGPUStats(g GPUStats) *protoEncoder {
+	e := &protoEncoder{}
+	e.int64(1, int64(g.Id))
+	e.string(2, g.Alias)
+	e.double(3, g.Load)
+	e.double(4, g.MemoryUsage)
+	e.double(5, g.MemoryTotal)
+	e.double(6, g.Power)
+	e.int64(7, int64(g.Temperature))
+	e.string(8, g.Error)
+	return e
+}
+
+func protoProcStats(p ProcStats) *protoEncoder {
+	e := &protoEncoder{}
+	e.int64(1, int64(p.PID))
+	e.int64(2, int64(p.PPID))
+	e.string(3, p.Name)
+	e.string(4, p.User)
+	e.double(5, p.CPUPercent)
+	e.uint64(6, p.RSS)
+	e.double(7, p.MemoryPercent)
+	e.string(8, p.State)
+	e.int64(9, int64(p.Threads))
+	e.int64(10, int64(p.Nice))
+	e.int64(11, int64(p.Handles))
+	e.string(12, p.ContainerID)
+	e.string(13, p.ContainerRuntime)
+	e.string(14, p.Service)
+	e.uint64(15, p.GPUMemory)
+	e.uint64(16, p.ReadBytes)
+	e.uint64(17, p.WriteBytes)
+	e.double(18, p.ReadBytesPerSec)
+	e.double(19, p.WriteBytesPerSec)
+	return e
+}
+
+func protoPluginMetric(p PluginMetric) *protoEncoder {
+	e := &protoEncoder{}
+	e.string(1, p.Name)
+	e.double(2, p.Value)
+	e.string(3, string(p.Type))
+	e.string(4, p.Unit)
+	e.string(5, p.Help)
+	return e
+}
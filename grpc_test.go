@@ -0,0 +1,139 @@
+package gtm
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// grpcCall posts a request message to the Stats service of `server` over HTTP/2
+func grpcCall(t *testing.T, ctx context.Context, server *httptest.Server, method string,
+	message []byte) *http.Response {
+	t.Helper()
+	frame := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(message)))
+	url := server.URL + "/" + GRPC_SERVICE + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url,
+		bytes.NewReader(append(frame, message...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	resp, err := server.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ProtoMajor != 2 {
+		t.Fatalf("the call used HTTP/%d, gRPC needs HTTP/2", resp.ProtoMajor)
+	}
+	return resp
+}
+
+// readSnapshot reads a response message and decodes the fields of the Snapshot the
+// test checks: schema_version and the usage of the CPU
+func readSnapshot(t *testing.T, body io.Reader) (schemaVersion uint64, cpuUsage float64) {
+	t.Helper()
+	var prefix [5]byte
+	if _, err := io.ReadFull(body, prefix[:]); err != nil {
+		t.Fatal(err)
+	}
+	message := make([]byte, binary.BigEndian.Uint32(prefix[1:]))
+	if _, err := io.ReadFull(body, message); err != nil {
+		t.Fatal(err)
+	}
+
+	fields := protoFields(t, message)
+	schemaVersion, _ = binary.Uvarint(fields[1])
+	cpu := protoFields(t, fields[4])
+	cpuUsage = math.Float64frombits(binary.LittleEndian.Uint64(cpu[1]))
+	return schemaVersion, cpuUsage
+}
+
+// protoFields returns the last value of every varint, fixed64 and length delimited
+// field of a message, as its raw bytes
+func protoFields(t *testing.T, message []byte) map[uint64][]byte {
+	t.Helper()
+	fields := map[uint64][]byte{}
+	for len(message) > 0 {
+		tag, n := binary.Uvarint(message)
+		message = message[n:]
+		switch tag & 7 {
+		case protoVarint:
+			_, n = binary.Uvarint(message)
+		case protoFixed64:
+			n = 8
+		case protoBytes:
+			length, m := binary.Uvarint(message)
+			message = message[m:]
+			n = int(length)
+		default:
+			t.Fatalf("unexpected wire type %d", tag&7)
+		}
+		fields[tag>>3] = message[:n]
+		message = message[n:]
+	}
+	return fields
+}
+
+func TestGRPCSnapshot(t *testing.T) {
+	m := NewMonitor(WithCPUSource(fakeCPUSource{}))
+	server := httptest.NewUnstartedServer(m.GRPCHandler())
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	resp := grpcCall(t, context.Background(), server, "Snapshot", nil)
+	defer resp.Body.Close()
+	schemaVersion, cpuUsage := readSnapshot(t, resp.Body)
+	if schemaVersion != SCHEMA_VERSION {
+		t.Errorf("schema_version %d, want %d", schemaVersion, SCHEMA_VERSION)
+	}
+	if want, _ := m.CPUStats(); cpuUsage != want.UsagePercent {
+		t.Errorf("cpu.usage_percent %v, want %v", cpuUsage, want.UsagePercent)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if status := resp.Trailer.Get("Grpc-Status"); status != "0" {
+		t.Errorf("grpc-status %q, want 0: %s", status, resp.Trailer.Get("Grpc-Message"))
+	}
+
+	unknown := grpcCall(t, context.Background(), server, "Unknown", nil)
+	defer unknown.Body.Close()
+	_, _ = io.Copy(io.Discard, unknown.Body)
+	if status := unknown.Trailer.Get("Grpc-Status"); status != "12" {
+		t.Errorf("grpc-status %q for an unknown method, want 12 (UNIMPLEMENTED)", status)
+	}
+}
+
+func TestGRPCWatch(t *testing.T) {
+	m := NewMonitor(WithCPUSource(fakeCPUSource{}))
+	server := httptest.NewUnstartedServer(m.GRPCHandler())
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	// interval_ms = 100, the minimum
+	request := binary.AppendUvarint([]byte{1 << 3}, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	resp := grpcCall(t, ctx, server, "Watch", request)
+	defer resp.Body.Close()
+	for range 2 {
+		schemaVersion, _ := readSnapshot(t, resp.Body)
+		if schemaVersion != SCHEMA_VERSION {
+			t.Errorf("schema_version %d, want %d", schemaVersion, SCHEMA_VERSION)
+		}
+	}
+	cancel()
+
+	tooShort := grpcCall(t, context.Background(), server, "Watch",
+		binary.AppendUvarint([]byte{1 << 3}, 1))
+	defer tooShort.Body.Close()
+	_, _ = io.Copy(io.Discard, tooShort.Body)
+	if status := tooShort.Trailer.Get("Grpc-Status"); status != "3" {
+		t.Errorf("grpc-status %q for a 1ms interval, want 3 (INVALID_ARGUMENT)", status)
+	}
+}
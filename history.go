@@ -0,0 +1,352 @@
+package gtm
+
+import (
+	"github.com/euheimr/ringbuffer"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DISK_HISTORY_DURATION is how far back disk history is kept. The number of samples
+// stored per mountpoint is DISK_HISTORY_DURATION / DISK_STATS_UPDATE_INTERVAL
+const DISK_HISTORY_DURATION = time.Hour
+
+// DISK_PROJECTION_MIN_SPAN is the minimum amount of history needed before a growth rate
+// and days-until-full projection is calculated for a mountpoint
+const DISK_PROJECTION_MIN_SPAN = 10 * time.Minute
+
+// DiskRingBuffer holds the history of a single mountpoint. Every buffer is written
+// together, so the same index in each buffer belongs to the same sample
+type DiskRingBuffer struct {
+	Timestamp        *ringbuffer.RingBuffer[int64] // unix milliseconds
+	Used             *ringbuffer.RingBuffer[uint64]
+	UsedPercent      *ringbuffer.RingBuffer[float64]
+	ReadBytesPerSec  *ringbuffer.RingBuffer[float64]
+	WriteBytesPerSec *ringbuffer.RingBuffer[float64]
+}
+
+// DiskHistory is a copy of the samples stored for a mountpoint, oldest first
+type DiskHistory struct {
+	Mountpoint       string      `json:"mountpoint"`
+	Device           string      `json:"device"`
+	Timestamps       []time.Time `json:"timestamps"`
+	Used             []uint64    `json:"used"`
+	UsedPercent      []float64   `json:"used_percent"`
+	ReadBytesPerSec  []float64   `json:"read_bytes_per_sec"`
+	WriteBytesPerSec []float64   `json:"write_bytes_per_sec"`
+}
+
+var (
+	diskHistory    = map[string]*DiskRingBuffer{}
+	diskHistoryDev = map[string]string{}
+	diskHistoryMut sync.Mutex
+)
+
+func newDiskRingBuffer() (*DiskRingBuffer, error) {
+	capacity := int(DISK_HISTORY_DURATION / DISK_STATS_UPDATE_INTERVAL)
+
+	timestamp, err := ringbuffer.New[int64](capacity)
+	if err != nil {
+		return nil, err
+	}
+	used, err := ringbuffer.New[uint64](capacity)
+	if err != nil {
+		return nil, err
+	}
+	usedPercent, err := ringbuffer.New[float64](capacity)
+	if err != nil {
+		return nil, err
+	}
+	readBytesPerSec, err := ringbuffer.New[float64](capacity)
+	if err != nil {
+		return nil, err
+	}
+	writeBytesPerSec, err := ringbuffer.New[float64](capacity)
+	if err != nil {
+		return nil, err
+	}
+	return &DiskRingBuffer{
+		Timestamp:        timestamp,
+		Used:             used,
+		UsedPercent:      usedPercent,
+		ReadBytesPerSec:  readBytesPerSec,
+		WriteBytesPerSec: writeBytesPerSec,
+	}, nil
+}
+
+func recordDiskHistory(timestamp time.Time, stats DiskStats) {
+	diskHistoryMut.Lock()
+	defer diskHistoryMut.Unlock()
+
+	rb, ok := diskHistory[stats.Mountpoint]
+	if !ok {
+		var err error
+		if rb, err = newDiskRingBuffer(); err != nil {
+			logger().Error("Failed to create disk history for " + stats.Mountpoint + " ! " +
+				err.Error())
+			return
+		}
+		diskHistory[stats.Mountpoint] = rb
+	}
+	diskHistoryDev[stats.Mountpoint] = stats.Device
+
+	// GetDisksStats and GetAllDisksStats may both fetch the same mountpoint, so only keep
+	//	one sample per DISK_STATS_UPDATE_INTERVAL
+	if timestamps := rb.Timestamp.Read(); len(timestamps) > 0 &&
+		timestamp.Sub(time.UnixMilli(timestamps[len(timestamps)-1])) <
+			DISK_STATS_UPDATE_INTERVAL/2 {
+		return
+	}
+
+	rb.Timestamp.Write(timestamp.UnixMilli())
+	rb.Used.Write(stats.Used)
+	rb.UsedPercent.Write(stats.UsedPercent)
+	rb.ReadBytesPerSec.Write(stats.ReadBytesPerSec)
+	rb.WriteBytesPerSec.Write(stats.WriteBytesPerSec)
+}
+
+// GetDiskHistory returns the stored samples for a mountpoint that are newer than
+// `window`. A window of 0 (or less) returns every stored sample. The boolean is false
+// when no history exists for the mountpoint
+func GetDiskHistory(mountpoint string, window time.Duration) (DiskHistory, bool) {
+	diskHistoryMut.Lock()
+	defer diskHistoryMut.Unlock()
+
+	history := DiskHistory{Mountpoint: mountpoint}
+	rb, ok := diskHistory[mountpoint]
+	if !ok {
+		return history, false
+	}
+	history.Device = diskHistoryDev[mountpoint]
+
+	timestamps := rb.Timestamp.Read()
+	used := rb.Used.Read()
+	usedPercent := rb.UsedPercent.Read()
+	readBytesPerSec := rb.ReadBytesPerSec.Read()
+	writeBytesPerSec := rb.WriteBytesPerSec.Read()
+
+	var cutoff int64
+	if window > 0 {
+		cutoff = GetClock().Now().Add(-window).UnixMilli()
+	}
+	for i, ts := range timestamps {
+		if ts < cutoff {
+			continue
+		}
+		history.Timestamps = append(history.Timestamps, time.UnixMilli(ts))
+		history.Used = append(history.Used, used[i])
+		history.UsedPercent = append(history.UsedPercent, usedPercent[i])
+		history.ReadBytesPerSec = append(history.ReadBytesPerSec, readBytesPerSec[i])
+		history.WriteBytesPerSec = append(history.WriteBytesPerSec, writeBytesPerSec[i])
+	}
+	return history, true
+}
+
+// GetDiskHistoryMountpoints returns every mountpoint that has recorded history, sorted
+func GetDiskHistoryMountpoints() []string {
+	diskHistoryMut.Lock()
+	defer diskHistoryMut.Unlock()
+
+	mountpoints := make([]string, 0, len(diskHistory))
+	for mountpoint := range diskHistory {
+		mountpoints = append(mountpoints, mountpoint)
+	}
+	sort.Strings(mountpoints)
+	return mountpoints
+}
+
+// projectDiskFull fits a least squares line through the used bytes history of a
+// mountpoint and returns the growth in bytes per day plus the days left until the
+// remaining `free` bytes are used up. daysUntilFull is -1 when the disk isn't growing.
+// ok is false when there isn't DISK_PROJECTION_MIN_SPAN worth of history yet
+func projectDiskFull(mountpoint string, free uint64) (bytesPerDay float64,
+	daysUntilFull float64, ok bool) {
+
+	history, found := GetDiskHistory(mountpoint, 0)
+	samples := len(history.Timestamps)
+	if !found || samples < 2 ||
+		history.Timestamps[samples-1].Sub(history.Timestamps[0]) < DISK_PROJECTION_MIN_SPAN {
+		return 0, -1, false
+	}
+
+	// x is seconds since the first sample, y is bytes used relative to the first sample.
+	//	Both are offset from the first sample to keep the sums small enough to not lose
+	//	precision on multi terabyte disks
+	var sumX, sumY, sumXY, sumXX float64
+	for i, ts := range history.Timestamps {
+		x := ts.Sub(history.Timestamps[0]).Seconds()
+		y := float64(history.Used[i]) - float64(history.Used[0])
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	n := float64(samples)
+	denominator := n*sumXX - sumX*sumX
+	if denominator == 0 {
+		return 0, -1, false
+	}
+	bytesPerSecond := (n*sumXY - sumX*sumY) / denominator
+	bytesPerDay = bytesPerSecond * (24 * time.Hour).Seconds()
+
+	if bytesPerDay <= 0 {
+		return bytesPerDay, -1, true
+	}
+	return bytesPerDay, float64(free) / bytesPerDay, true
+}
+
+// METRIC_HISTORY_DURATION is how far back the history of every scalar metric (see
+// recordMetric) is kept
+const METRIC_HISTORY_DURATION = time.Hour
+
+// METRIC_HISTORY_CAPACITY is the number of samples kept per metric, enough for one
+// sample per second over METRIC_HISTORY_DURATION
+const METRIC_HISTORY_CAPACITY = int(METRIC_HISTORY_DURATION / time.Second)
+
+// Names of the metrics recorded in the metric history. Per-device metrics are named
+// "<kind>.<device>.<metric>", ie. "gpu.0.temperature" or "net.eth0.download_bytes_per_sec"
+const (
+	MetricCPUUsage       = "cpu.usage_percent"
+	MetricMemoryUsed     = "memory.used_percent"
+	MetricPowerTotal     = "power.total_watts"
+	MetricDiskUsed       = "used_percent"
+	MetricDiskRead       = "read_bytes_per_sec"
+	MetricDiskWrite      = "write_bytes_per_sec"
+	MetricGPULoad        = "load_percent"
+	MetricGPUMemoryUsed  = "memory_used"
+	MetricGPUPower       = "power"
+	MetricGPUTemperature = "temperature"
+	MetricNetDownload    = "download_bytes_per_sec"
+	MetricNetUpload      = "upload_bytes_per_sec"
+)
+
+const (
+	metricPrefixBattery   = "battery."
+	metricPrefixCPU       = "cpu."
+	metricPrefixDisk      = "disk."
+	metricPrefixGPU       = "gpu."
+	metricPrefixNet       = "net."
+	metricPrefixProcesses = "processes."
+	metricPrefixSensor    = "sensor."
+	metricPrefixUPS       = "ups."
+	metricPrefixWiFi      = "wifi."
+	metricDeviceSeparator = "."
+	// metricHistoryMaxMetrics caps the number of metrics with history, ie. when
+	//	thousands of short lived veth interfaces come and go
+	metricHistoryMaxMetrics = 1024
+)
+
+// MetricRingBuffer holds the history of a single scalar metric
+type MetricRingBuffer struct {
+	Timestamp *ringbuffer.RingBuffer[int64] // unix milliseconds
+	Value     *ringbuffer.RingBuffer[float64]
+}
+
+// MetricHistory is a copy of the samples stored for a metric, oldest first, along with
+// the annotations that apply to it
+type MetricHistory struct {
+	Name        string       `json:"name"`
+	Timestamps  []time.Time  `json:"timestamps"`
+	Values      []float64    `json:"values"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
+
+var (
+	metricHistory    = map[string]*MetricRingBuffer{}
+	metricHistoryMut sync.Mutex
+)
+
+// CPUCoreMetric, DiskMetric, GPUMetric and NetMetric build the name of a per-device
+// metric. The usage of a core is "cpu.<core>.usage_percent", next to MetricCPUUsage
+func CPUCoreMetric(core int) string {
+	_, metric, _ := strings.Cut(MetricCPUUsage, metricDeviceSeparator)
+	return metricPrefixCPU + strconv.Itoa(core) + metricDeviceSeparator + metric
+}
+
+func DiskMetric(mountpoint string, metric string) string {
+	return metricPrefixDisk + mountpoint + metricDeviceSeparator + metric
+}
+
+func GPUMetric(id int32, metric string) string {
+	return metricPrefixGPU + strconv.FormatInt(int64(id), 10) + metricDeviceSeparator + metric
+}
+
+func NetMetric(iface string, metric string) string {
+	return metricPrefixNet + iface + metricDeviceSeparator + metric
+}
+
+// recordMetric adds a sample to the history of a metric
+func recordMetric(name string, timestamp time.Time, value float64) {
+	observeSessionMetric(name, value)
+
+	metricHistoryMut.Lock()
+	defer metricHistoryMut.Unlock()
+
+	rb, ok := metricHistory[name]
+	if !ok {
+		if len(metricHistory) >= metricHistoryMaxMetrics {
+			logger().Debug("Metric history is full, not recording: " + name)
+			return
+		}
+		timestamps, err := ringbuffer.New[int64](METRIC_HISTORY_CAPACITY)
+		if err != nil {
+			logger().Error("Failed to create metric history for " + name + " ! " + err.Error())
+			return
+		}
+		values, err := ringbuffer.New[float64](METRIC_HISTORY_CAPACITY)
+		if err != nil {
+			logger().Error("Failed to create metric history for " + name + " ! " + err.Error())
+			return
+		}
+		rb = &MetricRingBuffer{Timestamp: timestamps, Value: values}
+		metricHistory[name] = rb
+	}
+	rb.Timestamp.Write(timestamp.UnixMilli())
+	rb.Value.Write(value)
+}
+
+// GetMetricHistory returns the stored samples of a metric that are newer than `window`.
+// A window of 0 (or less) returns every stored sample. The boolean is false when no
+// history exists for the metric
+func GetMetricHistory(name string, window time.Duration) (MetricHistory, bool) {
+	history := MetricHistory{Name: name, Annotations: GetAnnotations(window, name)}
+
+	metricHistoryMut.Lock()
+	defer metricHistoryMut.Unlock()
+
+	rb, ok := metricHistory[name]
+	if !ok {
+		return history, false
+	}
+	timestamps := rb.Timestamp.Read()
+	values := rb.Value.Read()
+
+	var cutoff int64
+	if window > 0 {
+		cutoff = GetClock().Now().Add(-window).UnixMilli()
+	}
+	for i, ts := range timestamps {
+		if ts < cutoff || i >= len(values) {
+			continue
+		}
+		history.Timestamps = append(history.Timestamps, time.UnixMilli(ts))
+		history.Values = append(history.Values, values[i])
+	}
+	return history, true
+}
+
+// GetMetricHistoryNames returns the name of every metric that has recorded history,
+// sorted
+func GetMetricHistoryNames() []string {
+	metricHistoryMut.Lock()
+	defer metricHistoryMut.Unlock()
+
+	names := make([]string, 0, len(metricHistory))
+	for name := range metricHistory {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,106 @@
+package gtm
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// BeforeCollectHook is called right before a collector of a Monitor fetches, once its
+// cache expired, ie. to start a tracing span
+type BeforeCollectHook func(collector Collector)
+
+// CollectHook is called after every fetch of a collector of a Monitor, whether it failed
+// or not, with how long the fetch took. `stats` and `err` are what the Update of the
+// fetch holds (see Update). Hooks run before the update is published to the
+// subscribers, and the slices and pointers in `stats` are the cached ones, so a hook can
+// modify them in place (ie. to rescale or relabel them). The metric history is recorded
+// before the hooks run
+type CollectHook func(collector Collector, stats any, err error, duration time.Duration)
+
+// hookSet holds the hooks of a Monitor. Hooks are removed by id, since funcs can't be
+// compared. The slices are copied on write, so they're run without holding the mutex
+type hookSet struct {
+	mut    sync.RWMutex
+	nextID int
+	before []hook[BeforeCollectHook]
+	after  []hook[CollectHook]
+}
+
+type hook[T any] struct {
+	id int
+	fn T
+}
+
+// OnBeforeCollect calls `fn` before every fetch of the collectors of the Monitor. Hooks
+// can run with the cache of the collector locked, so they must not call the Get methods
+// of that collector. Call the returned function to remove the hook
+func (m *Monitor) OnBeforeCollect(fn BeforeCollectHook) (remove func()) {
+	m.hooks.mut.Lock()
+	defer m.hooks.mut.Unlock()
+	id := m.hooks.nextID
+	m.hooks.nextID++
+	m.hooks.before = append(slices.Clip(m.hooks.before),
+		hook[BeforeCollectHook]{id: id, fn: fn})
+
+	return func() {
+		m.hooks.mut.Lock()
+		defer m.hooks.mut.Unlock()
+		m.hooks.before = slices.DeleteFunc(slices.Clone(m.hooks.before),
+			func(h hook[BeforeCollectHook]) bool { return h.id == id })
+	}
+}
+
+// OnCollect calls `fn` after every fetch of the collectors of the Monitor, see
+// CollectHook. Like OnBeforeCollect, hooks must not call the Get methods of the
+// collector. Call the returned function to remove the hook
+func (m *Monitor) OnCollect(fn CollectHook) (remove func()) {
+	m.hooks.mut.Lock()
+	defer m.hooks.mut.Unlock()
+	id := m.hooks.nextID
+	m.hooks.nextID++
+	m.hooks.after = append(slices.Clip(m.hooks.after), hook[CollectHook]{id: id, fn: fn})
+
+	return func() {
+		m.hooks.mut.Lock()
+		defer m.hooks.mut.Unlock()
+		m.hooks.after = slices.DeleteFunc(slices.Clone(m.hooks.after),
+			func(h hook[CollectHook]) bool { return h.id == id })
+	}
+}
+
+// OnBeforeCollect adds a hook to the default Monitor, see Monitor.OnBeforeCollect
+func OnBeforeCollect(fn BeforeCollectHook) (remove func()) {
+	return defaultMonitor.OnBeforeCollect(fn)
+}
+
+// OnCollect adds a hook to the default Monitor, see Monitor.OnCollect
+func OnCollect(fn CollectHook) (remove func()) {
+	return defaultMonitor.OnCollect(fn)
+}
+
+// startCollect runs the BeforeCollectHooks and returns when the fetch started
+func (m *Monitor) startCollect(collector Collector) time.Time {
+	m.hooks.mut.RLock()
+	before := m.hooks.before
+	m.hooks.mut.RUnlock()
+	for _, h := range before {
+		h.fn(collector)
+	}
+	return GetClock().Now()
+}
+
+// collected runs the CollectHooks and publishes the update of a fetch that started at
+// `start`
+func (m *Monitor) collected(collector Collector, start time.Time, stats any, err error) {
+	m.hooks.mut.RLock()
+	after := m.hooks.after
+	m.hooks.mut.RUnlock()
+	if len(after) > 0 {
+		duration := GetClock().Since(start)
+		for _, h := range after {
+			h.fn(collector, stats, err, duration)
+		}
+	}
+	m.publish(collector, stats, err)
+}
@@ -0,0 +1,175 @@
+package gtm
+
+import (
+	"strings"
+	"sync"
+)
+
+// MessageID identifies a user facing string that gtm generates. Translations are looked
+// up by MessageID, so the IDs must never change once released
+type MessageID string
+
+// DEFAULT_LANGUAGE is used when no language is configured and as the fallback for any
+// MessageID missing from the configured language
+const DEFAULT_LANGUAGE = "en"
+
+// Panel titles
+const (
+	LblCPUTemp MessageID = "label.cpu_temp"
+	LblDisk    MessageID = "label.disk"
+	LblGPUTemp MessageID = "label.gpu_temp"
+	LblMemory  MessageID = "label.memory"
+	LblNetwork MessageID = "label.network"
+	LblPinned  MessageID = "label.pinned"
+	LblProc    MessageID = "label.processes"
+)
+
+// Text used inside of the panels
+const (
+	MsgCPULoad     MessageID = "msg.cpu_load"
+	MsgDown        MessageID = "msg.down"
+	MsgEndurance   MessageID = "msg.endurance"
+	MsgErrors      MessageID = "msg.errors"
+	MsgGateway     MessageID = "msg.gateway"
+	MsgGPULoad     MessageID = "msg.gpu_load"
+	MsgGPUMemory   MessageID = "msg.gpu_memory"
+	MsgGPUTemp     MessageID = "msg.gpu_temp"
+	MsgMemoryTotal MessageID = "msg.memory_total"
+	MsgMemoryUsed  MessageID = "msg.memory_used"
+	MsgProcCPU     MessageID = "msg.proc_cpu"
+	MsgProcMemory  MessageID = "msg.proc_memory"
+	MsgProcName    MessageID = "msg.proc_name"
+	MsgProcPID     MessageID = "msg.proc_pid"
+	MsgProcUser    MessageID = "msg.proc_user"
+	MsgPublicIP    MessageID = "msg.public_ip"
+	MsgUp          MessageID = "msg.up"
+)
+
+// Catalog maps every MessageID to its translated text for a single language
+type Catalog map[MessageID]string
+
+// Translator is the extension point for localization. Implement it to source
+// translations from somewhere other than the registered catalogs (ie. gettext files or
+// a remote service), then pass it to SetTranslator
+type Translator interface {
+	// Translate returns the text for `id` in language `lang`. The boolean is false when
+	// the translator has no text for that message
+	Translate(lang string, id MessageID) (string, bool)
+}
+
+var catalogEnglish = Catalog{
+	LblCPUTemp:     "CPU Temp",
+	LblDisk:        "HDD / SSD",
+	LblGPUTemp:     "GPU Temp",
+	LblMemory:      "Memory",
+	LblNetwork:     "Network",
+	LblPinned:      "Pinned",
+	LblProc:        "Processes",
+	MsgCPULoad:     "CPU load:",
+	MsgDown:        "DOWN:",
+	MsgEndurance:   "Wear:",
+	MsgErrors:      "ERR/DROP:",
+	MsgGateway:     "GW:",
+	MsgGPULoad:     "Load:",
+	MsgGPUMemory:   "Mem:",
+	MsgGPUTemp:     "Temp:",
+	MsgMemoryTotal: "Total",
+	MsgMemoryUsed:  "Used",
+	MsgProcCPU:     "CPU%",
+	MsgProcMemory:  "MEM%",
+	MsgProcName:    "NAME",
+	MsgProcPID:     "PID",
+	MsgProcUser:    "USER",
+	MsgPublicIP:    "WAN:",
+	MsgUp:          "UP:",
+}
+
+// catalogTranslator is the default Translator, backed by the catalogs added through
+// RegisterCatalog
+type catalogTranslator struct{}
+
+var (
+	catalogs              = map[string]Catalog{DEFAULT_LANGUAGE: catalogEnglish}
+	translator Translator = catalogTranslator{}
+	language              = DEFAULT_LANGUAGE
+	i18nMut    sync.RWMutex
+)
+
+func (catalogTranslator) Translate(lang string, id MessageID) (string, bool) {
+	catalog, ok := catalogs[lang]
+	if !ok {
+		return "", false
+	}
+	text, ok := catalog[id]
+	return text, ok
+}
+
+// RegisterCatalog adds (or replaces) the catalog for a language such as "de" or
+// "pt-BR". Messages missing from the catalog fall back to English
+func RegisterCatalog(lang string, catalog Catalog) {
+	i18nMut.Lock()
+	defer i18nMut.Unlock()
+	catalogs[lang] = catalog
+}
+
+// SetTranslator replaces the default catalog based translator
+func SetTranslator(t Translator) {
+	i18nMut.Lock()
+	defer i18nMut.Unlock()
+	if t == nil {
+		t = catalogTranslator{}
+	}
+	translator = t
+}
+
+// SetLanguage sets the language used by T. An empty string resets it to English.
+// POSIX style values like "de_DE.UTF-8" or "de_DE:de" are normalized to "de-DE"
+func SetLanguage(lang string) {
+	i18nMut.Lock()
+	defer i18nMut.Unlock()
+
+	lang, _, _ = strings.Cut(lang, ":")
+	lang, _, _ = strings.Cut(lang, ".")
+	lang = strings.ReplaceAll(lang, "_", "-")
+	if lang == "" || lang == "C" || lang == "POSIX" {
+		lang = DEFAULT_LANGUAGE
+	}
+	language = lang
+	logger().Debug("Language set to: " + language)
+}
+
+// GetLanguage returns the language currently used by T
+func GetLanguage() string {
+	i18nMut.RLock()
+	defer i18nMut.RUnlock()
+	return language
+}
+
+// T returns the text for `id` in the configured language. Lookups fall back from a
+// regional language to its base language (ie. "pt-BR" -> "pt"), then to English, and
+// finally to the MessageID itself so a missing translation is obvious but never fatal
+func T(id MessageID) string {
+	i18nMut.RLock()
+	defer i18nMut.RUnlock()
+
+	if text, ok := translator.Translate(language, id); ok {
+		return text
+	}
+	if base, _, found := strings.Cut(language, "-"); found {
+		if text, ok := translator.Translate(base, id); ok {
+			return text
+		}
+	}
+	if text, ok := translator.Translate(DEFAULT_LANGUAGE, id); ok {
+		return text
+	}
+	if text, ok := catalogEnglish[id]; ok {
+		return text
+	}
+	return string(id)
+}
+
+// Title returns the translated text for `id` padded for use as a box title
+func Title(id MessageID) string {
+	return " " + T(id) + " "
+}
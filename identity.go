@@ -0,0 +1,114 @@
+package gtm
+
+import (
+	"net"
+	"os"
+	"slices"
+	"sync"
+	"time"
+)
+
+// HOST_IDENTITY_UPDATE_INTERVAL is how often the hostname, domain and addresses are
+// checked for changes, ie. a laptop joining another network
+const HOST_IDENTITY_UPDATE_INTERVAL = 10 * time.Second
+
+// HostIdentity is what the host is known as on the network. IPs are the addresses of
+// every interface that is up, except loopback and link-local addresses, sorted
+type HostIdentity struct {
+	Hostname string   `json:"hostname"`
+	Domain   string   `json:"domain,omitempty"`
+	IPs      []string `json:"ips"`
+}
+
+// HostIdentityEvent is published when the hostname, domain or addresses of the host
+// change
+type HostIdentityEvent struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Previous  HostIdentity `json:"previous"`
+	Current   HostIdentity `json:"current"`
+}
+
+var (
+	hostIdentity          HostIdentity
+	lastFetchHostIdentity time.Time
+	hostIdentityEvents    = NewBroadcaster[HostIdentityEvent]()
+	hostIdentityMut       sync.Mutex
+)
+
+// WatchHostIdentity subscribes to changes of the host identity. Changes are only noticed
+// while the identity is read, ie. by GetHostname or GetHostIdentity. Call the returned
+// function to unsubscribe
+func WatchHostIdentity() (*Subscriber[HostIdentityEvent], func()) {
+	return hostIdentityEvents.Subscribe(0, 0)
+}
+
+// GetHostIdentity returns the hostname, domain and addresses of the host. When any of
+// them changed since the last check, the cached host info is refreshed and a
+// HostIdentityEvent is published. When they can't be read, the last identity is returned
+// with the error
+func GetHostIdentity() (HostIdentity, error) {
+	hostIdentityMut.Lock()
+	defer hostIdentityMut.Unlock()
+
+	if !lastFetchHostIdentity.IsZero() &&
+		GetClock().Since(lastFetchHostIdentity) < HOST_IDENTITY_UPDATE_INTERVAL {
+		return hostIdentity, nil
+	}
+	first := lastFetchHostIdentity.IsZero()
+	lastFetchHostIdentity = GetClock().Now()
+
+	identity, err := readHostIdentity()
+	if err != nil {
+		collectorError(CollectorHost, "Failed to retrieve the host identity!", err)
+		return hostIdentity, err
+	}
+	if first || identity.Equal(hostIdentity) {
+		hostIdentity = identity
+		return hostIdentity, nil
+	}
+
+	event := HostIdentityEvent{Timestamp: lastFetchHostIdentity, Previous: hostIdentity,
+		Current: identity}
+	hostIdentity = identity
+	defaultMonitor.setHostname(identity.Hostname)
+	hostIdentityEvents.Publish(event)
+	return hostIdentity, nil
+}
+
+// Equal reports whether two identities have the same hostname, domain and addresses
+func (h HostIdentity) Equal(other HostIdentity) bool {
+	return h.Hostname == other.Hostname && h.Domain == other.Domain &&
+		slices.Equal(h.IPs, other.IPs)
+}
+
+func readHostIdentity() (HostIdentity, error) {
+	name, err := os.Hostname()
+	if err != nil {
+		return HostIdentity{}, err
+	}
+	identity := HostIdentity{Hostname: name, Domain: readDNSDomain(), IPs: []string{}}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return HostIdentity{}, err
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.IsLinkLocalUnicast() || ipNet.IP.IsLoopback() {
+				continue
+			}
+			identity.IPs = append(identity.IPs, ipNet.IP.String())
+		}
+	}
+	slices.Sort(identity.IPs)
+	identity.IPs = slices.Compact(identity.IPs)
+	return identity, nil
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strings"
+)
+
+// readDNSDomain returns the local domain of RESOLV_CONF_PATH, which DHCP usually sets
+func readDNSDomain() string {
+	data, err := os.ReadFile(RESOLV_CONF_PATH)
+	if err != nil {
+		return ""
+	}
+	return parseResolvDomain(data)
+}
+
+// parseResolvDomain returns the "domain", or else the first "search" domain
+func parseResolvDomain(data []byte) string {
+	domain := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "domain":
+			return fields[1]
+		case "search":
+			if domain == "" {
+				domain = fields[1]
+			}
+		}
+	}
+	return domain
+}
@@ -0,0 +1,14 @@
+package gtm
+
+import "golang.org/x/sys/windows"
+
+// readDNSDomain returns the primary DNS suffix, or the one assigned by DHCP
+func readDNSDomain() string {
+	n := uint32(256)
+	buf := make([]uint16, n)
+	if err := windows.GetComputerNameEx(windows.ComputerNameDnsDomain, &buf[0],
+		&n); err != nil {
+		return ""
+	}
+	return windows.UTF16ToString(buf[:n])
+}
@@ -0,0 +1,60 @@
+package gtm
+
+import "github.com/shirou/gopsutil/v4/net"
+
+// IPSplit is the traffic of an interface split by IP version. The IPv6 counters are the
+// bytes of IPv6 packets, and the IPv4 counters are everything else the interface counted,
+// so they also include link layer overhead and non-IP traffic (ie. ARP)
+type IPSplit struct {
+	IPv4BytesSent           uint64  `json:"ipv4_bytes_sent"`
+	IPv4BytesRecv           uint64  `json:"ipv4_bytes_recv"`
+	IPv6BytesSent           uint64  `json:"ipv6_bytes_sent"`
+	IPv6BytesRecv           uint64  `json:"ipv6_bytes_recv"`
+	IPv4UploadBytesPerSec   float64 `json:"ipv4_upload_bytes_per_sec"`
+	IPv4DownloadBytesPerSec float64 `json:"ipv4_download_bytes_per_sec"`
+	IPv6UploadBytesPerSec   float64 `json:"ipv6_upload_bytes_per_sec"`
+	IPv6DownloadBytesPerSec float64 `json:"ipv6_download_bytes_per_sec"`
+}
+
+// ipv6Counters are the IPv6 byte counters of an interface
+type ipv6Counters struct {
+	BytesSent uint64
+	BytesRecv uint64
+}
+
+// IPv6Share returns the share of IPv6 in the traffic of the interface, 0-100
+func (s IPSplit) IPv6Share() float64 {
+	total := s.IPv4BytesSent + s.IPv4BytesRecv + s.IPv6BytesSent + s.IPv6BytesRecv
+	if total == 0 {
+		return 0
+	}
+	return RoundStat(float64(s.IPv6BytesSent+s.IPv6BytesRecv) / float64(total) * 100)
+}
+
+// newIPSplit splits the counters of an interface. `previous` is the split of the last
+// fetch (nil for the first one), used for the rates
+func newIPSplit(total net.IOCountersStat, v6 ipv6Counters, previous *IPSplit,
+	elapsed float64) *IPSplit {
+
+	split := &IPSplit{
+		IPv6BytesSent: v6.BytesSent,
+		IPv6BytesRecv: v6.BytesRecv,
+	}
+	if total.BytesSent > v6.BytesSent {
+		split.IPv4BytesSent = total.BytesSent - v6.BytesSent
+	}
+	if total.BytesRecv > v6.BytesRecv {
+		split.IPv4BytesRecv = total.BytesRecv - v6.BytesRecv
+	}
+	if previous != nil {
+		split.IPv4UploadBytesPerSec = RoundStat(ratePerSecond(previous.IPv4BytesSent,
+			split.IPv4BytesSent, elapsed))
+		split.IPv4DownloadBytesPerSec = RoundStat(ratePerSecond(previous.IPv4BytesRecv,
+			split.IPv4BytesRecv, elapsed))
+		split.IPv6UploadBytesPerSec = RoundStat(ratePerSecond(previous.IPv6BytesSent,
+			split.IPv6BytesSent, elapsed))
+		split.IPv6DownloadBytesPerSec = RoundStat(ratePerSecond(previous.IPv6BytesRecv,
+			split.IPv6BytesRecv, elapsed))
+	}
+	return split
+}
@@ -0,0 +1,45 @@
+package gtm
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// PROC_NET_SNMP6_DIR has the IPv6 counters of every interface, one file per interface
+const PROC_NET_SNMP6_DIR = "/proc/net/dev_snmp6"
+
+// getIPv6Counters reads Ip6OutOctets and Ip6InOctets of every interface. The directory
+// doesn't exist when IPv6 is disabled, so nothing is split then
+func getIPv6Counters() map[string]ipv6Counters {
+	entries, err := os.ReadDir(PROC_NET_SNMP6_DIR)
+	if err != nil {
+		return nil
+	}
+	counters := make(map[string]ipv6Counters, len(entries))
+	for _, entry := range entries {
+		file, err := os.Open(filepath.Join(PROC_NET_SNMP6_DIR, entry.Name()))
+		if err != nil {
+			continue
+		}
+		var c ipv6Counters
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) != 2 {
+				continue
+			}
+			switch fields[0] {
+			case "Ip6OutOctets":
+				c.BytesSent, _ = strconv.ParseUint(fields[1], 10, 64)
+			case "Ip6InOctets":
+				c.BytesRecv, _ = strconv.ParseUint(fields[1], 10, 64)
+			}
+		}
+		file.Close()
+		counters[entry.Name()] = c
+	}
+	return counters
+}
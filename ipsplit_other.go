@@ -0,0 +1,9 @@
+//go:build !linux
+
+package gtm
+
+// getIPv6Counters returns nothing, since Windows and macOS only count IPv6 traffic for
+// the whole system and not per interface
+func getIPv6Counters() map[string]ipv6Counters {
+	return nil
+}
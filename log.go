@@ -8,11 +8,37 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 const LevelPerf = slog.Level(-5)
 
+var (
+	// pkgLogger is where gtm logs to, nil for the default slog logger (see SetLogger)
+	pkgLogger atomic.Pointer[slog.Logger]
+	// logLevel is the level of the log file, see SetLogLevel
+	logLevel slog.LevelVar
+)
+
+// SetLogger logs the errors and debug lines of gtm to `l` instead of the default slog
+// logger, so applications embedding gtm choose where they go, their level and their
+// format. nil logs to the default slog logger again. Monitors created WithLogger keep
+// logging to their own logger
+func SetLogger(l *slog.Logger) { pkgLogger.Store(l) }
+
+// logger returns the logger set with SetLogger, or the default slog logger
+func logger() *slog.Logger {
+	if l := pkgLogger.Load(); l != nil {
+		return l
+	}
+	return slog.Default()
+}
+
+// SetLogLevel changes the level of the log file created by SetupFileLogging, ie. to
+// debug a running gtm. The file name keeps the level it was created with
+func SetLogLevel(level slog.Level) { logLevel.Set(level) }
+
 func SetupFileLogging() {
 	var (
 		file        io.Writer
@@ -28,12 +54,13 @@ func SetupFileLogging() {
 	}
 
 	if Cfg.Debug && Cfg.PerformanceLogging {
-		opts.Level = LevelPerf
+		logLevel.Set(LevelPerf)
 	} else if Cfg.Debug {
-		opts.Level = slog.LevelDebug
+		logLevel.Set(slog.LevelDebug)
 	} else {
-		opts.Level = slog.LevelInfo
+		logLevel.Set(slog.LevelInfo)
 	}
+	opts.Level = &logLevel
 
 	opts.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
 		if a.Key == slog.LevelKey {
@@ -57,32 +84,32 @@ func SetupFileLogging() {
 
 	cwd, err := os.Getwd()
 	if err != nil {
-		slog.Error("Failed to get current working directory !")
+		logger().Error("Failed to get current working directory !")
 	}
 	logsDir := filepath.Join(cwd, "log")
 
 	if Cfg.DeleteOldLogs {
 		if err = os.RemoveAll(logsDir); err != nil {
-			slog.Error("Failed to remove old log files !")
+			logger().Error("Failed to remove old log files !")
 		}
 	}
 
 	err = os.Mkdir(logsDir, 0750)
 	if errors.Is(err, fs.ErrExist) {
-		slog.Debug("Log directory exists")
+		logger().Debug("Log directory exists")
 	} else if errors.Is(err, fs.ErrNotExist) {
-		slog.Error("Failed to create directory: " + logsDir + " !")
+		logger().Error("Failed to create directory: " + logsDir + " !")
 	}
 
 	if err = os.Chdir(logsDir); err != nil {
-		slog.Error("Failed to change directory: " + logsDir)
+		logger().Error("Failed to change directory: " + logsDir)
 	}
 
 	timestamp := time.Now().Format(time.DateTime)
 	timestampString := strings.ReplaceAll(timestamp, ":", ".")
 	timestampString = strings.ReplaceAll(timestampString, " ", "_")
 
-	switch opts.Level {
+	switch logLevel.Level() {
 	case LevelPerf:
 		logLevelStr = "perf"
 	case slog.LevelDebug:
@@ -99,7 +126,7 @@ func SetupFileLogging() {
 	logFilepath := filepath.Join(logsDir, timestampString+"_"+logLevelStr+".log")
 
 	if file, err = os.Create(logFilepath); err != nil {
-		slog.Error("Failed to create log file at " + logFilepath + " !")
+		logger().Error("Failed to create log file at " + logFilepath + " !")
 	}
 
 	fileHandler := slog.NewJSONHandler(file, opts)
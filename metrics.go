@@ -0,0 +1,270 @@
+package gtm
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricType is how the values of a metric behave over time
+type MetricType string
+
+const (
+	// MetricGauge is a value that goes up and down, ie. a usage percent or a rate
+	MetricGauge MetricType = "gauge"
+	// MetricCounter is a total that only goes up, until it's reset ie. by a reboot
+	MetricCounter MetricType = "counter"
+)
+
+// Units of the metrics. They're spelled out like Prometheus and OpenTelemetry name them,
+// unlike the display units of the sensors (ie. UnitCelsius)
+const (
+	MetricUnitBytes             = "bytes"
+	MetricUnitBytesPerSecond    = "bytes_per_second"
+	MetricUnitCelsius           = "celsius"
+	MetricUnitDecibelMilliwatts = "dbm"
+	MetricUnitMebibytes         = "mebibytes"
+	MetricUnitMegabitsPerSecond = "megabits_per_second"
+	MetricUnitPercent           = "percent"
+	MetricUnitRPM               = "rpm"
+	MetricUnitSeconds           = "seconds"
+	MetricUnitVolts             = "volts"
+	MetricUnitWatts             = "watts"
+)
+
+// Names of the network counters. They're described in the registry for the exporters,
+// but aren't recorded in the metric history like the other metrics
+const (
+	MetricNetBytesRecv = "bytes_recv"
+	MetricNetBytesSent = "bytes_sent"
+)
+
+// Names of the metrics of the collectors outside of the Monitor, which are only sampled
+// by the default Monitor (see Samples). They aren't recorded in the metric history either.
+// The sensors, batteries, UPSes and WiFi interfaces are per-device, like the disks
+const (
+	MetricBatteryCharge     = "charge_percent"
+	MetricBatteryPower      = "power_watts"
+	MetricPowerCPU          = "power.cpu_watts"
+	MetricPowerGPU          = "power.gpu_watts"
+	MetricProcessCount      = "processes.count"
+	MetricSensorFan         = "fan_speed"
+	MetricSensorTemperature = "temperature"
+	MetricSensorVoltage     = "voltage"
+	MetricUPSBattery        = "battery_percent"
+	MetricUPSLoad           = "load_percent"
+	MetricUPSRuntime        = "runtime_seconds"
+	MetricWiFiLinkRate      = "link_rate_megabits_per_sec"
+	MetricWiFiSignal        = "signal_dbm"
+	MetricWiFiSignalPercent = "signal_percent"
+)
+
+// MetricDescriptor describes a metric, so exporters can generate their descriptors
+// (name, type, unit and help) from the registry. Per-device metrics are named without
+// the device, ie. "gpu.temperature" describes "gpu.0.temperature" (see GPUMetric)
+type MetricDescriptor struct {
+	Name      string     `json:"name"`
+	Collector Collector  `json:"collector"`
+	Type      MetricType `json:"type"`
+	Unit      string     `json:"unit"`
+	// Label names the device of per-device metrics: "core", "mountpoint", "gpu",
+	//	"interface", "sensor", "battery", "ups" or the "state" of processes. It's ""
+	//	for the metrics of the whole system
+	Label string `json:"label,omitempty"`
+	Help  string `json:"help"`
+	// Interval is how often the metric is collected. Metrics returns the interval of
+	//	the collector in the Monitor instead, when the Monitor has that collector
+	Interval time.Duration `json:"interval"`
+}
+
+// ErrMetricRegistered is returned by RegisterMetric for names that are taken already
+var ErrMetricRegistered = errors.New("metric is already registered")
+
+var (
+	metricRegistry = newMetricRegistry(
+		// the samples of every core carry the core, the sample of all CPUs carries
+		//	METRIC_LABEL_ALL (see MetricSample.LabelValue)
+		MetricDescriptor{Name: MetricCPUUsage, Collector: CollectorCPU, Type: MetricGauge,
+			Unit: MetricUnitPercent, Label: "core",
+			Help: "Usage of all CPUs, or of a single core"},
+		MetricDescriptor{Name: MetricMemoryUsed, Collector: CollectorMemory,
+			Type: MetricGauge, Unit: MetricUnitPercent, Help: "Used memory"},
+		MetricDescriptor{Name: MetricPowerTotal, Collector: CollectorPower,
+			Type: MetricGauge, Unit: MetricUnitWatts,
+			Help: "Estimated power draw of the system", Interval: POWER_UPDATE_INTERVAL},
+		MetricDescriptor{Name: MetricPowerCPU, Collector: CollectorPower,
+			Type: MetricGauge, Unit: MetricUnitWatts,
+			Help: "Estimated power draw of the CPU", Interval: POWER_UPDATE_INTERVAL},
+		MetricDescriptor{Name: MetricPowerGPU, Collector: CollectorPower,
+			Type: MetricGauge, Unit: MetricUnitWatts,
+			Help: "Power draw of every GPU", Interval: POWER_UPDATE_INTERVAL},
+		// the samples of every state carry the state, the sample of all processes carries
+		//	METRIC_LABEL_ALL
+		MetricDescriptor{Name: MetricProcessCount, Collector: CollectorProcesses,
+			Type: MetricGauge, Label: "state", Interval: PROCS_UPDATE_INTERVAL,
+			Help: "Number of processes, or of processes in a state"},
+		diskMetric(MetricDiskUsed, MetricGauge, MetricUnitPercent,
+			"Used space of the partition"),
+		diskMetric(MetricDiskRead, MetricGauge, MetricUnitBytesPerSecond,
+			"Read rate of the partition"),
+		diskMetric(MetricDiskWrite, MetricGauge, MetricUnitBytesPerSecond,
+			"Write rate of the partition"),
+		gpuMetric(MetricGPULoad, MetricUnitPercent, "Utilization of the GPU"),
+		gpuMetric(MetricGPUMemoryUsed, MetricUnitMebibytes, "Used memory of the GPU"),
+		gpuMetric(MetricGPUPower, MetricUnitWatts, "Power draw of the GPU"),
+		gpuMetric(MetricGPUTemperature, MetricUnitCelsius, "Temperature of the GPU"),
+		netMetric(MetricNetDownload, MetricGauge, MetricUnitBytesPerSecond,
+			"Download rate of the network interface"),
+		netMetric(MetricNetUpload, MetricGauge, MetricUnitBytesPerSecond,
+			"Upload rate of the network interface"),
+		netMetric(MetricNetBytesRecv, MetricCounter, MetricUnitBytes,
+			"Bytes received by the network interface"),
+		netMetric(MetricNetBytesSent, MetricCounter, MetricUnitBytes,
+			"Bytes sent by the network interface"),
+		deviceMetric(CollectorBattery, metricPrefixBattery+MetricBatteryCharge,
+			MetricUnitPercent, "Charge of the battery"),
+		deviceMetric(CollectorBattery, metricPrefixBattery+MetricBatteryPower,
+			MetricUnitWatts, "Charge or discharge rate of the battery"),
+		deviceMetric(CollectorSensors, metricPrefixSensor+MetricSensorFan, MetricUnitRPM,
+			"Speed of the fan"),
+		deviceMetric(CollectorSensors, metricPrefixSensor+MetricSensorTemperature,
+			MetricUnitCelsius, "Temperature of the sensor"),
+		deviceMetric(CollectorSensors, metricPrefixSensor+MetricSensorVoltage,
+			MetricUnitVolts, "Voltage of the sensor"),
+		deviceMetric(CollectorUPS, metricPrefixUPS+MetricUPSBattery, MetricUnitPercent,
+			"Charge of the battery of the UPS"),
+		deviceMetric(CollectorUPS, metricPrefixUPS+MetricUPSLoad, MetricUnitPercent,
+			"Load of the UPS"),
+		deviceMetric(CollectorUPS, metricPrefixUPS+MetricUPSRuntime, MetricUnitSeconds,
+			"Runtime left on the battery of the UPS"),
+		deviceMetric(CollectorWiFi, metricPrefixWiFi+MetricWiFiLinkRate,
+			MetricUnitMegabitsPerSecond, "Link rate of the WiFi interface"),
+		deviceMetric(CollectorWiFi, metricPrefixWiFi+MetricWiFiSignal,
+			MetricUnitDecibelMilliwatts, "Signal strength of the WiFi interface"),
+		deviceMetric(CollectorWiFi, metricPrefixWiFi+MetricWiFiSignalPercent,
+			MetricUnitPercent, "Signal quality of the WiFi interface"),
+	)
+	metricRegistryMut sync.RWMutex
+)
+
+func newMetricRegistry(descs ...MetricDescriptor) map[string]MetricDescriptor {
+	registry := make(map[string]MetricDescriptor, len(descs))
+	for _, desc := range descs {
+		registry[desc.Name] = desc
+	}
+	return registry
+}
+
+// diskMetric, gpuMetric and netMetric describe a per-device metric
+func diskMetric(metric string, typ MetricType, unit, help string) MetricDescriptor {
+	return MetricDescriptor{Name: metricPrefixDisk + metric, Collector: CollectorDisk,
+		Type: typ, Unit: unit, Label: "mountpoint", Help: help}
+}
+
+func gpuMetric(metric string, unit string, help string) MetricDescriptor {
+	return MetricDescriptor{Name: metricPrefixGPU + metric, Collector: CollectorGPU,
+		Type: MetricGauge, Unit: unit, Label: "gpu", Help: help}
+}
+
+func netMetric(metric string, typ MetricType, unit, help string) MetricDescriptor {
+	return MetricDescriptor{Name: metricPrefixNet + metric, Collector: CollectorNetwork,
+		Type: typ, Unit: unit, Label: "interface", Help: help}
+}
+
+// deviceMetric describes a per-device gauge of a collector outside of the Monitor, named
+// by metricDeviceLabels and collected every metricDeviceIntervals
+func deviceMetric(collector Collector, name, unit, help string) MetricDescriptor {
+	return MetricDescriptor{Name: name, Collector: collector, Type: MetricGauge,
+		Unit: unit, Label: metricDeviceLabels[collector], Help: help,
+		Interval: metricDeviceIntervals[collector]}
+}
+
+// metricDeviceLabels and metricDeviceIntervals are the labels and intervals of the
+// collectors described by deviceMetric
+var (
+	metricDeviceLabels = map[Collector]string{
+		CollectorBattery: "battery",
+		CollectorSensors: "sensor",
+		CollectorUPS:     "ups",
+		CollectorWiFi:    "interface",
+	}
+	metricDeviceIntervals = map[Collector]time.Duration{
+		CollectorBattery: BATTERY_UPDATE_INTERVAL,
+		CollectorSensors: SENSORS_UPDATE_INTERVAL,
+		CollectorUPS:     UPS_UPDATE_INTERVAL,
+		CollectorWiFi:    WIFI_UPDATE_INTERVAL,
+	}
+)
+
+// metricDevicePrefixes are the prefixes of the per-device metrics, see LookupMetric
+var metricDevicePrefixes = []string{metricPrefixBattery, metricPrefixCPU,
+	metricPrefixDisk, metricPrefixGPU, metricPrefixNet, metricPrefixProcesses,
+	metricPrefixSensor, metricPrefixUPS, metricPrefixWiFi}
+
+// RegisterMetric adds a metric to the registry, ie. for the stats of a plugin. It fails
+// with ErrMetricRegistered when the name is taken
+func RegisterMetric(desc MetricDescriptor) error {
+	if desc.Name == "" {
+		return errors.New("the metric has no name")
+	}
+	metricRegistryMut.Lock()
+	defer metricRegistryMut.Unlock()
+	if _, ok := metricRegistry[desc.Name]; ok {
+		return fmt.Errorf("%w: %s", ErrMetricRegistered, desc.Name)
+	}
+	metricRegistry[desc.Name] = desc
+	return nil
+}
+
+// Metrics returns the descriptor of every metric in the registry, sorted by name, with
+// the intervals of the Monitor
+func (m *Monitor) Metrics() []MetricDescriptor {
+	metricRegistryMut.RLock()
+	descs := slices.SortedFunc(maps.Values(metricRegistry),
+		func(a, b MetricDescriptor) int { return strings.Compare(a.Name, b.Name) })
+	metricRegistryMut.RUnlock()
+
+	for i := range descs {
+		if interval := m.interval(descs[i].Collector); interval > 0 {
+			descs[i].Interval = interval
+		}
+	}
+	return descs
+}
+
+// Metrics returns the metrics of the default Monitor, see Monitor.Metrics
+func Metrics() []MetricDescriptor { return defaultMonitor.Metrics() }
+
+// LookupMetric returns the descriptor of a metric, by its name or by the name of one of
+// its devices (ie. "gpu.0.temperature"), along with the device ("0"). The interval is
+// the one of the default Monitor
+func LookupMetric(name string) (desc MetricDescriptor, device string, ok bool) {
+	metricRegistryMut.RLock()
+	desc, ok = metricRegistry[name]
+	if !ok {
+		// devices can have dots in their name (ie. "eth0.100"), metrics can't
+		if i := strings.LastIndex(name, metricDeviceSeparator); i > 0 {
+			for _, prefix := range metricDevicePrefixes {
+				if strings.HasPrefix(name[:i], prefix) && len(name[:i]) > len(prefix) {
+					device = name[len(prefix):i]
+					desc, ok = metricRegistry[prefix+name[i+1:]]
+					ok = ok && desc.Label != ""
+					break
+				}
+			}
+		}
+	}
+	metricRegistryMut.RUnlock()
+
+	if !ok {
+		return MetricDescriptor{}, "", false
+	}
+	if interval := defaultMonitor.interval(desc.Collector); interval > 0 {
+		desc.Interval = interval
+	}
+	return desc, device, true
+}
@@ -0,0 +1,265 @@
+package gtm
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"sync"
+	"time"
+)
+
+const (
+	// MIN_UPDATE_INTERVAL is the shortest interval a collector can be set to
+	MIN_UPDATE_INTERVAL = 100 * time.Millisecond
+	// MIN_GPU_UPDATE_INTERVAL is longer, since a single nvidia-smi run takes about as long
+	MIN_GPU_UPDATE_INTERVAL = 500 * time.Millisecond
+	// MIN_DISK_UPDATE_INTERVAL is longer, since every mountpoint is read on each fetch
+	MIN_DISK_UPDATE_INTERVAL = time.Second
+)
+
+// ErrInvalidInterval is returned for intervals below the minimum of a collector, and for
+// collectors the Monitor doesn't have
+var ErrInvalidInterval = errors.New("invalid update interval")
+
+// Monitor collects the CPU, memory, disk, network, GPU and host stats. Every Monitor has
+// its own caches and update intervals, so several Monitors can be used side by side
+// without sharing state. The package level Get functions use the default Monitor, see
+// DefaultMonitor.
+//
+// Only the default Monitor feeds the metric history, disk projections, session summary
+// and bandwidth accounting, so other Monitors don't record the same samples twice
+type Monitor struct {
+	// intervals is how long the stats of each collector are cached
+	intervals   map[Collector]time.Duration
+	intervalMut sync.RWMutex
+	// global is only set on the default Monitor
+	global bool
+	// logger is nil for the logger of gtm, see SetLogger
+	logger *slog.Logger
+	// diskFilters & netFilters are nil for the filters in the config
+	diskFilters *NameFilter
+	netFilters  *NameFilter
+	noGPU       bool
+
+	cpu      cpuCache
+	gpu      gpuCache
+	host     hostCache
+	mem      memCache
+	net      netCache
+	disks    *diskCollection
+	allDisks *diskCollection
+
+	sources  sources
+	poll     pollState
+	updates  *Broadcaster[Update]
+	hooks    hookSet
+	shutdown shutdownState
+
+	plugins   map[Collector]*pluginCollector
+	pluginMut sync.RWMutex
+}
+
+// MonitorOption configures a Monitor created by NewMonitor
+type MonitorOption func(*Monitor)
+
+// WithInterval sets how long the stats of a collector are cached. Invalid intervals
+// (see SetInterval) are logged and ignored
+func WithInterval(collector Collector, interval time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		if err := m.SetInterval(collector, interval); err != nil {
+			m.log().Warn("Ignoring the " + string(collector) + " interval! " + err.Error())
+		}
+	}
+}
+
+// WithCPUInterval sets how long the CPU usage is cached (CPU_STATS_UPDATE_INTERVAL)
+func WithCPUInterval(interval time.Duration) MonitorOption {
+	return WithInterval(CollectorCPU, interval)
+}
+
+// WithDiskInterval sets how long the disk stats are cached (DISK_STATS_UPDATE_INTERVAL)
+func WithDiskInterval(interval time.Duration) MonitorOption {
+	return WithInterval(CollectorDisk, interval)
+}
+
+// WithGPUInterval sets how long the GPU stats are cached (GPU_STATS_UPDATE_INTERVAL)
+func WithGPUInterval(interval time.Duration) MonitorOption {
+	return WithInterval(CollectorGPU, interval)
+}
+
+// WithHostInterval sets how long the host info is cached (HOST_INFO_UPDATE_INTERVAL)
+func WithHostInterval(interval time.Duration) MonitorOption {
+	return WithInterval(CollectorHost, interval)
+}
+
+// WithMemoryInterval sets how long the memory usage is cached (MEM_STATS_UPDATE_INTERVAL)
+func WithMemoryInterval(interval time.Duration) MonitorOption {
+	return WithInterval(CollectorMemory, interval)
+}
+
+// WithNetworkInterval sets how long the network stats are cached
+// (NET_STATS_UPDATE_INTERVAL)
+func WithNetworkInterval(interval time.Duration) MonitorOption {
+	return WithInterval(CollectorNetwork, interval)
+}
+
+// WithDiskFilters replaces DISK_INCLUDE & DISK_EXCLUDE of the config, see NameFilter
+func WithDiskFilters(include []string, exclude []string) MonitorOption {
+	return func(m *Monitor) {
+		filter := NewNameFilter(include, exclude)
+		m.diskFilters = &filter
+	}
+}
+
+// WithNetworkFilters replaces NET_INCLUDE & NET_EXCLUDE of the config, see NameFilter
+func WithNetworkFilters(include []string, exclude []string) MonitorOption {
+	return func(m *Monitor) {
+		filter := NewNameFilter(include, exclude)
+		m.netFilters = &filter
+	}
+}
+
+// WithLogger logs the errors and debug lines of the Monitor to `logger` instead of the
+// logger of gtm (see SetLogger)
+func WithLogger(logger *slog.Logger) MonitorOption {
+	return func(m *Monitor) { m.logger = logger }
+}
+
+// WithoutGPU never looks for GPUs, so nvidia-smi & rocm-smi are never run. HasGPU
+// reports false and GPUStats fails with ErrCollectorDisabled
+func WithoutGPU() MonitorOption {
+	return func(m *Monitor) { m.noGPU = true }
+}
+
+var defaultMonitor = newDefaultMonitor()
+
+// NewMonitor returns a Monitor with empty caches. Unless changed by the options, it uses
+// the *_UPDATE_INTERVAL constants, the filters in the config and the logger of gtm
+func NewMonitor(opts ...MonitorOption) *Monitor {
+	m := &Monitor{
+		intervals: map[Collector]time.Duration{
+			CollectorCPU:     CPU_STATS_UPDATE_INTERVAL,
+			CollectorDisk:    DISK_STATS_UPDATE_INTERVAL,
+			CollectorGPU:     GPU_STATS_UPDATE_INTERVAL,
+			CollectorHost:    HOST_INFO_UPDATE_INTERVAL,
+			CollectorMemory:  MEM_STATS_UPDATE_INTERVAL,
+			CollectorNetwork: NET_STATS_UPDATE_INTERVAL,
+		},
+		disks:    &diskCollection{all: false},
+		allDisks: &diskCollection{all: true},
+		updates:  NewBroadcaster[Update](),
+		sources:  defaultSources(),
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+func newDefaultMonitor() *Monitor {
+	m := NewMonitor()
+	m.global = true
+	return m
+}
+
+// DefaultMonitor returns the Monitor used by the package level Get functions
+func DefaultMonitor() *Monitor { return defaultMonitor }
+
+// interval returns how long the stats of a collector are cached
+func (m *Monitor) interval(collector Collector) time.Duration {
+	m.intervalMut.RLock()
+	defer m.intervalMut.RUnlock()
+	return m.intervals[collector]
+}
+
+// Interval returns how long the stats of a collector are cached, or 0 for collectors the
+// Monitor doesn't have
+func (m *Monitor) Interval(collector Collector) time.Duration {
+	return m.interval(collector)
+}
+
+// Intervals returns the interval of every collector of the Monitor
+func (m *Monitor) Intervals() map[Collector]time.Duration {
+	m.intervalMut.RLock()
+	defer m.intervalMut.RUnlock()
+	return maps.Clone(m.intervals)
+}
+
+// SetInterval changes how long the stats of a collector are cached, ie. to slow
+// everything down while on battery. When the Monitor is polling, the collector is
+// rescheduled right away. It fails with ErrInvalidInterval when the interval is below
+// the minimum of the collector (MIN_UPDATE_INTERVAL, MIN_GPU_UPDATE_INTERVAL or
+// MIN_DISK_UPDATE_INTERVAL)
+func (m *Monitor) SetInterval(collector Collector, interval time.Duration) error {
+	if err := m.validateInterval(collector, interval); err != nil {
+		return err
+	}
+	m.intervalMut.Lock()
+	m.intervals[collector] = interval
+	m.intervalMut.Unlock()
+
+	m.reschedule(collector)
+	return nil
+}
+
+// SetInterval changes an interval of the default Monitor, see Monitor.SetInterval
+func SetInterval(collector Collector, interval time.Duration) error {
+	return defaultMonitor.SetInterval(collector, interval)
+}
+
+func (m *Monitor) validateInterval(collector Collector, interval time.Duration) error {
+	m.intervalMut.RLock()
+	_, ok := m.intervals[collector]
+	m.intervalMut.RUnlock()
+	if !ok {
+		return fmt.Errorf("%w: the monitor has no %s collector", ErrInvalidInterval,
+			collector)
+	}
+
+	minimum := MIN_UPDATE_INTERVAL
+	switch collector {
+	case CollectorGPU:
+		minimum = MIN_GPU_UPDATE_INTERVAL
+	case CollectorDisk:
+		minimum = MIN_DISK_UPDATE_INTERVAL
+	}
+	if interval < minimum {
+		return fmt.Errorf("%w: %s is below the %s minimum of %s", ErrInvalidInterval,
+			interval, collector, minimum)
+	}
+	return nil
+}
+
+func (m *Monitor) log() *slog.Logger {
+	if m.logger == nil {
+		return logger()
+	}
+	return m.logger
+}
+
+// collectorError is the package level collectorError, logging to the Monitor logger
+func (m *Monitor) collectorError(c Collector, msg string, err error) {
+	logCollectorError(m.log(), c, msg, err)
+}
+
+func (m *Monitor) diskFilter() NameFilter {
+	if m.diskFilters != nil {
+		return *m.diskFilters
+	}
+	return NewNameFilter(Cfg.DiskInclude, Cfg.DiskExclude)
+}
+
+func (m *Monitor) netFilter() NameFilter {
+	if m.netFilters != nil {
+		return *m.netFilters
+	}
+	return NewNameFilter(Cfg.NetInclude, Cfg.NetExclude)
+}
+
+// recordMetric records a sample in the metric history, for the default Monitor only
+func (m *Monitor) recordMetric(name string, timestamp time.Time, value float64) {
+	if m.global {
+		recordMetric(name, timestamp, value)
+	}
+}
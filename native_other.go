@@ -0,0 +1,15 @@
+//go:build !windows
+
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/mem"
+)
+
+// The native fallbacks are only needed on Windows, where gopsutil depends on WMI
+
+func nativeMemoryStats() (*mem.VirtualMemoryStat, error) { return nil, ErrUnsupportedPlatform }
+func nativeHostInfo() (*host.InfoStat, error)            { return nil, ErrUnsupportedPlatform }
+func nativeCPUInfo() ([]cpu.InfoStat, error)             { return nil, ErrUnsupportedPlatform }
@@ -0,0 +1,119 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/mem"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"os"
+	"runtime"
+	"strconv"
+	"time"
+	"unsafe"
+)
+
+// Some Windows installs have a broken WMI repository, which fails (or hangs until it
+// times out) every WMI query. These native Win32 API and registry fallbacks are used
+// when gopsutil fails, so memory, host and CPU info never depend on WMI
+var (
+	kernel32                 = windows.NewLazySystemDLL("kernel32.dll")
+	procGlobalMemoryStatusEx = kernel32.NewProc("GlobalMemoryStatusEx")
+	procGetTickCount64       = kernel32.NewProc("GetTickCount64")
+)
+
+// memoryStatusEx is MEMORYSTATUSEX
+type memoryStatusEx struct {
+	Length               uint32
+	MemoryLoad           uint32
+	TotalPhys            uint64
+	AvailPhys            uint64
+	TotalPageFile        uint64
+	AvailPageFile        uint64
+	TotalVirtual         uint64
+	AvailVirtual         uint64
+	AvailExtendedVirtual uint64
+}
+
+func nativeMemoryStats() (*mem.VirtualMemoryStat, error) {
+	var status memoryStatusEx
+	status.Length = uint32(unsafe.Sizeof(status))
+	ok, _, err := procGlobalMemoryStatusEx.Call(uintptr(unsafe.Pointer(&status)))
+	if ok == 0 {
+		return nil, err
+	}
+	used := status.TotalPhys - status.AvailPhys
+	return &mem.VirtualMemoryStat{
+		Total:       status.TotalPhys,
+		Available:   status.AvailPhys,
+		Free:        status.AvailPhys,
+		Used:        used,
+		UsedPercent: float64(used) / float64(status.TotalPhys) * 100,
+	}, nil
+}
+
+func nativeHostInfo() (*host.InfoStat, error) {
+	info := &host.InfoStat{
+		OS:         runtime.GOOS,
+		KernelArch: runtime.GOARCH,
+	}
+	var err error
+	if info.Hostname, err = os.Hostname(); err != nil {
+		return nil, err
+	}
+
+	if err = procGetTickCount64.Find(); err == nil {
+		uptimeMillis, _, _ := procGetTickCount64.Call()
+		info.Uptime = uint64(uptimeMillis / 1000)
+		info.BootTime = uint64(GetClock().Now().Add(
+			-time.Duration(uptimeMillis) * time.Millisecond).Unix())
+	}
+
+	version := windows.RtlGetVersion()
+	info.KernelVersion = strconv.FormatUint(uint64(version.MajorVersion), 10) + "." +
+		strconv.FormatUint(uint64(version.MinorVersion), 10) + "." +
+		strconv.FormatUint(uint64(version.BuildNumber), 10)
+	info.PlatformVersion = info.KernelVersion
+
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		CURRENT_VERSION_KEY, registry.QUERY_VALUE)
+	if err == nil {
+		info.Platform, _, _ = key.GetStringValue("ProductName")
+		if installType, _, err := key.GetStringValue("InstallationType"); err == nil {
+			if installType == "Client" {
+				info.PlatformFamily = "Standalone Workstation"
+			} else {
+				info.PlatformFamily = "Server"
+			}
+		}
+		key.Close()
+	}
+	key, err = registry.OpenKey(registry.LOCAL_MACHINE, `SOFTWARE\Microsoft\Cryptography`,
+		registry.QUERY_VALUE|registry.WOW64_64KEY)
+	if err == nil {
+		info.HostID, _, _ = key.GetStringValue("MachineGuid")
+		key.Close()
+	}
+	return info, nil
+}
+
+// nativeCPUInfo reads the processor from the registry. The registry doesn't have the
+// number of physical cores, so Cores is the number of logical processors
+func nativeCPUInfo() ([]cpu.InfoStat, error) {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE,
+		`HARDWARE\DESCRIPTION\System\CentralProcessor\0`, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, err
+	}
+	defer key.Close()
+
+	info := cpu.InfoStat{Cores: int32(runtime.NumCPU())}
+	if info.ModelName, _, err = key.GetStringValue("ProcessorNameString"); err != nil {
+		return nil, err
+	}
+	info.VendorID, _, _ = key.GetStringValue("VendorIdentifier")
+	if mhz, _, err := key.GetIntegerValue("~MHz"); err == nil {
+		info.Mhz = float64(mhz)
+	}
+	return []cpu.InfoStat{info}, nil
+}
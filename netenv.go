@@ -0,0 +1,150 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// NET_ENV_UPDATE_INTERVAL is slow on purpose, since the gateway and DNS servers only
+	// change when the host moves to another network
+	NET_ENV_UPDATE_INTERVAL = time.Minute
+	// PUBLIC_IP_UPDATE_INTERVAL is how often the public IP is looked up, when enabled
+	PUBLIC_IP_UPDATE_INTERVAL = 15 * time.Minute
+	// PUBLIC_IP_TIMEOUT limits how long a public IP lookup may take
+	PUBLIC_IP_TIMEOUT = 5 * time.Second
+	// PUBLIC_IP_URL is the default lookup endpoint. It must answer with the bare address
+	PUBLIC_IP_URL = "https://api.ipify.org"
+	// RESOLV_CONF_PATH is read for the DNS servers on unix-like systems
+	RESOLV_CONF_PATH = "/etc/resolv.conf"
+)
+
+// NetworkEnvironment is the network the host is on. Only IPv4 default routes are
+// reported. PublicIP is empty unless PUBLIC_IP_LOOKUP is enabled in the config, since
+// looking it up sends a request to a third party
+type NetworkEnvironment struct {
+	DefaultGateway   string   `json:"default_gateway"`
+	GatewayInterface string   `json:"gateway_interface"`
+	DNSServers       []string `json:"dns_servers"`
+	PublicIP         string   `json:"public_ip,omitempty"`
+}
+
+var (
+	netEnv          NetworkEnvironment
+	lastFetchNetEnv time.Time
+	netEnvMut       sync.Mutex
+
+	publicIP          string
+	lastFetchPublicIP time.Time
+	publicIPMut       sync.Mutex
+)
+
+// GetNetworkEnvironment returns the default gateway, DNS servers and, when enabled,
+// the public IP. The public IP is looked up in the background, so it is empty until
+// the first lookup finished
+func GetNetworkEnvironment() (NetworkEnvironment, error) {
+	netEnvMut.Lock()
+	defer netEnvMut.Unlock()
+
+	if GetClock().Since(lastFetchNetEnv) >= NET_ENV_UPDATE_INTERVAL {
+		lastFetchNetEnv = GetClock().Now()
+
+		env, err := getNetworkEnvironment()
+		if err != nil {
+			collectorError(CollectorNetwork, "Failed to retrieve the network environment!",
+				err)
+			netEnv.PublicIP = getPublicIP()
+			return netEnv, err
+		}
+		netEnv = env
+	}
+	netEnv.PublicIP = getPublicIP()
+	return netEnv, nil
+}
+
+// getPublicIP returns the last looked up public IP, and starts a new lookup when it's
+// older than PUBLIC_IP_UPDATE_INTERVAL
+func getPublicIP() string {
+	if !Cfg.PublicIPLookup {
+		return ""
+	}
+	publicIPMut.Lock()
+	defer publicIPMut.Unlock()
+
+	if GetClock().Since(lastFetchPublicIP) >= PUBLIC_IP_UPDATE_INTERVAL {
+		lastFetchPublicIP = GetClock().Now()
+		go func() {
+			ip, err := lookupPublicIP(context.Background(), Cfg.PublicIPURL)
+			if err != nil {
+				logger().Warn("Failed to look up the public IP! " + err.Error())
+				return
+			}
+			publicIPMut.Lock()
+			publicIP = ip
+			publicIPMut.Unlock()
+		}()
+	}
+	return publicIP
+}
+
+// lookupPublicIP asks `url` (PUBLIC_IP_URL when empty) for the address the request came
+// from
+func lookupPublicIP(ctx context.Context, url string) (string, error) {
+	if url == "" {
+		url = PUBLIC_IP_URL
+	}
+	ctx, cancel := context.WithTimeout(ctx, PUBLIC_IP_TIMEOUT)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", errors.New("public IP lookup failed: " + resp.Status)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 256))
+	if err != nil {
+		return "", err
+	}
+	ip := net.ParseIP(strings.TrimSpace(string(body)))
+	if ip == nil {
+		return "", errors.New("public IP lookup returned an invalid address")
+	}
+	return ip.String(), nil
+}
+
+// readResolvConf returns the nameservers in RESOLV_CONF_PATH
+func readResolvConf() ([]string, error) {
+	data, err := os.ReadFile(RESOLV_CONF_PATH)
+	if err != nil {
+		return nil, err
+	}
+	return parseResolvConf(data), nil
+}
+
+func parseResolvConf(data []byte) []string {
+	servers := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			servers = append(servers, fields[1])
+		}
+	}
+	return servers
+}
@@ -0,0 +1,43 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+func getNetworkEnvironment() (NetworkEnvironment, error) {
+	env := NetworkEnvironment{}
+	out, err := runCommand("route", "-n", "get", "default")
+	if err != nil {
+		return env, err
+	}
+	env.DefaultGateway, env.GatewayInterface = parseRouteGet(out)
+
+	// macOS generates resolv.conf from the primary resolver configuration
+	env.DNSServers, err = readResolvConf()
+	return env, err
+}
+
+// parseRouteGet parses the output of `route -n get default`:
+//
+//	   route to: default
+//	destination: default
+//	    gateway: 192.168.1.1
+//	  interface: en0
+func parseRouteGet(out []byte) (gateway string, iface string) {
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		switch strings.TrimSpace(key) {
+		case "gateway":
+			gateway = strings.TrimSpace(value)
+		case "interface":
+			iface = strings.TrimSpace(value)
+		}
+	}
+	return gateway, iface
+}
@@ -0,0 +1,62 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// PROC_NET_ROUTE_PATH is the IPv4 routing table
+const PROC_NET_ROUTE_PATH = "/proc/net/route"
+
+// RTF_GATEWAY is the flag of a route that goes through a gateway
+const RTF_GATEWAY = 0x2
+
+func getNetworkEnvironment() (NetworkEnvironment, error) {
+	env := NetworkEnvironment{}
+	data, err := os.ReadFile(PROC_NET_ROUTE_PATH)
+	if err != nil {
+		return env, err
+	}
+	env.DefaultGateway, env.GatewayInterface = parseProcNetRoute(data)
+
+	// with systemd-resolved this is the stub resolver (127.0.0.53)
+	env.DNSServers, err = readResolvConf()
+	return env, err
+}
+
+// parseProcNetRoute returns the gateway and interface of the default route with the
+// lowest metric. Addresses in /proc/net/route are hex in host (little endian) order
+func parseProcNetRoute(data []byte) (gateway string, iface string) {
+	bestMetric := int64(math.MaxInt64)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Scan() // skip the header
+	for scanner.Scan() {
+		// Iface Destination Gateway Flags RefCnt Use Metric Mask MTU Window IRTT
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || fields[1] != "00000000" || fields[7] != "00000000" {
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 16)
+		if err != nil || flags&RTF_GATEWAY == 0 {
+			continue
+		}
+		metric, err := strconv.ParseInt(fields[6], 10, 64)
+		if err != nil || metric >= bestMetric {
+			continue
+		}
+		addr, err := strconv.ParseUint(fields[2], 16, 32)
+		if err != nil {
+			continue
+		}
+		ip := make(net.IP, net.IPv4len)
+		binary.LittleEndian.PutUint32(ip, uint32(addr))
+		gateway, iface, bestMetric = ip.String(), fields[0], metric
+	}
+	return gateway, iface
+}
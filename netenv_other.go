@@ -0,0 +1,10 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+// getNetworkEnvironment can't find the default gateway on this platform, so only the
+// DNS servers are reported
+func getNetworkEnvironment() (NetworkEnvironment, error) {
+	servers, err := readResolvConf()
+	return NetworkEnvironment{DNSServers: servers}, err
+}
@@ -0,0 +1,40 @@
+package gtm
+
+import (
+	"golang.org/x/sys/windows"
+	"math"
+)
+
+// getNetworkEnvironment takes the IPv4 gateway of the connected adapter with the lowest
+// IPv4 metric, which is the adapter Windows routes through by default
+func getNetworkEnvironment() (NetworkEnvironment, error) {
+	env := NetworkEnvironment{DNSServers: []string{}}
+	adapter, err := getAdaptersAddresses(windows.GAA_FLAG_INCLUDE_GATEWAYS)
+	if err != nil {
+		return env, err
+	}
+
+	var best *windows.IpAdapterAddresses
+	bestMetric := uint32(math.MaxUint32)
+	for ; adapter != nil; adapter = adapter.Next {
+		if adapter.OperStatus != windows.IfOperStatusUp || adapter.Ipv4Metric >= bestMetric {
+			continue
+		}
+		for gw := adapter.FirstGatewayAddress; gw != nil; gw = gw.Next {
+			if ip := gw.Address.IP(); ip.To4() != nil && !ip.IsUnspecified() {
+				env.DefaultGateway = ip.String()
+				env.GatewayInterface = windows.UTF16PtrToString(adapter.FriendlyName)
+				best, bestMetric = adapter, adapter.Ipv4Metric
+				break
+			}
+		}
+	}
+	if best != nil {
+		for dns := best.FirstDnsServerAddress; dns != nil; dns = dns.Next {
+			if ip := dns.Address.IP(); ip != nil {
+				env.DNSServers = append(env.DNSServers, ip.String())
+			}
+		}
+	}
+	return env, nil
+}
@@ -0,0 +1,154 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/net"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// NET_INFO_UPDATE_INTERVAL is slow on purpose, since addresses and link speeds rarely
+// change and reading them is much more expensive than reading the byte counters
+const NET_INFO_UPDATE_INTERVAL = 30 * time.Second
+
+// NetInterface is the metadata of a network interface. SpeedMbps is the negotiated link
+// speed, which is 0 when it's unknown (ie. virtual interfaces, or on macOS). The driver
+// fields are only known for physical NICs on Linux (ethtool) and Windows (registry).
+// Firmware is only known on Linux, and BusInfo is the PCI address on Linux but the
+// hardware ID (ie. "pci\ven_8086&dev_15bc") on Windows
+type NetInterface struct {
+	Name          string   `json:"name"`
+	Alias         string   `json:"alias,omitempty"`
+	MAC           string   `json:"mac"`
+	MTU           int      `json:"mtu"`
+	SpeedMbps     int64    `json:"speed_mbps"`
+	IsUp          bool     `json:"is_up"`
+	IsLoopback    bool     `json:"is_loopback"`
+	Addresses     []string `json:"addresses"`
+	Driver        string   `json:"driver,omitempty"`
+	DriverVersion string   `json:"driver_version,omitempty"`
+	Firmware      string   `json:"firmware,omitempty"`
+	BusInfo       string   `json:"bus_info,omitempty"`
+}
+
+// linkInfo is what the platform can tell about a link beyond the net.Interfaces() flags
+type linkInfo struct {
+	SpeedMbps int64
+	// IsUp is nil when the operational state is unknown, so the "up" flag is used
+	IsUp          *bool
+	Driver        string
+	DriverVersion string
+	Firmware      string
+	BusInfo       string
+}
+
+var (
+	netInterfaces     []NetInterface
+	lastFetchNetIface time.Time
+	netIfaceMut       sync.Mutex
+)
+
+// GetNetworkInterfaces returns the addresses, MAC, MTU, link speed and state of every
+// network interface
+func GetNetworkInterfaces() ([]NetInterface, error) {
+	netIfaceMut.Lock()
+	defer netIfaceMut.Unlock()
+
+	if GetClock().Since(lastFetchNetIface) < NET_INFO_UPDATE_INTERVAL && len(netInterfaces) > 0 {
+		return netInterfaces, nil
+	}
+
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		collectorError(CollectorNetwork, "Failed to retrieve net.Interfaces()!", err)
+		return netInterfaces, err
+	}
+
+	names := make([]string, 0, len(ifaces))
+	for _, iface := range ifaces {
+		names = append(names, iface.Name)
+	}
+	links := getLinkInfo(names)
+
+	result := make([]NetInterface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		info := NetInterface{
+			Name:       iface.Name,
+			Alias:      netAlias(iface.Name),
+			MAC:        iface.HardwareAddr,
+			MTU:        iface.MTU,
+			IsUp:       slices.Contains(iface.Flags, "up"),
+			IsLoopback: slices.Contains(iface.Flags, "loopback"),
+		}
+		for _, addr := range iface.Addrs {
+			info.Addresses = append(info.Addresses, addr.Addr)
+		}
+		if link, ok := links[iface.Name]; ok {
+			info.SpeedMbps = link.SpeedMbps
+			info.Driver = link.Driver
+			info.DriverVersion = link.DriverVersion
+			info.Firmware = link.Firmware
+			info.BusInfo = link.BusInfo
+			if link.IsUp != nil {
+				info.IsUp = *link.IsUp
+			}
+		}
+		logger().Debug("net.Interfaces(), interface " + iface.Name + ": " + iface.String())
+		result = append(result, info)
+	}
+
+	netInterfaces = result
+	lastFetchNetIface = GetClock().Now()
+	return netInterfaces, nil
+}
+
+// resetNetworkInterfaces makes the next GetNetworkInterfaces read the interfaces again
+func resetNetworkInterfaces() {
+	netIfaceMut.Lock()
+	lastFetchNetIface = time.Time{}
+	netIfaceMut.Unlock()
+}
+
+// GetNetworkInterface returns the metadata of a single interface by name
+func GetNetworkInterface(name string) (NetInterface, bool) {
+	ifaces, _ := GetNetworkInterfaces()
+	for _, iface := range ifaces {
+		if iface.Name == name {
+			return iface, true
+		}
+	}
+	return NetInterface{}, false
+}
+
+// PrimaryAddress returns the first IPv4 address without its prefix length, falling
+// back to the first IPv6 address
+func (n NetInterface) PrimaryAddress() string {
+	var fallback string
+	for _, addr := range n.Addresses {
+		ip, _, _ := strings.Cut(addr, "/")
+		if strings.Contains(ip, ".") {
+			return ip
+		}
+		if fallback == "" {
+			fallback = ip
+		}
+	}
+	return fallback
+}
+
+// SpeedString formats the link speed, ie. "1 GbE" or "100 MbE". It is empty when the
+// speed is unknown
+func (n NetInterface) SpeedString() string {
+	switch {
+	case n.SpeedMbps <= 0:
+		return ""
+	case n.SpeedMbps >= 1000 && n.SpeedMbps%1000 == 0:
+		return strconv.FormatInt(n.SpeedMbps/1000, 10) + " GbE"
+	case n.SpeedMbps >= 1000:
+		return strconv.FormatFloat(float64(n.SpeedMbps)/1000, 'f', 1, 64) + " GbE"
+	default:
+		return strconv.FormatInt(n.SpeedMbps, 10) + " MbE"
+	}
+}
@@ -0,0 +1,64 @@
+package gtm
+
+import (
+	"golang.org/x/sys/unix"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// getLinkInfo reads the link speed and operational state from sysfs. The speed file
+// can't be read (EINVAL) for interfaces that are down or don't have a speed
+func getLinkInfo(names []string) map[string]linkInfo {
+	links := make(map[string]linkInfo, len(names))
+	for _, name := range names {
+		var link linkInfo
+		dir := filepath.Join("/sys/class/net", name)
+
+		if data, err := os.ReadFile(filepath.Join(dir, "speed")); err == nil {
+			speed, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+			if err == nil && speed > 0 {
+				link.SpeedMbps = speed
+			}
+		}
+		if data, err := os.ReadFile(filepath.Join(dir, "operstate")); err == nil {
+			switch strings.TrimSpace(string(data)) {
+			case "up":
+				isUp := true
+				link.IsUp = &isUp
+			case "down", "lowerlayerdown", "notpresent":
+				isUp := false
+				link.IsUp = &isUp
+			}
+			// "unknown" (ie. loopback & tun devices) falls back to the "up" flag
+		}
+		// only physical NICs have a device, virtual interfaces have no driver to report
+		if fileExists(filepath.Join(dir, "device")) {
+			getDriverInfo(name, &link)
+		}
+		links[name] = link
+	}
+	return links
+}
+
+// getDriverInfo reads the driver, its version, the firmware version and the bus address
+// of a NIC with the ETHTOOL_GDRVINFO ioctl, the same as `ethtool -i`
+func getDriverInfo(name string, link *linkInfo) {
+	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM|unix.SOCK_CLOEXEC, 0)
+	if err != nil {
+		logger().Debug("Failed to open a socket for ethtool! " + err.Error())
+		return
+	}
+	defer unix.Close(fd)
+
+	info, err := unix.IoctlGetEthtoolDrvinfo(fd, name)
+	if err != nil {
+		logger().Debug("Failed to retrieve ETHTOOL_GDRVINFO for " + name + "! " + err.Error())
+		return
+	}
+	link.Driver = unix.ByteSliceToString(info.Driver[:])
+	link.DriverVersion = unix.ByteSliceToString(info.Version[:])
+	link.Firmware = unix.ByteSliceToString(info.Fw_version[:])
+	link.BusInfo = unix.ByteSliceToString(info.Bus_info[:])
+}
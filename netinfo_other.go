@@ -0,0 +1,9 @@
+//go:build !linux && !windows
+
+package gtm
+
+// getLinkInfo isn't implemented on this platform, so link speeds are unknown and the
+// state comes from the interface flags
+func getLinkInfo(names []string) map[string]linkInfo {
+	return map[string]linkInfo{}
+}
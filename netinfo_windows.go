@@ -0,0 +1,96 @@
+package gtm
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"strings"
+	"unsafe"
+)
+
+// NET_ADAPTER_CLASS_KEY is the registry key of the network adapter device class. Every
+// subkey is an adapter, with its driver info and the GUID of its interface
+const NET_ADAPTER_CLASS_KEY = `SYSTEM\CurrentControlSet\Control\Class\` +
+	`{4d36e972-e325-11ce-bfc1-08002be10318}`
+
+// getLinkInfo reads the link speed and operational state of every adapter. Adapters
+// are matched by their friendly name, which is the name Go (and gopsutil) report
+func getLinkInfo(names []string) map[string]linkInfo {
+	links := make(map[string]linkInfo, len(names))
+
+	adapter, err := getAdaptersAddresses(0)
+	if err != nil {
+		logger().Debug("Failed to retrieve GetAdaptersAddresses()! " + err.Error())
+		return links
+	}
+	drivers := getDriverInfo()
+	for ; adapter != nil; adapter = adapter.Next {
+		name := windows.UTF16PtrToString(adapter.FriendlyName)
+		isUp := adapter.OperStatus == windows.IfOperStatusUp
+		link := linkInfo{IsUp: &isUp}
+		if adapter.TransmitLinkSpeed != 0 && adapter.TransmitLinkSpeed != ^uint64(0) {
+			link.SpeedMbps = int64(adapter.TransmitLinkSpeed / 1_000_000)
+		}
+		if driver, ok := drivers[strings.ToLower(windows.BytePtrToString(
+			adapter.AdapterName))]; ok {
+			link.Driver = driver.Driver
+			link.DriverVersion = driver.DriverVersion
+			link.BusInfo = driver.BusInfo
+		}
+		links[name] = link
+	}
+	return links
+}
+
+// getAdaptersAddresses returns the linked list of adapters from GetAdaptersAddresses,
+// growing the buffer until everything fits
+func getAdaptersAddresses(flags uint32) (*windows.IpAdapterAddresses, error) {
+	size := uint32(15 * 1024) // recommended starting size
+	for {
+		buf := make([]byte, size)
+		adapter := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0]))
+		err := windows.GetAdaptersAddresses(windows.AF_UNSPEC, flags, 0, adapter, &size)
+		if err == nil {
+			return adapter, nil
+		}
+		if !errors.Is(err, windows.ERROR_BUFFER_OVERFLOW) {
+			return nil, err
+		}
+	}
+}
+
+// getDriverInfo reads the driver of every adapter from the registry instead of WMI
+// (Win32_NetworkAdapter), keyed by the lowercase interface GUID. Windows doesn't expose
+// the firmware version of NICs
+func getDriverInfo() map[string]linkInfo {
+	drivers := map[string]linkInfo{}
+	class, err := registry.OpenKey(registry.LOCAL_MACHINE, NET_ADAPTER_CLASS_KEY,
+		registry.ENUMERATE_SUB_KEYS)
+	if err != nil {
+		logger().Debug("Failed to open the network adapter class key! " + err.Error())
+		return drivers
+	}
+	defer class.Close()
+
+	subkeys, err := class.ReadSubKeyNames(-1)
+	if err != nil {
+		return drivers
+	}
+	for _, subkey := range subkeys {
+		key, err := registry.OpenKey(class, subkey, registry.QUERY_VALUE)
+		if err != nil {
+			// "Properties" and other non-adapter subkeys can't be opened without admin
+			continue
+		}
+		guid, _, err := key.GetStringValue("NetCfgInstanceId")
+		if err == nil {
+			var driver linkInfo
+			driver.Driver, _, _ = key.GetStringValue("DriverDesc")
+			driver.DriverVersion, _, _ = key.GetStringValue("DriverVersion")
+			driver.BusInfo, _, _ = key.GetStringValue("MatchingDeviceId")
+			drivers[strings.ToLower(guid)] = driver
+		}
+		key.Close()
+	}
+	return drivers
+}
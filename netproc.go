@@ -0,0 +1,119 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/process"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PROC_NET_UPDATE_INTERVAL limits how often per-process bandwidth is sampled, since
+// every sample runs an external command (linux, macOS) or walks the TCP table (windows)
+const PROC_NET_UPDATE_INTERVAL = 2 * time.Second
+
+// ProcessBandwidth is the network throughput of a single process. Only TCP traffic is
+// attributed, since there are no per-socket counters for UDP. Connections is the
+// number of open TCP connections the process had when sampled
+type ProcessBandwidth struct {
+	PID                 int32   `json:"pid"`
+	Name                string  `json:"name"`
+	UploadBytesPerSec   float64 `json:"upload_bytes_per_sec"`
+	DownloadBytesPerSec float64 `json:"download_bytes_per_sec"`
+	Connections         int     `json:"connections"`
+}
+
+// socketCounter holds the byte counters of a socket (or of a whole process, when the
+// platform only reports per-process totals). Key must be unique and stable for the
+// lifetime of the socket, so its counters can be compared between samples
+type socketCounter struct {
+	Key       string
+	PID       int32
+	Name      string
+	BytesSent uint64
+	BytesRecv uint64
+}
+
+var (
+	procBandwidth     []ProcessBandwidth
+	procNetCounters   map[string]socketCounter
+	lastFetchProcNet  time.Time
+	lastSampleProcNet time.Time
+	procNetMut        sync.Mutex
+)
+
+// GetProcessBandwidth returns the send/receive rate of every process with TCP traffic,
+// busiest first. Sockets owned by other users can only be attributed when running as
+// root/admin; their traffic is reported under PID 0. Rates are computed between two
+// samples, so the first call always returns an empty list
+func GetProcessBandwidth() ([]ProcessBandwidth, error) {
+	procNetMut.Lock()
+	defer procNetMut.Unlock()
+
+	if GetClock().Since(lastFetchProcNet) < PROC_NET_UPDATE_INTERVAL && procBandwidth != nil {
+		return procBandwidth, nil
+	}
+	if !IsCollectorEnabled(CollectorProcessNetwork) {
+		return nil, ErrCollectorDisabled
+	}
+
+	counters, err := getSocketCounters()
+	lastFetchProcNet = GetClock().Now()
+	if err != nil {
+		collectorError(CollectorProcessNetwork,
+			"Failed to retrieve per-process network counters!", err)
+		return procBandwidth, err
+	}
+	sampleTime := GetClock().Now()
+	elapsed := sampleSeconds(lastSampleProcNet, sampleTime)
+
+	byPID := map[int32]*ProcessBandwidth{}
+	current := make(map[string]socketCounter, len(counters))
+	for _, counter := range counters {
+		current[counter.Key] = counter
+
+		bandwidth, ok := byPID[counter.PID]
+		if !ok {
+			bandwidth = &ProcessBandwidth{PID: counter.PID, Name: counter.Name}
+			byPID[counter.PID] = bandwidth
+		}
+		bandwidth.Connections++
+
+		// Sockets that are new since the previous sample are skipped, since their
+		//	counters include traffic from before this sample window
+		if previous, ok := procNetCounters[counter.Key]; ok {
+			bandwidth.UploadBytesPerSec += ratePerSecond(previous.BytesSent,
+				counter.BytesSent, elapsed)
+			bandwidth.DownloadBytesPerSec += ratePerSecond(previous.BytesRecv,
+				counter.BytesRecv, elapsed)
+		}
+	}
+
+	result := make([]ProcessBandwidth, 0, len(byPID))
+	for _, bandwidth := range byPID {
+		if bandwidth.Name == "" && bandwidth.PID > 0 {
+			if proc, err := process.NewProcess(bandwidth.PID); err == nil {
+				bandwidth.Name, _ = proc.Name()
+			}
+		}
+		bandwidth.UploadBytesPerSec = RoundStat(bandwidth.UploadBytesPerSec)
+		bandwidth.DownloadBytesPerSec = RoundStat(bandwidth.DownloadBytesPerSec)
+		result = append(result, *bandwidth)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a := result[i].UploadBytesPerSec + result[i].DownloadBytesPerSec
+		b := result[j].UploadBytesPerSec + result[j].DownloadBytesPerSec
+		if a != b {
+			return a > b
+		}
+		return result[i].PID < result[j].PID
+	})
+
+	if procNetCounters == nil {
+		// first sample, there's nothing to compare to yet
+		result = []ProcessBandwidth{}
+	}
+	procBandwidth = result
+	procNetCounters = current
+	lastSampleProcNet = sampleTime
+	return procBandwidth, nil
+}
@@ -0,0 +1,45 @@
+package gtm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// getSocketCounters reads per-process byte totals from a single `nettop` sample:
+//
+//	,bytes_in,bytes_out,
+//	Google Chrome H.1234,5678,910,
+//
+// nettop reports processes rather than sockets, so the PID is the key
+func getSocketCounters() ([]socketCounter, error) {
+	out, err := runCommand("nettop", "-P", "-L", "1", "-x", "-n",
+		"-J", "bytes_in,bytes_out")
+	if err != nil {
+		return nil, err
+	}
+	return parseNettop(string(out)), nil
+}
+
+func parseNettop(output string) []socketCounter {
+	var counters []socketCounter
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Split(line, ",")
+		if len(fields) < 3 || fields[0] == "" {
+			continue
+		}
+		// the process column is "<name>.<pid>", and names may contain dots
+		i := strings.LastIndex(fields[0], ".")
+		if i < 0 {
+			continue
+		}
+		pid, err := strconv.ParseInt(fields[0][i+1:], 10, 32)
+		if err != nil {
+			continue
+		}
+		counter := socketCounter{Key: fields[0][i+1:], PID: int32(pid), Name: fields[0][:i]}
+		counter.BytesRecv, _ = strconv.ParseUint(fields[1], 10, 64)
+		counter.BytesSent, _ = strconv.ParseUint(fields[2], 10, 64)
+		counters = append(counters, counter)
+	}
+	return counters
+}
@@ -0,0 +1,82 @@
+package gtm
+
+import (
+	"strconv"
+	"strings"
+)
+
+// getSocketCounters reads the tcp_info byte counters of every TCP socket from
+// `ss -tinpH`. Every socket takes two lines:
+//
+//	ESTAB 0 0 192.168.1.10:22 192.168.1.5:51234 users:(("sshd",pid=1234,fd=3))
+//		 cubic wscale:7,7 rto:204 ... bytes_sent:12345 bytes_acked:12346 bytes_received:6789 ...
+//
+// The users:() column is missing for sockets of other users when not running as root
+func getSocketCounters() ([]socketCounter, error) {
+	out, err := runCommand("ss", "-tinpH")
+	if err != nil {
+		return nil, err
+	}
+	return parseSSTCPInfo(string(out)), nil
+}
+
+func parseSSTCPInfo(output string) []socketCounter {
+	var (
+		counters []socketCounter
+		current  *socketCounter
+	)
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '\t' {
+			// socket line: state, recv-q, send-q, local, peer, [users]
+			fields := strings.Fields(line)
+			if len(fields) < 5 {
+				current = nil
+				continue
+			}
+			counters = append(counters, socketCounter{Key: fields[3] + "-" + fields[4]})
+			current = &counters[len(counters)-1]
+			if len(fields) > 5 {
+				current.Name, current.PID = parseSSUsers(fields[5])
+				current.Key += "-" + strconv.FormatInt(int64(current.PID), 10)
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		// tcp_info line
+		for _, field := range strings.Fields(line) {
+			key, value, ok := strings.Cut(field, ":")
+			if !ok {
+				continue
+			}
+			switch key {
+			case "bytes_acked":
+				// bytes_acked doesn't count retransmits, unlike bytes_sent
+				current.BytesSent, _ = strconv.ParseUint(value, 10, 64)
+			case "bytes_received":
+				current.BytesRecv, _ = strconv.ParseUint(value, 10, 64)
+			}
+		}
+	}
+	return counters
+}
+
+// parseSSUsers parses the first owner of users:(("sshd",pid=1234,fd=3),...)
+func parseSSUsers(users string) (name string, pid int32) {
+	_, rest, ok := strings.Cut(users, `(("`)
+	if !ok {
+		return "", 0
+	}
+	name, rest, _ = strings.Cut(rest, `"`)
+	if _, rest, ok = strings.Cut(rest, "pid="); ok {
+		value, _, _ := strings.Cut(rest, ",")
+		if p, err := strconv.ParseInt(value, 10, 32); err == nil {
+			pid = int32(p)
+		}
+	}
+	return name, pid
+}
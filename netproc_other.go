@@ -0,0 +1,7 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+func getSocketCounters() ([]socketCounter, error) {
+	return nil, ErrUnsupportedPlatform
+}
@@ -0,0 +1,143 @@
+package gtm
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/sys/windows"
+	"io/fs"
+	"strconv"
+	"unsafe"
+)
+
+const (
+	tcpTableOwnerPIDAll     = 5 // TCP_TABLE_OWNER_PID_ALL
+	tcpConnectionEstatsData = 1 // TcpConnectionEstatsData
+	mibTCPStateEstablished  = 5 // MIB_TCP_STATE_ESTAB
+)
+
+var (
+	modIphlpapi                   = windows.NewLazySystemDLL("iphlpapi.dll")
+	procGetExtendedTCPTable       = modIphlpapi.NewProc("GetExtendedTcpTable")
+	procSetPerTCPConnectionEStats = modIphlpapi.NewProc("SetPerTcpConnectionEStats")
+	procGetPerTCPConnectionEStats = modIphlpapi.NewProc("GetPerTcpConnectionEStats")
+)
+
+// mibTCPRowOwnerPID is MIB_TCPROW_OWNER_PID. Its first five fields are a MIB_TCPROW,
+// so a pointer to it is passed as-is to the EStats functions
+type mibTCPRowOwnerPID struct {
+	State      uint32
+	LocalAddr  uint32
+	LocalPort  uint32
+	RemoteAddr uint32
+	RemotePort uint32
+	OwningPID  uint32
+}
+
+// tcpEstatsDataRodV0 is TCP_ESTATS_DATA_ROD_v0, with the padding of the C struct
+type tcpEstatsDataRodV0 struct {
+	DataBytesOut      uint64
+	DataSegsOut       uint64
+	DataBytesIn       uint64
+	DataSegsIn        uint64
+	SegsOut           uint64
+	SegsIn            uint64
+	SoftErrors        uint32
+	SoftErrorReason   uint32
+	SndUna            uint32
+	SndNxt            uint32
+	SndMax            uint32
+	_                 uint32
+	ThruBytesAcked    uint64
+	RcvNxt            uint32
+	_                 uint32
+	ThruBytesReceived uint64
+}
+
+// getSocketCounters reads the extended statistics (GetPerTcpConnectionEStats) of every
+// established IPv4 TCP connection. Collection has to be enabled per connection first,
+// which needs an elevated process; traffic is counted from the moment it is enabled
+func getSocketCounters() ([]socketCounter, error) {
+	rows, err := getTCPTableOwnerPID()
+	if err != nil {
+		return nil, err
+	}
+
+	var counters []socketCounter
+	for i := range rows {
+		row := &rows[i]
+		if row.State != mibTCPStateEstablished {
+			continue
+		}
+
+		enable := byte(1) // TCP_ESTATS_DATA_RW_v0{EnableCollection: TRUE}
+		r, _, _ := procSetPerTCPConnectionEStats.Call(uintptr(unsafe.Pointer(row)),
+			tcpConnectionEstatsData, uintptr(unsafe.Pointer(&enable)), 0, 1, 0)
+		if r == uintptr(windows.ERROR_ACCESS_DENIED) {
+			return nil, fmt.Errorf("SetPerTcpConnectionEStats: %w", fs.ErrPermission)
+		} else if r != 0 {
+			continue // the connection closed in the meantime
+		}
+
+		var rod tcpEstatsDataRodV0
+		r, _, _ = procGetPerTCPConnectionEStats.Call(uintptr(unsafe.Pointer(row)),
+			tcpConnectionEstatsData, 0, 0, 0, 0, 0, 0,
+			uintptr(unsafe.Pointer(&rod)), 0, unsafe.Sizeof(rod))
+		if r != 0 {
+			continue
+		}
+
+		counters = append(counters, socketCounter{
+			Key: formatTCPEndpoint(row.LocalAddr, row.LocalPort) + "-" +
+				formatTCPEndpoint(row.RemoteAddr, row.RemotePort) + "-" +
+				strconv.FormatUint(uint64(row.OwningPID), 10),
+			PID:       int32(row.OwningPID),
+			BytesSent: rod.DataBytesOut,
+			BytesRecv: rod.DataBytesIn,
+		})
+	}
+	return counters, nil
+}
+
+func getTCPTableOwnerPID() ([]mibTCPRowOwnerPID, error) {
+	buf, err := getExtendedTable(procGetExtendedTCPTable, windows.AF_INET,
+		tcpTableOwnerPIDAll)
+	if err != nil {
+		return nil, err
+	}
+
+	// MIB_TCPTABLE_OWNER_PID: DWORD dwNumEntries, then the rows
+	count := *(*uint32)(unsafe.Pointer(&buf[0]))
+	if count == 0 {
+		return nil, nil
+	}
+	rows := unsafe.Slice((*mibTCPRowOwnerPID)(unsafe.Pointer(&buf[4])), count)
+	return append([]mibTCPRowOwnerPID{}, rows...), nil
+}
+
+// getExtendedTable calls GetExtendedTcpTable or GetExtendedUdpTable, growing the buffer
+// until the table fits. The table starts with its number of rows (a DWORD)
+func getExtendedTable(proc *windows.LazyProc, family uint32, class uint32) ([]byte,
+	error) {
+
+	size := uint32(4)
+	buf := make([]byte, size)
+	for {
+		r, _, _ := proc.Call(uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&size)), 0, uintptr(family), uintptr(class), 0)
+		if r == 0 {
+			return buf, nil
+		}
+		if r != uintptr(windows.ERROR_INSUFFICIENT_BUFFER) {
+			return nil, errors.New(proc.Name + " failed: " + windows.Errno(r).Error())
+		}
+		buf = make([]byte, size)
+	}
+}
+
+// formatTCPEndpoint formats an address and port, which are both in network byte order
+func formatTCPEndpoint(addr uint32, port uint32) string {
+	p := uint16(port>>8) | uint16(port&0xff)<<8
+	return strconv.Itoa(int(byte(addr))) + "." + strconv.Itoa(int(byte(addr>>8))) + "." +
+		strconv.Itoa(int(byte(addr>>16))) + "." + strconv.Itoa(int(byte(addr>>24))) + ":" +
+		strconv.Itoa(int(p))
+}
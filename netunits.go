@@ -0,0 +1,46 @@
+package gtm
+
+import "strings"
+
+// NetUnit is the unit network rates are shown in. Rates are always collected in bytes
+// per second, the unit only changes how they are formatted and which extra fields are
+// filled in the JSON output
+type NetUnit string
+
+const (
+	NetUnitBytes NetUnit = "bytes"
+	NetUnitBits  NetUnit = "bits"
+)
+
+// ParseNetUnit parses "bytes" or "bits" (case insensitive, "B" and "b" are accepted too)
+func ParseNetUnit(s string) (NetUnit, bool) {
+	switch strings.TrimSpace(s) {
+	case "B":
+		return NetUnitBytes, true
+	case "b":
+		return NetUnitBits, true
+	}
+	switch NetUnit(strings.ToLower(strings.TrimSpace(s))) {
+	case NetUnitBytes:
+		return NetUnitBytes, true
+	case NetUnitBits:
+		return NetUnitBits, true
+	}
+	return "", false
+}
+
+// FormatNetRate formats a rate in bytes per second with the configured unit (NET_UNITS
+// in the config), ie. "1.5 MiB/s" or "12Mbps"
+func FormatNetRate(bytesPerSec float64) string {
+	if Cfg.NetUnits == NetUnitBits {
+		return formatBitsPerSec(bytesPerSec)
+	}
+	return formatBytesPerSec(bytesPerSec)
+}
+
+// String formats the interface and its rates with the configured unit, ie.
+// "eth0 ↓12Mbps ↑3.1Mbps"
+func (n NetStats) String() string {
+	return displayName(n.Name, n.Alias) + " ↓" + FormatNetRate(n.DownloadBytesPerSec) +
+		" ↑" + FormatNetRate(n.UploadBytesPerSec)
+}
@@ -0,0 +1,45 @@
+package gtm
+
+import (
+	"strings"
+	"sync"
+)
+
+// osDetails are the normalized OS names and versions of HostInfo
+type osDetails struct {
+	Name       string
+	Version    string
+	PrettyName string
+	Build      string
+}
+
+var (
+	osInfo     osDetails
+	osInfoOnce sync.Once
+)
+
+// getOSDetails reads the OS names and versions once, since they only change on an
+// upgrade (which needs a reboot anyway)
+func getOSDetails() osDetails {
+	osInfoOnce.Do(func() {
+		osInfo = readOSDetails()
+	})
+	return osInfo
+}
+
+// OSDisplayName is a short name of the OS for a header line, ie. "Windows 11 23H2",
+// "macOS 14.5" or "Ubuntu 24.04 (6.8.0)". Linux adds the kernel version, since the
+// distro release says little about it
+func (h HostInfo) OSDisplayName() string {
+	name, version := h.OSName, h.OSVersion
+	if name == "" {
+		name, version = h.Platform, h.PlatformVersion
+	}
+	display := strings.TrimSpace(name + " " + version)
+	if h.OS == "linux" && h.KernelVersion != "" {
+		// ie. 6.8.0-45-generic
+		kernel, _, _ := strings.Cut(h.KernelVersion, "-")
+		display += " (" + kernel + ")"
+	}
+	return display
+}
@@ -0,0 +1,53 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// MACOS_NAMES are the marketing names of macOS by major version
+var MACOS_NAMES = map[string]string{
+	"11": "Big Sur",
+	"12": "Monterey",
+	"13": "Ventura",
+	"14": "Sonoma",
+	"15": "Sequoia",
+	"26": "Tahoe",
+}
+
+func readOSDetails() osDetails {
+	out, err := runCommand("sw_vers")
+	if err != nil {
+		return osDetails{Name: "macOS"}
+	}
+	return parseSwVers(out)
+}
+
+// parseSwVers parses the "ProductName:	macOS" lines of `sw_vers`
+func parseSwVers(out []byte) osDetails {
+	details := osDetails{Name: "macOS"}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "ProductName":
+			details.Name = value
+		case "ProductVersion":
+			details.Version = value
+		case "BuildVersion":
+			details.Build = value
+		}
+	}
+	details.PrettyName = details.Name
+	major, _, _ := strings.Cut(details.Version, ".")
+	if name, ok := MACOS_NAMES[major]; ok {
+		details.PrettyName += " " + name
+	}
+	details.PrettyName = strings.TrimSpace(details.PrettyName + " " + details.Version)
+	return details
+}
@@ -0,0 +1,49 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// OS_RELEASE_PATHS are tried in order, see os-release(5)
+var OS_RELEASE_PATHS = []string{"/etc/os-release", "/usr/lib/os-release"}
+
+func readOSDetails() osDetails {
+	for _, path := range OS_RELEASE_PATHS {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		release := parseOSRelease(data)
+		return osDetails{
+			Name:       release["NAME"],
+			Version:    release["VERSION_ID"],
+			PrettyName: release["PRETTY_NAME"],
+			Build:      release["BUILD_ID"],
+		}
+	}
+	return osDetails{Name: "Linux"}
+}
+
+// parseOSRelease parses the KEY=value lines of os-release. Values may be quoted
+func parseOSRelease(data []byte) map[string]string {
+	release := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		key, value, ok := strings.Cut(line, "=")
+		if !ok || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `"'`)
+		}
+		release[key] = value
+	}
+	return release
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+func readOSDetails() osDetails {
+	return osDetails{}
+}
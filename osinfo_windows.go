@@ -0,0 +1,59 @@
+package gtm
+
+import (
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"strconv"
+	"strings"
+)
+
+// CURRENT_VERSION_KEY holds the product name and version of Windows
+const CURRENT_VERSION_KEY = `SOFTWARE\Microsoft\Windows NT\CurrentVersion`
+
+// WINDOWS_11_BUILD is the first build of Windows 11, which still calls itself
+// "Windows 10" in the registry
+const WINDOWS_11_BUILD = 22000
+
+func readOSDetails() osDetails {
+	version := windows.RtlGetVersion()
+	details := osDetails{Name: "Windows",
+		Build: strconv.FormatUint(uint64(version.BuildNumber), 10)}
+
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, CURRENT_VERSION_KEY,
+		registry.QUERY_VALUE)
+	if err != nil {
+		return details
+	}
+	defer key.Close()
+
+	product, _, _ := key.GetStringValue("ProductName")
+	if version.BuildNumber >= WINDOWS_11_BUILD && version.MajorVersion == 10 {
+		product = strings.Replace(product, "Windows 10", "Windows 11", 1)
+	}
+	details.Name, details.PrettyName = windowsName(product), product
+	// DisplayVersion (ie. 23H2) replaced ReleaseId (ie. 2004) in 20H2
+	if details.Version, _, err = key.GetStringValue("DisplayVersion"); err != nil {
+		details.Version, _, _ = key.GetStringValue("ReleaseId")
+	}
+	if details.Version != "" {
+		details.PrettyName += " " + details.Version
+	}
+	if ubr, _, err := key.GetIntegerValue("UBR"); err == nil {
+		details.Build += "." + strconv.FormatUint(ubr, 10)
+	}
+	return details
+}
+
+// windowsName drops the edition from the product name, ie. "Windows 11 Pro" is
+// "Windows 11" and "Windows Server 2022 Datacenter" is "Windows Server 2022"
+func windowsName(product string) string {
+	words := strings.Fields(product)
+	n := 2
+	if len(words) > 1 && words[1] == "Server" {
+		n = 3
+	}
+	if len(words) < n {
+		return product
+	}
+	return strings.Join(words[:n], " ")
+}
@@ -0,0 +1,81 @@
+// Package otelgtm registers the metrics of gtm as observable instruments on an
+// OpenTelemetry MeterProvider, so applications that already run the OpenTelemetry SDK
+// export them through their own pipeline (readers, exporters, views and resource)
+// instead of gtm.StartOTLP. It's a separate module so gtm itself doesn't depend on the
+// OpenTelemetry API
+package otelgtm
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"gtm"
+)
+
+// SCOPE_NAME is the name of the meter the instruments are created with
+const SCOPE_NAME = "gtm"
+
+// Register creates an instrument on `provider` for every metric of the registry of the
+// Monitor (see gtm.Monitor.Metrics): an observable counter for counters and an
+// observable gauge otherwise, with the help and the UCUM unit of the metric. Whenever
+// the SDK collects, the current samples of the Monitor (see gtm.Monitor.Samples) are
+// observed. Like gtm.StartOTLP, per-device metrics have the device as an attribute
+// named after MetricDescriptor.Label, and its alias as "alias" when it has one.
+//
+// Metrics registered after Register (ie. by a plugin) aren't observed. Call Unregister
+// on the returned registration to stop observing
+func Register(m *gtm.Monitor, provider metric.MeterProvider) (metric.Registration,
+	error) {
+	meter := provider.Meter(SCOPE_NAME)
+
+	var (
+		descs       = map[string]gtm.MetricDescriptor{}
+		instruments = map[string]metric.Float64Observable{}
+		observables []metric.Observable
+	)
+	for _, desc := range m.Metrics() {
+		var (
+			instrument metric.Float64Observable
+			err        error
+		)
+		description := metric.WithDescription(desc.Help)
+		unit := metric.WithUnit(gtm.OTLPUnit(desc.Unit))
+		if desc.Type == gtm.MetricCounter {
+			instrument, err = meter.Float64ObservableCounter(desc.Name, description, unit)
+		} else {
+			instrument, err = meter.Float64ObservableGauge(desc.Name, description, unit)
+		}
+		if err != nil {
+			return nil, err
+		}
+		descs[desc.Name] = desc
+		instruments[desc.Name] = instrument
+		observables = append(observables, instrument)
+	}
+
+	observe := func(ctx context.Context, observer metric.Observer) error {
+		for _, sample := range m.Samples() {
+			instrument, ok := instruments[sample.Name]
+			if !ok {
+				continue
+			}
+			var attributes []attribute.KeyValue
+			desc := descs[sample.Name]
+			if label := sample.LabelValue(desc); label != "" {
+				attributes = append(attributes, attribute.String(desc.Label, label))
+			}
+			if sample.Alias != "" {
+				attributes = append(attributes, attribute.String("alias", sample.Alias))
+			}
+			observer.ObserveFloat64(instrument, sample.Value,
+				metric.WithAttributes(attributes...))
+		}
+		return nil
+	}
+	return meter.RegisterCallback(observe, observables...)
+}
+
+// RegisterDefault registers the metrics of the default Monitor, see Register
+func RegisterDefault(provider metric.MeterProvider) (metric.Registration, error) {
+	return Register(gtm.DefaultMonitor(), provider)
+}
@@ -0,0 +1,260 @@
+package gtm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// OTLP_INTERVAL is how often the metrics are pushed to the OpenTelemetry collector by
+	//	default, like OTEL_METRIC_EXPORT_INTERVAL of the OpenTelemetry SDKs
+	OTLP_INTERVAL = time.Minute
+	// OTLP_METRICS_PATH is where an OTLP/HTTP receiver takes metrics
+	OTLP_METRICS_PATH = "/v1/metrics"
+	// OTLP_TIMEOUT limits a push, so a collector that hangs doesn't delay the next one
+	OTLP_TIMEOUT = 10 * time.Second
+	// otlpTemporalityCumulative is AGGREGATION_TEMPORALITY_CUMULATIVE of OTLP
+	otlpTemporalityCumulative = 2
+)
+
+// OTLPOptions configures the metrics pushed by StartOTLP
+type OTLPOptions struct {
+	// Headers are sent with every push, ie. the API key of a vendor
+	Headers map[string]string
+	// Interval is how often the metrics are pushed, OTLP_INTERVAL when 0
+	Interval time.Duration
+}
+
+// StartOTLP pushes the current value of every metric of the Monitor (see Samples) to an
+// OpenTelemetry collector every interval, over OTLP/HTTP with the JSON encoding, so gtm
+// data flows into any OTel pipeline without the OpenTelemetry SDK. `endpoint` is the
+// base URL of the receiver, ie. "http://localhost:4318"; metrics are posted to
+// OTLP_METRICS_PATH. Gauges and counters keep their type, per-device metrics have the
+// device as an attribute named after MetricDescriptor.Label (and its alias as "alias",
+// if any), and the resource is named "gtm" with the hostname as host.name. Call the
+// returned function to stop pushing; it also stops once the Monitor is shut down.
+//
+// Applications that already run the OpenTelemetry SDK can register the metrics on their
+// own MeterProvider with the gtm/otelgtm module instead
+func (m *Monitor) StartOTLP(endpoint string, options OTLPOptions) (stop func(),
+	err error) {
+	u, err := url.Parse(strings.TrimSuffix(endpoint, "/") + OTLP_METRICS_PATH)
+	if err != nil {
+		return nil, err
+	} else if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid OTLP endpoint %q, it must be an http(s) URL",
+			endpoint)
+	}
+	interval := options.Interval
+	if interval <= 0 {
+		interval = OTLP_INTERVAL
+	}
+
+	start := GetClock().Now()
+	stop = m.pushEvery(interval, func() {
+		if err := m.pushOTLP(u.String(), options.Headers, start); err != nil {
+			m.log().Debug("Failed to push metrics to the OpenTelemetry collector! " +
+				err.Error())
+		}
+	}, func() {})
+	m.log().Info("Pushing metrics to the OpenTelemetry collector at " + u.String() +
+		" every " + interval.String() + " ...")
+	return stop, nil
+}
+
+// StartOTLP pushes the metrics of the default Monitor to an OpenTelemetry collector, see
+// Monitor.StartOTLP
+func StartOTLP(endpoint string, options OTLPOptions) (stop func(), err error) {
+	return defaultMonitor.StartOTLP(endpoint, options)
+}
+
+// pushOTLP posts every sample. Counters are cumulative since the host booted, or since
+// `start` when the boot time is unknown
+func (m *Monitor) pushOTLP(endpoint string, headers map[string]string,
+	start time.Time) error {
+	body, err := json.Marshal(m.otlpRequest(start))
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), OTLP_TIMEOUT)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint,
+		bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+//// OTLP JSON ////#######################################################################
+
+// The OTLP types below are the subset of ExportMetricsServiceRequest gtm sends, in the
+// JSON encoding of OTLP (64 bit integers are strings)
+type otlpRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope    `json:"scope"`
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpMetric struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Unit        string     `json:"unit,omitempty"`
+	Gauge       *otlpGauge `json:"gauge,omitempty"`
+	Sum         *otlpSum   `json:"sum,omitempty"`
+}
+
+type otlpGauge struct {
+	DataPoints []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpSum struct {
+	DataPoints             []otlpDataPoint `json:"dataPoints"`
+	AggregationTemporality int             `json:"aggregationTemporality"`
+	IsMonotonic            bool            `json:"isMonotonic"`
+}
+
+type otlpDataPoint struct {
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano,omitempty"`
+	TimeUnixNano      string          `json:"timeUnixNano"`
+	AsDouble          float64         `json:"asDouble"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue"`
+}
+
+// otlpRequest converts the samples of the Monitor, one metric per descriptor. The
+// counters are totals since boot (ie. the bytes of a network interface), so their points
+// start at the boot time, falling back to `start`
+func (m *Monitor) otlpRequest(start time.Time) otlpRequest {
+	descs := map[string]MetricDescriptor{}
+	for _, desc := range m.Metrics() {
+		descs[desc.Name] = desc
+	}
+	host, _ := m.HostInfo()
+	now := strconv.FormatInt(GetClock().Now().UnixNano(), 10)
+	if !host.BootTime.IsZero() {
+		start = host.BootTime
+	}
+	startTime := strconv.FormatInt(start.UnixNano(), 10)
+
+	var metrics []otlpMetric
+	for _, sample := range m.Samples() {
+		desc, ok := descs[sample.Name]
+		if !ok {
+			desc = MetricDescriptor{Name: sample.Name, Type: MetricGauge}
+		}
+		point := otlpDataPoint{TimeUnixNano: now, AsDouble: sample.Value}
+		if label := sample.LabelValue(desc); label != "" {
+			point.Attributes = []otlpAttribute{{Key: desc.Label,
+				Value: otlpValue{StringValue: label}}}
+		}
+		if sample.Alias != "" {
+			point.Attributes = append(point.Attributes, otlpAttribute{Key: "alias",
+				Value: otlpValue{StringValue: sample.Alias}})
+		}
+
+		// samples are sorted by name, so the points of a metric follow each other
+		if n := len(metrics); n == 0 || metrics[n-1].Name != sample.Name {
+			metric := otlpMetric{Name: sample.Name, Description: desc.Help,
+				Unit: OTLPUnit(desc.Unit)}
+			if desc.Type == MetricCounter {
+				metric.Sum = &otlpSum{AggregationTemporality: otlpTemporalityCumulative,
+					IsMonotonic: true}
+			} else {
+				metric.Gauge = &otlpGauge{}
+			}
+			metrics = append(metrics, metric)
+		}
+		metric := &metrics[len(metrics)-1]
+		if metric.Sum != nil {
+			point.StartTimeUnixNano = startTime
+			metric.Sum.DataPoints = append(metric.Sum.DataPoints, point)
+		} else {
+			metric.Gauge.DataPoints = append(metric.Gauge.DataPoints, point)
+		}
+	}
+
+	resource := otlpResource{Attributes: []otlpAttribute{
+		{Key: "service.name", Value: otlpValue{StringValue: "gtm"}},
+		{Key: "host.name", Value: otlpValue{StringValue: displayHostname(host.Hostname)}},
+	}}
+	return otlpRequest{ResourceMetrics: []otlpResourceMetrics{{Resource: resource,
+		ScopeMetrics: []otlpScopeMetrics{{Scope: otlpScope{Name: "gtm"},
+			Metrics: metrics}}}}}
+}
+
+// OTLPUnit converts the unit of a metric to the UCUM unit OpenTelemetry uses, ie.
+// "By/s" for MetricUnitBytesPerSecond
+func OTLPUnit(unit string) string {
+	switch unit {
+	case MetricUnitBytes:
+		return "By"
+	case MetricUnitBytesPerSecond:
+		return "By/s"
+	case MetricUnitCelsius:
+		return "Cel"
+	case MetricUnitDecibelMilliwatts:
+		return "dBm"
+	case MetricUnitMebibytes:
+		return "MiBy"
+	case MetricUnitMegabitsPerSecond:
+		return "Mbit/s"
+	case MetricUnitPercent:
+		return "%"
+	case MetricUnitRPM:
+		return "{rpm}"
+	case MetricUnitSeconds:
+		return "s"
+	case MetricUnitVolts:
+		return "V"
+	case MetricUnitWatts:
+		return "W"
+	}
+	return unit
+}
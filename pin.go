@@ -0,0 +1,197 @@
+package gtm
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// PinKind is the type of metric a Pin refers to
+type PinKind string
+
+const (
+	PinDisk    PinKind = "disk"
+	PinNetwork PinKind = "net"
+	PinProcess PinKind = "proc"
+)
+
+// Pin is a single favorite metric shown on the overview panel and in the mini output
+// mode. Name is a mountpoint (or device) for disks, an interface name for networks and
+// a process name for processes. Aliases match too, so a pin can use either name. A
+// process pin sums up every process with that name, ie. every firefox process
+type Pin struct {
+	Kind PinKind `json:"kind"`
+	Name string  `json:"name"`
+}
+
+// PinnedMetric is the current value of a Pin, already formatted for display. Found is
+// false when nothing matches the pin (ie. an unmounted disk), in which case Value is
+// "n/a"
+type PinnedMetric struct {
+	Pin   Pin    `json:"pin"`
+	Label string `json:"label"`
+	Value string `json:"value"`
+	Found bool   `json:"found"`
+}
+
+var (
+	pins   []Pin
+	pinMut sync.RWMutex
+)
+
+func (p Pin) String() string {
+	return string(p.Kind) + ":" + p.Name
+}
+
+// ParsePin parses a pin written as `kind:name`, ie. "disk:/home" or "net:eth0"
+func ParsePin(value string) (Pin, bool) {
+	kind, name, ok := strings.Cut(strings.TrimSpace(value), ":")
+	pin := Pin{Kind: PinKind(strings.ToLower(kind)), Name: strings.TrimSpace(name)}
+	if !ok || pin.Name == "" {
+		return Pin{}, false
+	}
+	switch pin.Kind {
+	case PinDisk, PinNetwork, PinProcess:
+		return pin, true
+	default:
+		return Pin{}, false
+	}
+}
+
+// parsePins parses the PINS config value, ie. "disk:/, net:eth0, proc:firefox"
+func parsePins(value string) (result []Pin) {
+	for _, item := range parseList(value) {
+		if pin, ok := ParsePin(item); ok {
+			result = append(result, pin)
+		} else {
+			logger().Error("Failed to parse pin: PINS ... ignoring: " + item)
+		}
+	}
+	return result
+}
+
+// PinMetric adds a pin to the end of the pinned metrics. It returns false if the metric
+// was already pinned
+func PinMetric(pin Pin) bool {
+	pinMut.Lock()
+	defer pinMut.Unlock()
+	if slices.Contains(pins, pin) {
+		return false
+	}
+	pins = append(pins, pin)
+	return true
+}
+
+// UnpinMetric removes a pin. It returns false if the metric wasn't pinned
+func UnpinMetric(pin Pin) bool {
+	pinMut.Lock()
+	defer pinMut.Unlock()
+	i := slices.Index(pins, pin)
+	if i < 0 {
+		return false
+	}
+	pins = slices.Delete(pins, i, i+1)
+	return true
+}
+
+// IsPinned returns true if the metric is pinned
+func IsPinned(pin Pin) bool {
+	pinMut.RLock()
+	defer pinMut.RUnlock()
+	return slices.Contains(pins, pin)
+}
+
+// GetPins returns a copy of every pin, in the order they were pinned
+func GetPins() []Pin {
+	pinMut.RLock()
+	defer pinMut.RUnlock()
+	return slices.Clone(pins)
+}
+
+// SetPins replaces every pin, ie. with the pins read from the config
+func SetPins(newPins []Pin) {
+	pinMut.Lock()
+	defer pinMut.Unlock()
+	pins = slices.Clone(newPins)
+}
+
+// GetPinnedMetrics resolves every pin to its current value
+func GetPinnedMetrics() []PinnedMetric {
+	currentPins := GetPins()
+	result := make([]PinnedMetric, 0, len(currentPins))
+	for _, pin := range currentPins {
+		metric := PinnedMetric{Pin: pin, Label: pin.Name, Value: "n/a"}
+		switch pin.Kind {
+		case PinDisk:
+			resolveDiskPin(&metric)
+		case PinNetwork:
+			resolveNetworkPin(&metric)
+		case PinProcess:
+			resolveProcessPin(&metric)
+		}
+		result = append(result, metric)
+	}
+	return result
+}
+
+// FormatPinnedMetrics joins the pinned metrics into a single line, ie.
+// "/ 42% | eth0 ↓1.2 MiB/s ↑80.0 KiB/s"
+func FormatPinnedMetrics() string {
+	metrics := GetPinnedMetrics()
+	parts := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		parts = append(parts, metric.Label+" "+metric.Value)
+	}
+	return strings.Join(parts, " | ")
+}
+
+func resolveDiskPin(metric *PinnedMetric) {
+	disksStats, _ := GetAllDisksStats()
+	for _, dsk := range disksStats {
+		if metric.Pin.Name != dsk.Mountpoint && metric.Pin.Name != dsk.Device &&
+			metric.Pin.Name != dsk.Alias {
+			continue
+		}
+		metric.Label = displayName(dsk.Mountpoint, dsk.Alias)
+		if dsk.Error == "" {
+			metric.Value = strconv.FormatFloat(dsk.UsedPercent, 'f', 0, 64) + "%"
+			metric.Found = true
+		}
+		return
+	}
+}
+
+func resolveNetworkPin(metric *PinnedMetric) {
+	stats, _ := GetAllNetworkStats()
+	for _, iface := range stats {
+		if metric.Pin.Name != iface.Name && metric.Pin.Name != iface.Alias {
+			continue
+		}
+		metric.Label = displayName(iface.Name, iface.Alias)
+		metric.Value = "↓" + FormatNetRate(iface.DownloadBytesPerSec) +
+			" ↑" + FormatNetRate(iface.UploadBytesPerSec)
+		metric.Found = true
+		return
+	}
+}
+
+func resolveProcessPin(metric *PinnedMetric) {
+	procs, _ := GetProcesses()
+	var (
+		cpuPercent float64
+		rss        uint64
+	)
+	for _, proc := range procs {
+		if proc.Name != metric.Pin.Name {
+			continue
+		}
+		cpuPercent += proc.CPUPercent
+		rss += proc.RSS
+		metric.Found = true
+	}
+	if metric.Found {
+		metric.Value = strconv.FormatFloat(cpuPercent, 'f', 0, 64) + "% " +
+			formatBytes(float64(rss))
+	}
+}
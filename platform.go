@@ -0,0 +1,84 @@
+package gtm
+
+import (
+	"strings"
+	"sync"
+)
+
+// PlatformKind is what gtm runs on
+type PlatformKind string
+
+const (
+	PlatformBareMetal PlatformKind = "bare_metal"
+	PlatformVM        PlatformKind = "vm"
+	PlatformContainer PlatformKind = "container"
+	PlatformWSL       PlatformKind = "wsl"
+)
+
+// Platform is what gtm runs on, so frontends and exporters can hide metrics that mean
+// little there (ie. temperatures in a VM, or host disks in a container). Hypervisor is
+// set for VMs, and for containers and WSL when the VM under them is known. It's empty
+// when the hypervisor can't be told
+type Platform struct {
+	Kind             PlatformKind `json:"kind"`
+	Hypervisor       string       `json:"hypervisor,omitempty"`
+	ContainerRuntime string       `json:"container_runtime,omitempty"`
+}
+
+// Hypervisors of Platform.Hypervisor
+const (
+	HypervisorKVM        = "kvm"
+	HypervisorQEMU       = "qemu"
+	HypervisorVMware     = "vmware"
+	HypervisorVirtualBox = "virtualbox"
+	HypervisorHyperV     = "hyperv"
+	HypervisorXen        = "xen"
+	HypervisorParallels  = "parallels"
+	HypervisorApple      = "apple"
+	HypervisorAmazon     = "amazon"
+	HypervisorGoogle     = "google"
+	HypervisorUnknown    = "unknown"
+)
+
+var (
+	platform     Platform
+	platformOnce sync.Once
+)
+
+// GetPlatform detects whether gtm runs on bare metal, in a VM, in a container or in
+// WSL. It's only detected once, since it can't change while gtm runs
+func GetPlatform() Platform {
+	platformOnce.Do(func() {
+		platform = detectPlatform()
+	})
+	return platform
+}
+
+// hypervisorFromModel finds the hypervisor in the system vendor and product name
+// (DMI/SMBIOS, or hw.model on macOS), which every hypervisor fills with its own name
+func hypervisorFromModel(vendor string, product string) string {
+	model := strings.ToLower(vendor + " " + product)
+	switch {
+	case strings.Contains(model, "vmware"):
+		return HypervisorVMware
+	case strings.Contains(model, "virtualbox"), strings.Contains(model, "innotek"):
+		return HypervisorVirtualBox
+	case strings.Contains(model, "microsoft") && strings.Contains(model, "virtual"):
+		return HypervisorHyperV
+	case strings.Contains(model, "parallels"):
+		return HypervisorParallels
+	case strings.Contains(model, "xen"):
+		return HypervisorXen
+	case strings.Contains(model, "kvm"):
+		return HypervisorKVM
+	case strings.Contains(model, "qemu"):
+		return HypervisorQEMU
+	case strings.Contains(model, "amazon ec2"):
+		return HypervisorAmazon
+	case strings.Contains(model, "google compute engine"):
+		return HypervisorGoogle
+	case strings.Contains(model, "virtualmac"), strings.Contains(model, "apple virtual"):
+		return HypervisorApple
+	}
+	return ""
+}
@@ -0,0 +1,20 @@
+package gtm
+
+import "strings"
+
+// detectPlatform asks the kernel whether it runs under a hypervisor. Containers on
+// macOS run in a linux VM, so gtm itself is never in one
+func detectPlatform() Platform {
+	out, err := runCommand("sysctl", "-n", "kern.hv_vmm_present")
+	if err != nil || strings.TrimSpace(string(out)) != "1" {
+		return Platform{Kind: PlatformBareMetal}
+	}
+	p := Platform{Kind: PlatformVM, Hypervisor: HypervisorUnknown}
+	if model, err := runCommand("sysctl", "-n", "hw.model"); err == nil {
+		// ie. VirtualMac2,1 (Apple Virtualization), VMware7,1 or Parallels-ARM
+		if hypervisor := hypervisorFromModel("", string(model)); hypervisor != "" {
+			p.Hypervisor = hypervisor
+		}
+	}
+	return p
+}
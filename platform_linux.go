@@ -0,0 +1,92 @@
+package gtm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DMI_PATH is where linux exposes the DMI/SMBIOS tables
+const DMI_PATH = "/sys/class/dmi/id"
+
+func detectPlatform() Platform {
+	var p Platform
+	if hasCPUFlag("hypervisor") {
+		p.Hypervisor = HypervisorUnknown
+	}
+	vendor := readSysfsString(filepath.Join(DMI_PATH, "sys_vendor"))
+	product := readSysfsString(filepath.Join(DMI_PATH, "product_name"))
+	if hypervisor := hypervisorFromModel(vendor, product); hypervisor != "" {
+		p.Hypervisor = hypervisor
+	} else if readSysfsString("/sys/hypervisor/type") == "xen" {
+		p.Hypervisor = HypervisorXen
+	}
+
+	if runtime := detectContainerRuntime(); runtime != "" {
+		p.Kind, p.ContainerRuntime = PlatformContainer, runtime
+		return p
+	}
+	// WSL 1 kernels end with "-Microsoft", WSL 2 kernels with "-microsoft-standard-WSL2"
+	if strings.Contains(strings.ToLower(readSysfsString("/proc/sys/kernel/osrelease")),
+		"microsoft") {
+		p.Kind = PlatformWSL
+		if p.Hypervisor != "" {
+			p.Hypervisor = HypervisorHyperV
+		}
+		return p
+	}
+	if p.Hypervisor != "" {
+		p.Kind = PlatformVM
+	} else {
+		p.Kind = PlatformBareMetal
+	}
+	return p
+}
+
+// detectContainerRuntime looks for the files and variables container runtimes leave
+// behind, then for a container in the cgroup of gtm itself
+func detectContainerRuntime() string {
+	switch {
+	case os.Getenv("KUBERNETES_SERVICE_HOST") != "":
+		return RuntimeKubernetes
+	case fileExists("/run/.containerenv"):
+		return RuntimePodman
+	case fileExists("/.dockerenv"):
+		return RuntimeDocker
+	}
+	// systemd's container interface: PID 1 has container=<runtime> in its environment
+	if environ, err := os.ReadFile("/proc/1/environ"); err == nil {
+		for _, variable := range bytes.Split(environ, []byte{0}) {
+			if runtime, ok := bytes.CutPrefix(variable, []byte("container=")); ok {
+				return string(runtime)
+			}
+		}
+	}
+	if cgroup := readProcessCgroup(int32(os.Getpid())); cgroup.ContainerID != "" {
+		return cgroup.ContainerRuntime
+	}
+	return ""
+}
+
+// hasCPUFlag reports whether /proc/cpuinfo lists a flag, ie. "hypervisor", which the
+// CPU reports inside any VM
+func hasCPUFlag(flag string) bool {
+	data, err := os.ReadFile("/proc/cpuinfo")
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok || strings.TrimSpace(key) != "flags" {
+			continue
+		}
+		for _, f := range strings.Fields(value) {
+			if f == flag {
+				return true
+			}
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+func detectPlatform() Platform {
+	return Platform{Kind: PlatformBareMetal}
+}
@@ -0,0 +1,43 @@
+package gtm
+
+import (
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+)
+
+// BIOS_KEY holds the SMBIOS system manufacturer and product name
+const BIOS_KEY = `HARDWARE\DESCRIPTION\System\BIOS`
+
+func detectPlatform() Platform {
+	p := Platform{Kind: PlatformBareMetal}
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, BIOS_KEY, registry.QUERY_VALUE)
+	if err == nil {
+		defer key.Close()
+		vendor, _, _ := key.GetStringValue("SystemManufacturer")
+		product, _, _ := key.GetStringValue("SystemProductName")
+		if hypervisor := hypervisorFromModel(vendor, product); hypervisor != "" {
+			p.Kind, p.Hypervisor = PlatformVM, hypervisor
+		}
+	}
+	// Windows containers share the host kernel, and their only trace is the
+	// ContainerExecutionAgent service
+	if isContainer, err := hasService("cexecsvc"); err == nil && isContainer {
+		p.Kind, p.ContainerRuntime = PlatformContainer, RuntimeDocker
+	}
+	return p
+}
+
+func hasService(name string) (bool, error) {
+	manager, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_CONNECT)
+	if err != nil {
+		return false, err
+	}
+	defer windows.CloseServiceHandle(manager)
+	service, err := windows.OpenService(manager, windows.StringToUTF16Ptr(name),
+		windows.SERVICE_QUERY_STATUS)
+	if err != nil {
+		return false, nil
+	}
+	windows.CloseServiceHandle(service)
+	return true, nil
+}
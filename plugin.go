@@ -0,0 +1,247 @@
+package gtm
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PLUGIN_UPDATE_INTERVAL is how long the metrics of the plugins in the config are cached
+const PLUGIN_UPDATE_INTERVAL = 10 * time.Second
+
+// ErrPluginRegistered is returned when a Monitor has a plugin of the same name already
+var ErrPluginRegistered = errors.New("plugin is already registered")
+
+// Plugin is a collector of metrics gtm doesn't know about, ie. the stats of a router or
+// the counters of an application. Its metrics go through the same caching, history,
+// subscriptions and metric registry as the stats of the built-in collectors. Plugins
+// written in other languages are run with NewExecPlugin
+type Plugin interface {
+	// Name is the collector of the plugin, ie. "router". It prefixes the names of its
+	//	metrics, so it can't have dots nor be the name of a built-in collector
+	Name() string
+	// Collect returns the current value of every metric of the plugin
+	Collect() ([]PluginMetric, error)
+}
+
+// PluginMetric is a value collected by a plugin. Type (MetricGauge by default), Unit and
+// Help describe the metric in the registry the first time it's collected
+type PluginMetric struct {
+	Name  string     `json:"name"`
+	Value float64    `json:"value"`
+	Type  MetricType `json:"type,omitempty"`
+	Unit  string     `json:"unit,omitempty"`
+	Help  string     `json:"help,omitempty"`
+}
+
+// PluginMetricName builds the name of a metric of a plugin, ie. "router.wan_rx_bytes"
+func PluginMetricName(plugin string, metric string) string {
+	return plugin + metricDeviceSeparator + metric
+}
+
+// pluginCollector is the cache of a plugin
+type pluginCollector struct {
+	plugin    Plugin
+	mut       sync.Mutex
+	metrics   []PluginMetric
+	lastFetch time.Time
+	// registered is the metrics described in the registry already
+	registered map[string]bool
+	flight     flight[[]PluginMetric]
+}
+
+// AddPlugin collects the metrics of `plugin` every `interval`, like the built-in
+// collectors. The interval can be changed later with SetInterval, with the name of the
+// plugin as the collector. Plugins added while the Monitor is polling are polled once
+// it's started again
+func (m *Monitor) AddPlugin(plugin Plugin, interval time.Duration) error {
+	name := plugin.Name()
+	switch {
+	case name == "" || strings.Contains(name, metricDeviceSeparator):
+		return fmt.Errorf("invalid plugin name %q", name)
+	case slices.Contains(allCollectors, Collector(name)):
+		return fmt.Errorf("%w: %s is a built-in collector", ErrPluginRegistered, name)
+	case interval < MIN_UPDATE_INTERVAL:
+		return fmt.Errorf("%w: %s is below the %s minimum of %s", ErrInvalidInterval,
+			interval, name, MIN_UPDATE_INTERVAL)
+	}
+
+	m.pluginMut.Lock()
+	defer m.pluginMut.Unlock()
+	if _, ok := m.plugins[Collector(name)]; ok {
+		return fmt.Errorf("%w: %s", ErrPluginRegistered, name)
+	}
+	if m.plugins == nil {
+		m.plugins = map[Collector]*pluginCollector{}
+	}
+	m.plugins[Collector(name)] = &pluginCollector{plugin: plugin,
+		registered: map[string]bool{}}
+
+	m.intervalMut.Lock()
+	m.intervals[Collector(name)] = interval
+	m.intervalMut.Unlock()
+	return nil
+}
+
+// WithPlugin adds a plugin to the Monitor, see AddPlugin. Plugins that can't be added
+// are logged and ignored
+func WithPlugin(plugin Plugin, interval time.Duration) MonitorOption {
+	return func(m *Monitor) {
+		if err := m.AddPlugin(plugin, interval); err != nil {
+			m.log().Warn("Ignoring the " + plugin.Name() + " plugin! " + err.Error())
+		}
+	}
+}
+
+// RegisterPlugin adds a plugin to the default Monitor, see Monitor.AddPlugin
+func RegisterPlugin(plugin Plugin, interval time.Duration) error {
+	return defaultMonitor.AddPlugin(plugin, interval)
+}
+
+// RegisterConfigPlugins adds the plugins in PLUGINS of the config to the default
+// Monitor, ie.
+//
+//	PLUGINS=router=/usr/local/bin/router-stats --json,app=/opt/app/metrics
+//
+// Plugins that can't be added are logged and skipped
+func RegisterConfigPlugins() {
+	names := make([]string, 0, len(Cfg.Plugins))
+	for name := range Cfg.Plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		command := strings.Fields(Cfg.Plugins[name])
+		err := RegisterPlugin(NewExecPlugin(name, command[0], command[1:]...),
+			PLUGIN_UPDATE_INTERVAL)
+		if err != nil {
+			logger().Error("Failed to register the " + name + " plugin! " + err.Error())
+		}
+	}
+}
+
+// Plugins returns the names of the plugins of the Monitor, sorted
+func (m *Monitor) Plugins() []string {
+	m.pluginMut.RLock()
+	defer m.pluginMut.RUnlock()
+	names := make([]string, 0, len(m.plugins))
+	for name := range m.plugins {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Monitor) plugin(name string) (*pluginCollector, bool) {
+	m.pluginMut.RLock()
+	defer m.pluginMut.RUnlock()
+	plugin, ok := m.plugins[Collector(name)]
+	return plugin, ok
+}
+
+// PluginMetrics returns the metrics collected by a plugin. When the plugin fails, the
+// last metrics are returned with the error
+func (m *Monitor) PluginMetrics(name string) ([]PluginMetric, error) {
+	p, ok := m.plugin(name)
+	if !ok {
+		return nil, errors.New("the monitor has no " + name + " plugin")
+	}
+
+	p.mut.Lock()
+	if GetClock().Since(p.lastFetch) < m.interval(Collector(name)) && p.metrics != nil {
+		metrics := p.metrics
+		p.mut.Unlock()
+		return metrics, nil
+	}
+	p.mut.Unlock()
+
+	return p.flight.do(func() ([]PluginMetric, error) { return m.fetchPlugin(name, p) })
+}
+
+// GetPluginMetrics returns the metrics of a plugin of the default Monitor
+func GetPluginMetrics(name string) ([]PluginMetric, error) {
+	return defaultMonitor.PluginMetrics(name)
+}
+
+func (m *Monitor) fetchPlugin(name string, p *pluginCollector) ([]PluginMetric, error) {
+	start := m.startCollect(Collector(name))
+	collected, err := p.plugin.Collect()
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	if err != nil {
+		m.collectorError(Collector(name), "Failed to collect the "+name+" plugin!", err)
+		m.collected(Collector(name), start, nil, err)
+		return p.metrics, err
+	}
+
+	now := GetClock().Now()
+	metrics := make([]PluginMetric, 0, len(collected))
+	for _, metric := range collected {
+		if metric.Name == "" || strings.Contains(metric.Name, metricDeviceSeparator) {
+			m.log().Debug("Ignoring the metric " + metric.Name + " of the " + name +
+				" plugin, its name is invalid")
+			continue
+		}
+		if metric.Type == "" {
+			metric.Type = MetricGauge
+		}
+		fullName := PluginMetricName(name, metric.Name)
+		if !p.registered[metric.Name] {
+			err := RegisterMetric(MetricDescriptor{Name: fullName,
+				Collector: Collector(name), Type: metric.Type, Unit: metric.Unit,
+				Help: metric.Help})
+			if err != nil && !errors.Is(err, ErrMetricRegistered) {
+				m.log().Debug("Failed to register " + fullName + "! " + err.Error())
+			}
+			p.registered[metric.Name] = true
+		}
+		m.recordMetric(fullName, now, metric.Value)
+		metrics = append(metrics, metric)
+	}
+	p.metrics = metrics
+	p.lastFetch = now
+	m.collected(Collector(name), start, p.metrics, nil)
+	return p.metrics, nil
+}
+
+//// exec ////################################################################################
+
+// execPlugin is a Plugin running a command, see NewExecPlugin
+type execPlugin struct {
+	name    string
+	command string
+	args    []string
+}
+
+// NewExecPlugin returns a Plugin running a command, so plugins can be written in any
+// language. The command prints the metrics on stdout as a JSON array of PluginMetric,
+// ie.
+//
+//	[{"name": "wan_rx_bytes", "value": 1234, "type": "counter", "unit": "bytes"}]
+//
+// It runs in the shared command pool like the SMI tools, so it's killed after
+// EXEC_TIMEOUT
+func NewExecPlugin(name string, command string, args ...string) Plugin {
+	return execPlugin{name: name, command: command, args: args}
+}
+
+func (p execPlugin) Name() string { return p.name }
+
+func (p execPlugin) Collect() ([]PluginMetric, error) {
+	out, err := runCommand(p.command, p.args...)
+	if err != nil {
+		return nil, err
+	}
+	var metrics []PluginMetric
+	if err = json.Unmarshal(out, &metrics); err != nil {
+		return nil, fmt.Errorf("invalid output of %s: %w", p.command, err)
+	}
+	return metrics, nil
+}
@@ -0,0 +1,100 @@
+package gtm
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrMonitorStarted is returned by Monitor.Start when the Monitor is polling already
+var ErrMonitorStarted = errors.New("monitor is already started")
+
+// pollState is the background polling of a Monitor, see Monitor.Start
+type pollState struct {
+	mut    sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// rescheduled wakes up the poller of a collector when its interval changed
+	rescheduled map[Collector]chan struct{}
+}
+
+// Start polls every collector of the Monitor in the background, each one on its own
+// interval, until `ctx` is done or Stop is called. The caches always hold the latest
+// stats, so the Get methods return them without waiting for a fetch, and rates and
+// history are sampled at a steady pace instead of whenever someone asks. The GPU is
+// only polled when HasGPU found one at start. Call Stop before starting it again, even
+// when `ctx` is done already. A Monitor that was shut down returns ErrMonitorClosed
+func (m *Monitor) Start(ctx context.Context) error {
+	m.poll.mut.Lock()
+	defer m.poll.mut.Unlock()
+	if m.poll.cancel != nil {
+		return ErrMonitorStarted
+	}
+	if m.isClosed() {
+		return ErrMonitorClosed
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	m.poll.cancel = cancel
+	m.poll.rescheduled = map[Collector]chan struct{}{}
+	for collector, collect := range m.collectors() {
+		rescheduled := make(chan struct{}, 1)
+		m.poll.rescheduled[collector] = rescheduled
+		m.poll.wg.Add(1)
+		go func() {
+			defer m.poll.wg.Done()
+			m.pollCollector(ctx, collector, collect, rescheduled)
+		}()
+	}
+	return nil
+}
+
+// Stop stops the polling started by Start and waits for collections in progress to
+// finish. Stopping a Monitor that isn't polling does nothing
+func (m *Monitor) Stop() {
+	m.poll.mut.Lock()
+	defer m.poll.mut.Unlock()
+	if m.poll.cancel == nil {
+		return
+	}
+	m.poll.cancel()
+	m.poll.wg.Wait()
+	m.poll.cancel = nil
+	m.poll.rescheduled = nil
+}
+
+// reschedule wakes up the poller of a collector, so a new interval applies right away
+// instead of after the old interval
+func (m *Monitor) reschedule(collector Collector) {
+	m.poll.mut.Lock()
+	defer m.poll.mut.Unlock()
+	if rescheduled, ok := m.poll.rescheduled[collector]; ok {
+		select {
+		case rescheduled <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// pollCollector collects right away, and then every interval of the collector. Errors
+// are logged by the collectors already
+func (m *Monitor) pollCollector(ctx context.Context, collector Collector,
+	collect func() error, rescheduled <-chan struct{}) {
+	for {
+		collect()
+		collected := GetClock().Now()
+		next := GetClock().After(m.interval(collector))
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-rescheduled:
+				// the new interval counts from the last collection too
+				next = GetClock().After(m.interval(collector) - GetClock().Since(collected))
+			case <-next:
+				break wait
+			}
+		}
+	}
+}
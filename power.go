@@ -0,0 +1,138 @@
+package gtm
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// POWER_UPDATE_INTERVAL is how often the power draw is estimated. The CPU power is
+// averaged over the time between two estimates
+const POWER_UPDATE_INTERVAL = time.Second
+
+// PowerSource is how PowerStats.TotalWatts was estimated
+type PowerSource string
+
+const (
+	// PowerSourceBattery is the discharge rate of the batteries, which covers the whole
+	// system. It's only known while running on battery
+	PowerSourceBattery PowerSource = "battery"
+	// PowerSourceComponents is the CPU plus the GPUs, which leaves out the rest of the
+	// system (display, disks, fans, PSU losses), so it's a lower bound
+	PowerSourceComponents PowerSource = "components"
+)
+
+// PowerStats is an estimate of the power the system draws, in watts. CPUWatts is the
+// RAPL package power of every CPU socket (Intel, and AMD since Zen), which usually
+// needs root on linux and isn't available on Windows and macOS. GPUWatts is the draw
+// of every GPU, and BatteryWatts is the discharge rate of the batteries (0 on AC).
+// Every source that isn't available is 0
+type PowerStats struct {
+	CPUWatts     float64     `json:"cpu_watts"`
+	GPUWatts     float64     `json:"gpu_watts"`
+	BatteryWatts float64     `json:"battery_watts"`
+	TotalWatts   float64     `json:"total_watts"`
+	Source       PowerSource `json:"source,omitempty"`
+}
+
+// raplDomain is the energy counter of a RAPL package, in microjoules. The counter wraps
+// around at MaxEnergy
+type raplDomain struct {
+	Energy    uint64
+	MaxEnergy uint64
+}
+
+var (
+	powerStats     PowerStats
+	lastFetchPower time.Time
+	raplEnergy     map[string]raplDomain
+	lastFetchRAPL  time.Time
+	powerMut       sync.Mutex
+)
+
+// GetPowerStats estimates the power draw of the system. The CPU power is 0 on the first
+// call, since it's computed from the energy used since the previous call. The stats are
+// still filled from the other sources when the CPU power can't be read
+func GetPowerStats() (PowerStats, error) {
+	powerMut.Lock()
+	defer powerMut.Unlock()
+
+	if GetClock().Since(lastFetchPower) < POWER_UPDATE_INTERVAL {
+		return powerStats, nil
+	}
+	if !IsCollectorEnabled(CollectorPower) {
+		return PowerStats{}, ErrCollectorDisabled
+	}
+	lastFetchPower = GetClock().Now()
+
+	var stats PowerStats
+	cpuWatts, err := getCPUPower()
+	if errors.Is(err, errors.ErrUnsupported) {
+		err = nil
+	} else if err != nil {
+		collectorError(CollectorPower, "Failed to retrieve the RAPL energy counters!", err)
+	}
+	stats.CPUWatts = cpuWatts
+
+	// Not HasGPU(), which probes for GPUs again on every call when there are none
+	if hasGPU() {
+		gpus, _ := GetGPUStats()
+		for _, gpu := range gpus {
+			stats.GPUWatts += gpu.Power
+		}
+	}
+	batteries, _ := GetBatteryStats()
+	for _, battery := range batteries {
+		if battery.State == BatteryDischarging {
+			stats.BatteryWatts += battery.Watts
+		}
+	}
+
+	switch {
+	case stats.BatteryWatts > 0:
+		stats.TotalWatts, stats.Source = stats.BatteryWatts, PowerSourceBattery
+	case stats.CPUWatts > 0 || stats.GPUWatts > 0:
+		stats.TotalWatts = stats.CPUWatts + stats.GPUWatts
+		stats.Source = PowerSourceComponents
+	}
+	stats.CPUWatts = RoundStat(stats.CPUWatts)
+	stats.GPUWatts = RoundStat(stats.GPUWatts)
+	stats.BatteryWatts = RoundStat(stats.BatteryWatts)
+	stats.TotalWatts = RoundStat(stats.TotalWatts)
+
+	powerStats = stats
+	if stats.Source != "" {
+		recordMetric(MetricPowerTotal, lastFetchPower, stats.TotalWatts)
+	}
+	return powerStats, err
+}
+
+// getCPUPower returns the average CPU power since the previous call, from the RAPL
+// energy counters
+func getCPUPower() (float64, error) {
+	domains, err := readRAPL()
+	if err != nil {
+		return 0, err
+	}
+	now := GetClock().Now()
+	previous, seconds := raplEnergy, sampleSeconds(lastFetchRAPL, now)
+	raplEnergy, lastFetchRAPL = domains, now
+	if previous == nil || seconds <= 0 {
+		return 0, nil
+	}
+
+	var microjoules uint64
+	for name, domain := range domains {
+		last, ok := previous[name]
+		if !ok {
+			continue
+		}
+		if domain.Energy >= last.Energy {
+			microjoules += domain.Energy - last.Energy
+		} else {
+			// wrapped around
+			microjoules += domain.MaxEnergy - last.Energy + domain.Energy
+		}
+	}
+	return float64(microjoules) / 1e6 / seconds, nil
+}
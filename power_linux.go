@@ -0,0 +1,47 @@
+package gtm
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// POWERCAP_PATH holds the RAPL domains, ie. intel-rapl:0 for the package of socket 0
+// and intel-rapl:0:0 for its cores. AMD CPUs use the intel-rapl driver too
+const POWERCAP_PATH = "/sys/class/powercap"
+
+// readRAPL reads the energy counters of every RAPL package. The subdomains (cores,
+// uncore, dram) are left out, since they're part of the package
+func readRAPL() (map[string]raplDomain, error) {
+	entries, err := os.ReadDir(POWERCAP_PATH)
+	if os.IsNotExist(err) {
+		return map[string]raplDomain{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	domains := map[string]raplDomain{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasPrefix(name, "intel-rapl:") || strings.Count(name, ":") != 1 {
+			continue
+		}
+		dir := filepath.Join(POWERCAP_PATH, name)
+		if !strings.HasPrefix(readSysfsString(filepath.Join(dir, "name")), "package") {
+			continue
+		}
+		// energy_uj is only readable by root since linux 5.10
+		data, err := os.ReadFile(filepath.Join(dir, "energy_uj"))
+		if err != nil {
+			return nil, err
+		}
+		energy, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		maxEnergy, _ := strconv.ParseUint(
+			readSysfsString(filepath.Join(dir, "max_energy_range_uj")), 10, 64)
+		domains[name] = raplDomain{Energy: energy, MaxEnergy: maxEnergy}
+	}
+	return domains, nil
+}
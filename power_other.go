@@ -0,0 +1,9 @@
+//go:build !linux
+
+package gtm
+
+// readRAPL isn't supported, since reading the RAPL MSRs needs a kernel driver on
+// Windows and macOS
+func readRAPL() (map[string]raplDomain, error) {
+	return nil, ErrUnsupportedPlatform
+}
@@ -0,0 +1,80 @@
+package gtm
+
+import (
+	"math"
+	"strings"
+)
+
+// RoundingMode decides how stats are rounded to the configured precision (PRECISION in
+// the config) before they are returned by a collector or written to JSON
+type RoundingMode int
+
+const (
+	// RoundHalfEven is banker's rounding (2.345 -> 2.34), which doesn't drift upwards
+	//	when values are summed or averaged
+	RoundHalfEven RoundingMode = iota
+	// RoundHalfUp is "school" rounding (2.345 -> 2.35)
+	RoundHalfUp
+	// RoundDown truncates towards zero (2.349 -> 2.34)
+	RoundDown
+	// RoundNone keeps full float64 precision
+	RoundNone
+)
+
+// DEFAULT_PRECISION is the number of decimals stats are rounded to by default
+const DEFAULT_PRECISION = 2
+
+func (m RoundingMode) String() string {
+	switch m {
+	case RoundHalfEven:
+		return "half_even"
+	case RoundHalfUp:
+		return "half_up"
+	case RoundDown:
+		return "down"
+	case RoundNone:
+		return "none"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseRoundingMode parses the ROUNDING config value. The boolean is false for unknown
+// modes
+func ParseRoundingMode(mode string) (RoundingMode, bool) {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "half_even", "even", "bankers":
+		return RoundHalfEven, true
+	case "half_up", "up":
+		return RoundHalfUp, true
+	case "down", "truncate":
+		return RoundDown, true
+	case "none", "off":
+		return RoundNone, true
+	default:
+		return RoundHalfEven, false
+	}
+}
+
+// RoundStat rounds a stat using the configured precision and rounding mode. Every
+// percentage in gtm is on a 0-100 scale, so a precision of 2 gives ie. 42.37 %
+func RoundStat(value float64) float64 {
+	return roundTo(value, Cfg.Precision, Cfg.Rounding)
+}
+
+func roundTo(value float64, precision int, mode RoundingMode) float64 {
+	if mode == RoundNone || math.IsNaN(value) || math.IsInf(value, 0) {
+		return value
+	}
+	scale := math.Pow10(precision)
+	scaled := value * scale
+	switch mode {
+	case RoundHalfUp:
+		scaled = math.Round(scaled)
+	case RoundDown:
+		scaled = math.Trunc(scaled)
+	default:
+		scaled = math.RoundToEven(scaled)
+	}
+	return scaled / scale
+}
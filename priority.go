@@ -0,0 +1,38 @@
+package gtm
+
+import (
+	"errors"
+	"strconv"
+)
+
+// Range of nice values, from the highest to the lowest priority
+const (
+	NICE_MIN = -20
+	NICE_MAX = 19
+)
+
+// SetProcessPriority renices a process like htop does. Raising the priority (lowering
+// nice) usually needs root/admin. On Windows, nice is mapped to a priority class:
+//
+//	nice >= 15          IDLE
+//	nice 1 to 14        BELOW_NORMAL
+//	nice 0              NORMAL
+//	nice -1 to -9       ABOVE_NORMAL
+//	nice -10 and lower  HIGH
+//
+// REALTIME is never set, since a busy realtime process can starve the whole system
+func SetProcessPriority(pid int32, nice int) error {
+	op := "set priority " + strconv.Itoa(nice)
+	if pid <= 0 {
+		return &ProcessError{PID: pid, Op: op, Err: errors.New("invalid PID")}
+	}
+	if nice < NICE_MIN || nice > NICE_MAX {
+		return &ProcessError{PID: pid, Op: op, Err: errors.New("nice out of range")}
+	}
+	if err := setProcessPriority(pid, nice); err != nil {
+		return &ProcessError{PID: pid, Op: op, Err: err}
+	}
+	// show the new priority on the next fetch
+	resetProcesses()
+	return nil
+}
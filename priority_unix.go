@@ -0,0 +1,17 @@
+//go:build !windows
+
+package gtm
+
+import (
+	"errors"
+	"syscall"
+)
+
+func setProcessPriority(pid int32, nice int) error {
+	err := syscall.Setpriority(syscall.PRIO_PROCESS, int(pid), nice)
+	if errors.Is(err, syscall.ESRCH) {
+		return ErrProcessNotFound
+	}
+	// EACCES (raising the priority without root) matches fs.ErrPermission already
+	return err
+}
@@ -0,0 +1,37 @@
+package gtm
+
+import "golang.org/x/sys/windows"
+
+// priorityClassNice is the nice value shown for every priority class
+var priorityClassNice = map[uint32]int32{
+	windows.IDLE_PRIORITY_CLASS:         19,
+	windows.BELOW_NORMAL_PRIORITY_CLASS: 10,
+	windows.NORMAL_PRIORITY_CLASS:       0,
+	windows.ABOVE_NORMAL_PRIORITY_CLASS: -5,
+	windows.HIGH_PRIORITY_CLASS:         -10,
+	windows.REALTIME_PRIORITY_CLASS:     -20,
+}
+
+func setProcessPriority(pid int32, nice int) error {
+	handle, err := openProcess(windows.PROCESS_SET_INFORMATION, pid)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+	return windows.SetPriorityClass(handle, nicePriorityClass(nice))
+}
+
+func nicePriorityClass(nice int) uint32 {
+	switch {
+	case nice >= 15:
+		return windows.IDLE_PRIORITY_CLASS
+	case nice > 0:
+		return windows.BELOW_NORMAL_PRIORITY_CLASS
+	case nice == 0:
+		return windows.NORMAL_PRIORITY_CLASS
+	case nice > -10:
+		return windows.ABOVE_NORMAL_PRIORITY_CLASS
+	default:
+		return windows.HIGH_PRIORITY_CLASS
+	}
+}
@@ -0,0 +1,145 @@
+package gtm
+
+import (
+	"encoding/json"
+	"errors"
+	"os/exec"
+	"runtime"
+	"slices"
+	"sync"
+)
+
+// Capabilities is what Probe found on this machine. Frontends check it up front to hide
+// the panels of collectors that can't work here, instead of showing them empty
+type Capabilities struct {
+	Environment Environment `json:"environment"`
+	// GPUVendor is "nvidia" or "amd", or "" when no supported GPU was found
+	GPUVendor  string       `json:"gpu_vendor,omitempty"`
+	Collectors []Capability `json:"collectors"`
+}
+
+// Has reports whether a collector isn't disabled
+func (c Capabilities) Has(collector Collector) bool {
+	i := slices.IndexFunc(c.Collectors, func(c Capability) bool {
+		return c.Collector == collector
+	})
+	return i < 0 || c.Collectors[i].Status != CapabilityDisabled
+}
+
+func (c Capabilities) JSON(indent bool) string {
+	if indent {
+		out, err := json.MarshalIndent(c, "", "  ")
+		if err != nil {
+			logger().Error("Failed to marshal indent JSON from struct Capabilities{} !" +
+				err.Error())
+		}
+		return string(out)
+	} else {
+		out, err := json.Marshal(c)
+		if err != nil {
+			logger().Error("Failed to marshal JSON from struct Capabilities{} !" + err.Error())
+		}
+		return string(out)
+	}
+}
+
+var (
+	initCapabilities Capabilities
+	initOnce         sync.Once
+)
+
+// Init probes the machine once (see Probe) and returns the capabilities found. Call it
+// before any collector runs; later calls return the first result
+func Init() Capabilities {
+	initOnce.Do(func() {
+		initCapabilities = Probe()
+	})
+	return initCapabilities
+}
+
+// capabilityProbe reads a collector once. `found` is false when the hardware or the
+// tool the collector reads isn't there
+type capabilityProbe struct {
+	collector Collector
+	probe     func() (found bool, err error)
+	missing   string
+}
+
+var capabilityProbes = []capabilityProbe{
+	{CollectorBattery, func() (bool, error) {
+		batteries, err := readBatteries()
+		return len(batteries) > 0, err
+	}, "no batteries found"},
+	{CollectorProcessNetwork, func() (bool, error) {
+		_, err := getSocketCounters()
+		return true, err
+	}, ""},
+	{CollectorSensors, func() (bool, error) {
+		sensors, err := readSensors()
+		return len(sensors) > 0, err
+	}, "no sensors found"},
+	{CollectorSMART, func() (bool, error) {
+		_, err := runCommand("smartctl", "--scan", "--json")
+		return true, err
+	}, ""},
+	{CollectorTimeSync, func() (bool, error) {
+		_, err := readTimeSyncStatus()
+		return true, err
+	}, ""},
+	{CollectorWiFi, func() (bool, error) {
+		wifi, err := getWiFiStats()
+		return len(wifi) > 0, err
+	}, "no wireless interfaces found"},
+}
+
+// Probe detects the environment (see DetectEnvironment) and the GPU of the default
+// Monitor, and reads the sensors, SMART, per-process network, batteries, WiFi and time
+// sync once. Collectors the OS doesn't support, whose tool isn't installed or whose
+// hardware is missing are disabled, so they don't log the same error every interval.
+// Permission errors degrade the collector instead. The GPU is never disabled, so a GPU
+// plugged in later is still found (see StartDeviceDiscovery), it's only reported as
+// disabled while there is none
+func Probe() Capabilities {
+	env := DetectEnvironment()
+
+	var wg sync.WaitGroup
+	for _, p := range capabilityProbes {
+		if !IsCollectorEnabled(p.collector) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			found, err := p.probe()
+			switch {
+			case errors.Is(err, ErrUnsupported):
+				setCapability(p.collector, CapabilityDisabled,
+					"not supported on "+runtime.GOOS)
+			case errors.Is(err, exec.ErrNotFound):
+				setCapability(p.collector, CapabilityDisabled, err.Error())
+			case errors.Is(err, ErrPermissionDenied):
+				setCapability(p.collector, CapabilityDegraded,
+					"permission denied: "+err.Error())
+			case err == nil && !found:
+				setCapability(p.collector, CapabilityDisabled, p.missing)
+			}
+		}()
+	}
+	hasGPU := defaultMonitor.HasGPU()
+	wg.Wait()
+
+	capabilities := Capabilities{
+		Environment: env,
+		GPUVendor:   defaultMonitor.gpuVendor(),
+		Collectors:  GetCapabilities(),
+	}
+	if !hasGPU {
+		for i, c := range capabilities.Collectors {
+			if c.Collector == CollectorGPU && c.Status != CapabilityDisabled {
+				capabilities.Collectors[i].Status = CapabilityDisabled
+				capabilities.Collectors[i].Reason = ErrNoGPU.Error()
+			}
+		}
+	}
+	return capabilities
+}
@@ -0,0 +1,130 @@
+package gtm
+
+import (
+	"errors"
+	"github.com/shirou/gopsutil/v4/process"
+	"sort"
+	"time"
+)
+
+// ProcessDetail is everything known about a single process, like the detail view of
+// htop. It is read on every call instead of cached, since it's only asked for one
+// process at a time. OpenFiles is -1 when it can't be read (ie. processes of other users
+// without root/admin), and it's the handle count on Windows
+type ProcessDetail struct {
+	PID       int32     `json:"pid"`
+	PPID      int32     `json:"ppid"`
+	Name      string    `json:"name"`
+	Cmdline   string    `json:"cmdline"`
+	Cwd       string    `json:"cwd"`
+	Exe       string    `json:"exe"`
+	User      string    `json:"user"`
+	StartTime time.Time `json:"start_time"`
+	// Cumulative CPU time since the process started
+	CPUUserSeconds   float64 `json:"cpu_user_seconds"`
+	CPUSystemSeconds float64 `json:"cpu_system_seconds"`
+	OpenFiles        int32   `json:"open_files"`
+	Connections      int     `json:"connections"`
+	// MemoryMaps is nil where memory maps can't be read (everything but linux)
+	MemoryMaps *MemoryMapsSummary `json:"memory_maps,omitempty"`
+}
+
+// MemoryMapsSummary sums up the memory mappings of a process, in bytes
+type MemoryMapsSummary struct {
+	Mappings int    `json:"mappings"`
+	Size     uint64 `json:"size"`
+	RSS      uint64 `json:"rss"`
+	PSS      uint64 `json:"pss"`
+	Private  uint64 `json:"private"`
+	Shared   uint64 `json:"shared"`
+	Swap     uint64 `json:"swap"`
+}
+
+// GetProcessDetail returns the detail of a single process. It fails with a ProcessError
+// wrapping ErrProcessNotFound when the process doesn't exist. Fields that can't be read
+// (ie. the cwd of processes of other users) are left empty
+func GetProcessDetail(pid int32) (ProcessDetail, error) {
+	p, err := process.NewProcess(pid)
+	if errors.Is(err, process.ErrorProcessNotRunning) {
+		return ProcessDetail{}, &ProcessError{PID: pid, Op: "read", Err: ErrProcessNotFound}
+	} else if err != nil {
+		return ProcessDetail{}, &ProcessError{PID: pid, Op: "read", Err: err}
+	}
+	name, err := p.Name()
+	if err != nil {
+		return ProcessDetail{}, &ProcessError{PID: pid, Op: "read", Err: err}
+	}
+
+	detail := ProcessDetail{PID: pid, Name: name, User: processUser(p), OpenFiles: -1}
+	detail.PPID, _ = p.Ppid()
+	detail.Cmdline, _ = p.Cmdline()
+	detail.Cwd, _ = p.Cwd()
+	detail.Exe, _ = p.Exe()
+	if createTime, err := p.CreateTime(); err == nil {
+		detail.StartTime = time.UnixMilli(createTime)
+	}
+	if times, err := p.Times(); err == nil {
+		detail.CPUUserSeconds = RoundStat(times.User)
+		detail.CPUSystemSeconds = RoundStat(times.System)
+	}
+	if fds, err := p.NumFDs(); err == nil {
+		detail.OpenFiles = fds
+	}
+	if conns, err := GetProcessConnections(pid); err == nil {
+		detail.Connections = len(conns)
+	}
+	detail.MemoryMaps = getMemoryMapsSummary(p)
+	return detail, nil
+}
+
+// OpenFile is a file a process has open, like a line of `lsof`. FD is the handle value
+// on Windows
+type OpenFile struct {
+	FD   uint64 `json:"fd"`
+	Path string `json:"path"`
+}
+
+// GetProcessOpenFiles returns the files a process has open, sorted by FD. Sockets, pipes
+// and the like are left out; see GetProcessConnections for sockets. The open files of
+// other users' processes usually need root/admin
+func GetProcessOpenFiles(pid int32) ([]OpenFile, error) {
+	p, err := process.NewProcess(pid)
+	if errors.Is(err, process.ErrorProcessNotRunning) {
+		return nil, &ProcessError{PID: pid, Op: "list files of", Err: ErrProcessNotFound}
+	} else if err != nil {
+		return nil, &ProcessError{PID: pid, Op: "list files of", Err: err}
+	}
+	files, err := getOpenFiles(p)
+	if err != nil {
+		return nil, &ProcessError{PID: pid, Op: "list files of", Err: err}
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].FD < files[j].FD })
+	return files, nil
+}
+
+// GetProcessConnections returns the TCP and UDP sockets of a process. The connection
+// table is cached, so this is cheaper than reading the sockets of a single process
+func GetProcessConnections(pid int32) ([]Connection, error) {
+	conns, err := getAllConnections()
+	if err != nil {
+		return nil, err
+	}
+	result := []Connection{}
+	for _, conn := range conns {
+		if conn.PID == pid {
+			result = append(result, conn)
+		}
+	}
+	return result, nil
+}
+
+func convertOpenFiles(files []process.OpenFilesStat, err error) ([]OpenFile, error) {
+	if err != nil {
+		return nil, err
+	}
+	result := make([]OpenFile, 0, len(files))
+	for _, f := range files {
+		result = append(result, OpenFile{FD: f.Fd, Path: f.Path})
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"github.com/shirou/gopsutil/v4/process"
+	"strconv"
+	"strings"
+)
+
+func getMemoryMapsSummary(p *process.Process) *MemoryMapsSummary {
+	return nil
+}
+
+// getOpenFiles runs lsof, since gopsutil can't list open files on macOS
+func getOpenFiles(p *process.Process) ([]OpenFile, error) {
+	out, err := runCommand("lsof", "-n", "-P", "-p", strconv.Itoa(int(p.Pid)), "-F", "ftn")
+	if err != nil && len(out) == 0 {
+		return nil, err
+	}
+	return parseLsof(out), nil
+}
+
+// parseLsof parses the field output of `lsof -F ftn`: a line per field, starting with
+// the field letter. Every file starts with an f (fd) line, followed by its t (type) and
+// n (name) lines
+func parseLsof(out []byte) []OpenFile {
+	files := []OpenFile{}
+	var file OpenFile
+	valid := false
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		switch value := line[1:]; line[0] {
+		case 'f':
+			// cwd, txt, mem and the like aren't fds
+			fd, err := strconv.ParseUint(value, 10, 64)
+			file, valid = OpenFile{FD: fd}, err == nil
+		case 't':
+			valid = valid && (value == "REG" || value == "DIR")
+		case 'n':
+			if valid && !strings.HasPrefix(value, "->") {
+				file.Path = value
+				files = append(files, file)
+			}
+			valid = false
+		}
+	}
+	return files
+}
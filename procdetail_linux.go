@@ -0,0 +1,33 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/process"
+	"slices"
+	"strings"
+)
+
+// getMemoryMapsSummary adds up every mapping in /proc/<pid>/smaps, which reports kB
+func getMemoryMapsSummary(p *process.Process) *MemoryMapsSummary {
+	maps, err := p.MemoryMaps(false)
+	if err != nil || maps == nil {
+		return nil
+	}
+	summary := &MemoryMapsSummary{Mappings: len(*maps)}
+	for _, m := range *maps {
+		summary.Size += m.Size * 1024
+		summary.RSS += m.Rss * 1024
+		summary.PSS += m.Pss * 1024
+		summary.Private += (m.PrivateClean + m.PrivateDirty) * 1024
+		summary.Shared += (m.SharedClean + m.SharedDirty) * 1024
+		summary.Swap += m.Swap * 1024
+	}
+	return summary
+}
+
+func getOpenFiles(p *process.Process) ([]OpenFile, error) {
+	files, err := convertOpenFiles(p.OpenFiles())
+	// sockets, pipes and anon inodes are links like "socket:[1234]" instead of paths
+	return slices.DeleteFunc(files, func(f OpenFile) bool {
+		return !strings.HasPrefix(f.Path, "/")
+	}), err
+}
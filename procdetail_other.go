@@ -0,0 +1,13 @@
+//go:build !linux && !darwin
+
+package gtm
+
+import "github.com/shirou/gopsutil/v4/process"
+
+func getMemoryMapsSummary(p *process.Process) *MemoryMapsSummary {
+	return nil
+}
+
+func getOpenFiles(p *process.Process) ([]OpenFile, error) {
+	return convertOpenFiles(p.OpenFiles())
+}
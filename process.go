@@ -0,0 +1,398 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/process"
+	"iter"
+	"os/user"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcStats is a single process, like a row of `top`. CPUPercent is relative to a
+// single core like in top and htop, so a process busy on 4 cores is at 400%. It and the
+// IO rates are 0 on the first fetch a process is seen in, since they are computed
+// between two fetches. User is empty when the owner can't be read (ie. other users'
+// processes on Windows without admin), and so are the IO counters of processes of other
+// users without root/admin
+type ProcStats struct {
+	PID           int32   `json:"pid"`
+	PPID          int32   `json:"ppid"`
+	Name          string  `json:"name"`
+	User          string  `json:"user"`
+	CPUPercent    float64 `json:"cpu_percent"`
+	RSS           uint64  `json:"rss"`
+	MemoryPercent float64 `json:"memory_percent"`
+	State         string  `json:"state"` // one of the ProcState* constants
+	Threads       int32   `json:"threads"`
+	// Nice is the priority from -20 (highest) to 19 (lowest). On Windows it's mapped
+	// from the priority class, see SetProcessPriority
+	Nice int32 `json:"nice"`
+	// Handles is the number of open handles, only on Windows
+	Handles int32 `json:"handles,omitempty"`
+	// ContainerID is the container (the name for LXC) the process runs in, empty for
+	// host processes. Only linux is supported
+	ContainerID      string `json:"container_id,omitempty"`
+	ContainerRuntime string `json:"container_runtime,omitempty"`
+	// Service is the systemd unit (without .service), Windows service or launchd job
+	// the process belongs to. Every service of a shared svchost.exe is listed, joined by
+	// commas
+	Service string `json:"service,omitempty"`
+	// GPUMemory is the GPU memory the process uses, only for NVIDIA compute processes
+	GPUMemory uint64 `json:"gpu_memory,omitempty"`
+	// Read and written bytes since the process started and per second between two
+	// fetches, including page cache hits on Windows and macOS
+	ReadBytes        uint64  `json:"read_bytes"`
+	WriteBytes       uint64  `json:"write_bytes"`
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec"`
+}
+
+// States of ProcStats.State. ProcStateBlocked is uninterruptible sleep (D-state on
+// linux), usually a process waiting on stuck I/O
+const (
+	ProcStateRunning  = process.Running
+	ProcStateSleeping = process.Sleep
+	ProcStateIdle     = process.Idle
+	ProcStateBlocked  = process.Blocked
+	ProcStateStopped  = process.Stop
+	ProcStateZombie   = process.Zombie
+	ProcStateUnknown  = process.UnknownState
+)
+
+// ProcessCounts is the number of processes in each state. A growing number of zombies
+// points to a parent that doesn't reap its children, and blocked processes to stuck
+// I/O (ie. a hung NFS mount or a failing disk). Processes in any other state are only
+// counted in Total
+type ProcessCounts struct {
+	Total    int `json:"total"`
+	Running  int `json:"running"`
+	Sleeping int `json:"sleeping"`
+	Blocked  int `json:"blocked"`
+	Stopped  int `json:"stopped"`
+	Zombie   int `json:"zombie"`
+}
+
+// procSample is the CPU time and IO a process used up to a fetch. CreateTime tells a
+// reused PID apart from the process that had it before
+type procSample struct {
+	CPUSeconds float64
+	ReadBytes  uint64
+	WriteBytes uint64
+	CreateTime int64
+}
+
+var (
+	procStats      []ProcStats
+	procSamples    map[int32]procSample
+	lastSampleProc time.Time
+	procUsernames  = map[string]string{}
+	procCgroups    = map[int32]processCgroup{}
+
+	procUsernameMut sync.Mutex
+)
+
+// GetProcesses returns every process, sorted by PID. Processes that exit while they
+// are read are skipped
+func GetProcesses() ([]ProcStats, error) {
+	procMut.Lock()
+	defer procMut.Unlock()
+
+	if GetClock().Since(lastFetchProc) < PROCS_UPDATE_INTERVAL && procStats != nil {
+		return procStats, nil
+	}
+	if !IsCollectorEnabled(CollectorProcesses) {
+		return nil, ErrCollectorDisabled
+	}
+
+	procs, err := process.Processes()
+	lastFetchProc = GetClock().Now()
+	if err != nil {
+		collectorError(CollectorProcesses, "Failed to retrieve process.Processes()!", err)
+		return procStats, err
+	}
+	sampleTime := GetClock().Now()
+	elapsed := sampleSeconds(lastSampleProc, sampleTime)
+
+	var totalMemory uint64
+	if memStats, _ := GetMemoryStats(); memStats != nil {
+		totalMemory = memStats.Total
+	}
+
+	var gpuMemory map[int32]uint64
+	if hasGPU() {
+		gpuMemory = defaultMonitor.gpuProcessMemory()
+	}
+
+	result := make([]ProcStats, 0, len(procs))
+	samples := make(map[int32]procSample, len(procs))
+	cgroups := make(map[int32]processCgroup, len(procs))
+	services := getServicePIDs()
+	for _, p := range procs {
+		name, err := p.Name()
+		if err != nil {
+			// the process exited, or it's a kernel/system process that can't be read
+			continue
+		}
+		stat := ProcStats{PID: p.Pid, Name: name, User: processUser(p)}
+		stat.PPID, _ = p.Ppid()
+		stat.Threads, _ = p.NumThreads()
+		stat.Handles = processHandles(p)
+		stat.Nice = processNice(p)
+		createTime, _ := p.CreateTime()
+		cgroup, ok := procCgroups[p.Pid]
+		if !ok || cgroup.CreateTime != createTime {
+			cgroup = readProcessCgroup(p.Pid)
+			cgroup.CreateTime = createTime
+		}
+		cgroups[p.Pid] = cgroup
+		stat.ContainerID, stat.ContainerRuntime = cgroup.ContainerID, cgroup.ContainerRuntime
+		stat.Service = cgroup.Service
+		if service, ok := services[p.Pid]; ok {
+			stat.Service = service
+		}
+		stat.GPUMemory = gpuMemory[p.Pid]
+		if status, err := p.Status(); err == nil && len(status) > 0 {
+			stat.State = status[0]
+		}
+		if memInfo, err := p.MemoryInfo(); err == nil {
+			stat.RSS = memInfo.RSS
+			if totalMemory > 0 {
+				stat.MemoryPercent = RoundStat(float64(memInfo.RSS) / float64(totalMemory) *
+					100)
+			}
+		}
+		if times, err := p.Times(); err == nil {
+			sample := procSample{CPUSeconds: times.User + times.System}
+			sample.CreateTime, _ = p.CreateTime()
+			if io, err := p.IOCounters(); err == nil {
+				sample.ReadBytes, sample.WriteBytes = io.ReadBytes, io.WriteBytes
+				stat.ReadBytes, stat.WriteBytes = io.ReadBytes, io.WriteBytes
+			}
+			previous, ok := procSamples[p.Pid]
+			if ok && previous.CreateTime == sample.CreateTime && elapsed > 0 {
+				if sample.CPUSeconds >= previous.CPUSeconds {
+					stat.CPUPercent = RoundStat((sample.CPUSeconds - previous.CPUSeconds) /
+						elapsed * 100)
+				}
+				stat.ReadBytesPerSec = RoundStat(ratePerSecond(previous.ReadBytes,
+					sample.ReadBytes, elapsed))
+				stat.WriteBytesPerSec = RoundStat(ratePerSecond(previous.WriteBytes,
+					sample.WriteBytes, elapsed))
+			}
+			samples[p.Pid] = sample
+		}
+		result = append(result, stat)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].PID < result[j].PID })
+
+	if procStats != nil {
+		publishProcessEvents(procStats, procSamples, result, samples)
+	}
+	updateProcessWatches(result, samples)
+	procStats = result
+	procSamples = samples
+	procCgroups = cgroups
+	procTopCache = map[ProcessSort][]ProcStats{}
+	lastSampleProc = sampleTime
+	return procStats, nil
+}
+
+// resetProcesses makes the next GetProcesses read the processes again
+func resetProcesses() {
+	procMut.Lock()
+	lastFetchProc = time.Time{}
+	procMut.Unlock()
+}
+
+// ProcessFilter narrows down the processes returned by FindProcesses. Every set field
+// must match; the zero ProcessFilter matches every process
+type ProcessFilter struct {
+	// Name matches names containing it, ignoring case
+	Name string
+	// NameRegexp matches names it matches, ie. regexp.MustCompile("^(chrome|firefox)")
+	NameRegexp *regexp.Regexp
+	// Users match owners exactly (ignoring case, since Windows names are)
+	Users []string
+	// States match ProcStats.State, ie. "running", "sleep" or "zombie"
+	States           []string
+	MinCPUPercent    float64
+	MinMemoryPercent float64
+}
+
+// FindProcesses returns the processes that match the filter, sorted by PID. Filtering
+// happens on the cached process list, so pollers don't need to copy and scan all of it
+func FindProcesses(filter ProcessFilter) ([]ProcStats, error) {
+	procs, err := GetProcesses()
+	if err != nil && procs == nil {
+		return nil, err
+	}
+	result := []ProcStats{}
+	for _, proc := range procs {
+		if filter.Match(proc) {
+			result = append(result, proc)
+		}
+	}
+	return result, err
+}
+
+// Processes returns an iterator over the processes that match the filter, sorted by PID.
+// Unlike FindProcesses, it walks the cached process list without copying it, so polling
+// thousands of processes every second doesn't allocate a new slice. The processes are
+// fetched when the iteration starts; fetch errors are only logged, use GetProcesses to
+// handle them
+func Processes(filter ProcessFilter) iter.Seq[ProcStats] {
+	return func(yield func(ProcStats) bool) {
+		procs, _ := GetProcesses()
+		for _, proc := range procs {
+			if filter.Match(proc) && !yield(proc) {
+				return
+			}
+		}
+	}
+}
+
+// Match returns true when the process passes the filter
+func (f ProcessFilter) Match(proc ProcStats) bool {
+	if f.Name != "" && !strings.Contains(strings.ToLower(proc.Name), strings.ToLower(f.Name)) {
+		return false
+	}
+	if f.NameRegexp != nil && !f.NameRegexp.MatchString(proc.Name) {
+		return false
+	}
+	if len(f.Users) > 0 && !slices.ContainsFunc(f.Users, func(u string) bool {
+		return strings.EqualFold(u, proc.User)
+	}) {
+		return false
+	}
+	if len(f.States) > 0 && !slices.ContainsFunc(f.States, func(s string) bool {
+		return strings.EqualFold(s, proc.State)
+	}) {
+		return false
+	}
+	return proc.CPUPercent >= f.MinCPUPercent && proc.MemoryPercent >= f.MinMemoryPercent
+}
+
+// GetProcessCounts counts the processes of the last fetch by state
+func GetProcessCounts() (ProcessCounts, error) {
+	procs, err := GetProcesses()
+	if err != nil && procs == nil {
+		return ProcessCounts{}, err
+	}
+	counts := ProcessCounts{Total: len(procs)}
+	for _, proc := range procs {
+		switch proc.State {
+		case ProcStateRunning:
+			counts.Running++
+		case ProcStateSleeping, ProcStateIdle:
+			counts.Sleeping++
+		case ProcStateBlocked:
+			counts.Blocked++
+		case ProcStateStopped:
+			counts.Stopped++
+		case ProcStateZombie:
+			counts.Zombie++
+		}
+	}
+	return counts, err
+}
+
+// processUser returns the name of the owner of a process. Names are looked up once per
+// uid, since looking them up (ie. parsing /etc/passwd) is slow
+func processUser(p *process.Process) string {
+	uids, err := p.Uids()
+	if err != nil || len(uids) == 0 {
+		// Windows has no uids, the owner comes from the process token instead
+		username, _ := p.Username()
+		return username
+	}
+	// the effective uid (the second one on unix) is the user shown by top
+	uid := strconv.FormatInt(int64(uids[min(1, len(uids)-1)]), 10)
+	procUsernameMut.Lock()
+	defer procUsernameMut.Unlock()
+	if username, ok := procUsernames[uid]; ok {
+		return username
+	}
+	username := uid
+	if u, err := user.LookupId(uid); err == nil {
+		username = u.Username
+	}
+	procUsernames[uid] = username
+	return username
+}
+
+// ProcessSort is the order of GetTopProcesses
+type ProcessSort string
+
+const (
+	SortByCPU       ProcessSort = "cpu"
+	SortByMemory    ProcessSort = "memory"
+	SortByIO        ProcessSort = "io"
+	SortByName      ProcessSort = "name"
+	SortByGPUMemory ProcessSort = "gpu_memory"
+	// SortByThreads puts thread (and handle) leaks on top
+	SortByThreads ProcessSort = "threads"
+)
+
+// procTopCache holds the processes of the last fetch sorted by every order asked for,
+// so polling GetTopProcesses every frame only sorts once per fetch
+var procTopCache = map[ProcessSort][]ProcStats{}
+
+// GetTopProcesses returns the first `n` processes (all of them when n <= 0) in the given
+// order: the busiest first for CPU, memory (RSS), IO (read + write rate), GPU memory and
+// threads, and alphabetically for name. Ties are ordered by PID
+func GetTopProcesses(n int, sortBy ProcessSort) ([]ProcStats, error) {
+	procs, err := GetProcesses()
+	if err != nil && procs == nil {
+		return nil, err
+	}
+
+	procMut.Lock()
+	defer procMut.Unlock()
+	// procTopCache is cleared by fetches, so sort the latest fetch even when another
+	// goroutine fetched since GetProcesses returned
+	procs = procStats
+	sorted, ok := procTopCache[sortBy]
+	if !ok {
+		sorted = append([]ProcStats{}, procs...)
+		less := processLess(sortBy)
+		sort.SliceStable(sorted, func(i, j int) bool { return less(sorted[i], sorted[j]) })
+		procTopCache[sortBy] = sorted
+	}
+	if n <= 0 || n > len(sorted) {
+		n = len(sorted)
+	}
+	return append([]ProcStats{}, sorted[:n]...), err
+}
+
+// processLess returns the comparison of an order. procs are sorted by PID already, so
+// a stable sort keeps ties in PID order
+func processLess(sortBy ProcessSort) func(a, b ProcStats) bool {
+	switch sortBy {
+	case SortByMemory:
+		return func(a, b ProcStats) bool { return a.RSS > b.RSS }
+	case SortByIO:
+		return func(a, b ProcStats) bool {
+			return a.ReadBytesPerSec+a.WriteBytesPerSec > b.ReadBytesPerSec+b.WriteBytesPerSec
+		}
+	case SortByGPUMemory:
+		return func(a, b ProcStats) bool { return a.GPUMemory > b.GPUMemory }
+	case SortByThreads:
+		return func(a, b ProcStats) bool {
+			if a.Threads != b.Threads {
+				return a.Threads > b.Threads
+			}
+			return a.Handles > b.Handles
+		}
+	case SortByName:
+		return func(a, b ProcStats) bool {
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		}
+	default:
+		return func(a, b ProcStats) bool { return a.CPUPercent > b.CPUPercent }
+	}
+}
@@ -0,0 +1,18 @@
+package gtm
+
+import "github.com/shirou/gopsutil/v4/process"
+
+// processHandles is 0 on linux, since counting open fds means reading /proc/<pid>/fd of
+// every process
+func processHandles(p *process.Process) int32 {
+	return 0
+}
+
+func processNice(p *process.Process) int32 {
+	priority, err := p.Nice()
+	if err != nil {
+		return 0
+	}
+	// gopsutil returns the raw getpriority syscall on linux, which is 20 - nice
+	return 20 - priority
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !windows
+
+package gtm
+
+import "github.com/shirou/gopsutil/v4/process"
+
+// processHandles is 0 outside of Windows, since counting open fds means reading the fd
+// table of every process
+func processHandles(p *process.Process) int32 {
+	return 0
+}
+
+func processNice(p *process.Process) int32 {
+	nice, _ := p.Nice()
+	return nice
+}
@@ -0,0 +1,28 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/process"
+	"golang.org/x/sys/windows"
+)
+
+// processHandles returns the handle count of a process, or 0 when it can't be opened
+func processHandles(p *process.Process) int32 {
+	// NumFDs is GetProcessHandleCount on Windows
+	handles, _ := p.NumFDs()
+	return handles
+}
+
+// processNice maps the priority class of a process to a nice value, since gopsutil
+// returns the base priority (4 to 24) instead
+func processNice(p *process.Process) int32 {
+	handle, err := openProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, p.Pid)
+	if err != nil {
+		return 0
+	}
+	defer windows.CloseHandle(handle)
+	class, err := windows.GetPriorityClass(handle)
+	if err != nil {
+		return 0
+	}
+	return priorityClassNice[class]
+}
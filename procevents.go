@@ -0,0 +1,109 @@
+package gtm
+
+import (
+	"context"
+	"errors"
+	"github.com/shirou/gopsutil/v4/process"
+	"time"
+)
+
+// ProcessChange tells whether a process started or exited
+type ProcessChange string
+
+const (
+	ProcessStarted ProcessChange = "started"
+	ProcessExited  ProcessChange = "exited"
+)
+
+// ProcessEvent is published when a fetch of the process list finds a new process, or
+// misses a known one. Processes that start and exit between two fetches are never seen
+type ProcessEvent struct {
+	Timestamp time.Time     `json:"timestamp"`
+	Change    ProcessChange `json:"change"`
+	PID       int32         `json:"pid"`
+	PPID      int32         `json:"ppid"`
+	Name      string        `json:"name"`
+	User      string        `json:"user"`
+}
+
+var processEvents = NewBroadcaster[ProcessEvent]()
+
+// WatchProcessEvents subscribes to process-started and process-exited events. Events
+// are found by comparing the process lists of two fetches, so they're only published
+// while something fetches processes (ie. the UI, or a GetProcesses poller). Call the
+// returned function to unsubscribe
+func WatchProcessEvents() (*Subscriber[ProcessEvent], func()) {
+	return processEvents.Subscribe(0, 0)
+}
+
+// publishProcessEvents compares the process list of two fetches. A PID that now
+// belongs to another process (a different create time) is an exit and a start. Names
+// are only compared when the create time can't be read, since kernel threads rename
+// themselves and an exec renames a process without starting a new one
+func publishProcessEvents(previous []ProcStats, previousSamples map[int32]procSample,
+	current []ProcStats, currentSamples map[int32]procSample) {
+	known := make(map[int32]ProcStats, len(previous))
+	for _, proc := range previous {
+		known[proc.PID] = proc
+	}
+	now := GetClock().Now()
+	var events []ProcessEvent
+	for _, proc := range current {
+		if old, ok := known[proc.PID]; ok {
+			oldCreateTime := previousSamples[proc.PID].CreateTime
+			createTime := currentSamples[proc.PID].CreateTime
+			if oldCreateTime == createTime && (createTime != 0 || old.Name == proc.Name) {
+				delete(known, proc.PID)
+				continue
+			}
+		}
+		events = append(events, newProcessEvent(now, ProcessStarted, proc))
+	}
+	for _, proc := range previous {
+		if _, exited := known[proc.PID]; exited {
+			events = append(events, newProcessEvent(now, ProcessExited, proc))
+		}
+	}
+	for _, event := range events {
+		processEvents.Publish(event)
+	}
+}
+
+func newProcessEvent(now time.Time, change ProcessChange, proc ProcStats) ProcessEvent {
+	return ProcessEvent{Timestamp: now, Change: change, PID: proc.PID, PPID: proc.PPID,
+		Name: proc.Name, User: proc.User}
+}
+
+// WaitProcessExit blocks until a process exits, checking every PROCS_UPDATE_INTERVAL,
+// ie. to notify when a long running job finishes. It returns right away when the
+// process doesn't exist, and the context error when `ctx` is done first. Unlike
+// WatchProcessEvents it doesn't need anything to fetch processes
+func WaitProcessExit(ctx context.Context, pid int32) error {
+	p, err := process.NewProcessWithContext(ctx, pid)
+	if errors.Is(err, process.ErrorProcessNotRunning) {
+		return nil
+	} else if err != nil {
+		return &ProcessError{PID: pid, Op: "wait for", Err: err}
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-GetClock().After(PROCS_UPDATE_INTERVAL):
+		}
+		// IsRunning compares create times, so a reused PID doesn't count as running
+		running, err := p.IsRunningWithContext(ctx)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil || !running {
+			// the process is gone once it can't be read anymore
+			return nil
+		}
+		// a zombie has finished, it only waits for its parent to reap it
+		if status, err := p.StatusWithContext(ctx); err == nil && len(status) > 0 &&
+			status[0] == ProcStateZombie {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package gtm
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+// ProcessGroupStats is what a ProcessWatch tracked since it started. CPUSeconds only
+// counts the CPU time used while watched, and PeakRSS is the highest RSS of all the
+// processes together. A restart is a start that replaces an exited process, so a crash
+// loop shows up as a growing Restarts
+type ProcessGroupStats struct {
+	Patterns   []string  `json:"patterns"`
+	Since      time.Time `json:"since"`
+	PIDs       []int32   `json:"pids"`
+	CPUPercent float64   `json:"cpu_percent"`
+	CPUSeconds float64   `json:"cpu_seconds"`
+	RSS        uint64    `json:"rss"`
+	PeakRSS    uint64    `json:"peak_rss"`
+	Starts     int       `json:"starts"`
+	Exits      int       `json:"exits"`
+	Restarts   int       `json:"restarts"`
+	LastStart  time.Time `json:"last_start"`
+	LastExit   time.Time `json:"last_exit"`
+}
+
+// ProcessWatch tracks a named set of processes across PID changes, see WatchProcesses
+type ProcessWatch struct {
+	filter  NameFilter
+	stats   ProcessGroupStats
+	known   map[int32]procSample
+	updated bool
+	mut     sync.Mutex
+}
+
+var (
+	processWatches    = map[*ProcessWatch]struct{}{}
+	processWatchesMut sync.Mutex
+)
+
+// WatchProcesses starts tracking the processes whose name matches any of `patterns`
+// (globs like in NET_EXCLUDE, ie. "postgres*"). Like WatchProcessEvents, it's updated
+// by every fetch of the process list, so something must keep fetching processes. Call
+// Stop when done
+func WatchProcesses(patterns ...string) *ProcessWatch {
+	watch := &ProcessWatch{
+		filter: NewNameFilter(slices.Clone(patterns), nil),
+		stats: ProcessGroupStats{Patterns: slices.Clone(patterns), Since: GetClock().Now(),
+			PIDs: []int32{}},
+		known: map[int32]procSample{},
+	}
+	processWatchesMut.Lock()
+	processWatches[watch] = struct{}{}
+	processWatchesMut.Unlock()
+	return watch
+}
+
+// Stats returns what the watch tracked so far
+func (w *ProcessWatch) Stats() ProcessGroupStats {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+	stats := w.stats
+	stats.Patterns = slices.Clone(stats.Patterns)
+	stats.PIDs = slices.Clone(stats.PIDs)
+	stats.CPUPercent = RoundStat(stats.CPUPercent)
+	stats.CPUSeconds = RoundStat(stats.CPUSeconds)
+	return stats
+}
+
+// Stop stops updating the watch. Its stats can still be read
+func (w *ProcessWatch) Stop() {
+	processWatchesMut.Lock()
+	delete(processWatches, w)
+	processWatchesMut.Unlock()
+}
+
+// updateProcessWatches updates every watch with a new fetch of the process list
+func updateProcessWatches(procs []ProcStats, samples map[int32]procSample) {
+	processWatchesMut.Lock()
+	defer processWatchesMut.Unlock()
+	for watch := range processWatches {
+		watch.update(procs, samples)
+	}
+}
+
+func (w *ProcessWatch) update(procs []ProcStats, samples map[int32]procSample) {
+	w.mut.Lock()
+	defer w.mut.Unlock()
+
+	now := GetClock().Now()
+	known := map[int32]procSample{}
+	var started int
+	w.stats.PIDs = []int32{}
+	w.stats.CPUPercent, w.stats.RSS = 0, 0
+	for _, proc := range procs {
+		if !w.filter.Match(proc.Name) {
+			continue
+		}
+		sample := samples[proc.PID]
+		previous, seen := w.known[proc.PID]
+		switch {
+		case seen && previous.CreateTime == sample.CreateTime:
+			w.stats.CPUSeconds += max(sample.CPUSeconds-previous.CPUSeconds, 0)
+		case w.updated:
+			// started while watched, so all of its CPU time was used while watched
+			started++
+			w.stats.CPUSeconds += sample.CPUSeconds
+		}
+		known[proc.PID] = sample
+		w.stats.PIDs = append(w.stats.PIDs, proc.PID)
+		w.stats.CPUPercent += proc.CPUPercent
+		w.stats.RSS += proc.RSS
+	}
+	for pid, previous := range w.known {
+		if sample, ok := known[pid]; !ok || sample.CreateTime != previous.CreateTime {
+			w.stats.Exits++
+			w.stats.LastExit = now
+		}
+	}
+	// exits are counted first, so an exit and its replacement in the same fetch is a
+	// restart
+	for range started {
+		w.stats.Starts++
+		w.stats.LastStart = now
+		if w.stats.Restarts < w.stats.Exits {
+			w.stats.Restarts++
+		}
+	}
+	w.stats.PeakRSS = max(w.stats.PeakRSS, w.stats.RSS)
+	w.known = known
+	w.updated = true
+}
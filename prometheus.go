@@ -0,0 +1,112 @@
+package gtm
+
+import (
+	"bufio"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// PROMETHEUS_PATH is where the Prometheus exporter serves the metrics
+	PROMETHEUS_PATH = "/metrics"
+	// PROMETHEUS_NAMESPACE prefixes the name of every exported metric
+	PROMETHEUS_NAMESPACE = "gtm"
+)
+
+// prometheusContentType is the version of the text exposition format written
+const prometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// PrometheusName converts the name of a metric of the registry to a Prometheus metric
+// name, ie. "gpu.temperature" (in celsius) to "gtm_gpu_temperature_celsius". The unit
+// is appended unless the name already has it, and counters end with "_total"
+func PrometheusName(desc MetricDescriptor) string {
+	name := PROMETHEUS_NAMESPACE + "_" + desc.Name
+	// "bytes_per_second" is spelled out as "bytes_per_sec" in the names of the rates
+	if unit, _, _ := strings.Cut(desc.Unit, "_"); unit != "" &&
+		!strings.Contains(desc.Name, unit) {
+		name += "_" + desc.Unit
+	}
+	if desc.Type == MetricCounter && !strings.HasSuffix(name, "_total") {
+		name += "_total"
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '_' || r == ':' {
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// PrometheusHandler serves the current value of every metric of the Monitor (see
+// Samples) in the Prometheus text format. Per-device metrics are labeled with the
+// device, ie. gtm_disk_used_percent{mountpoint="/"} or gtm_cpu_usage_percent{core="0"},
+// and with its alias when it has one, ie.
+// gtm_disk_used_percent{mountpoint="/mnt/data",alias="Backups"}
+func (m *Monitor) PrometheusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		descs := map[string]MetricDescriptor{}
+		for _, desc := range m.Metrics() {
+			descs[desc.Name] = desc
+		}
+
+		w.Header().Set("Content-Type", prometheusContentType)
+		out := bufio.NewWriter(w)
+		var previous string
+		for _, sample := range m.Samples() {
+			desc, ok := descs[sample.Name]
+			if !ok {
+				desc = MetricDescriptor{Name: sample.Name, Type: MetricGauge}
+			}
+			name := PrometheusName(desc)
+			// samples are sorted by name, so every metric is described once
+			if sample.Name != previous {
+				previous = sample.Name
+				if desc.Help != "" {
+					out.WriteString("# HELP " + name + " " +
+						escapePrometheusHelp(desc.Help) + "\n")
+				}
+				out.WriteString("# TYPE " + name + " " + string(desc.Type) + "\n")
+			}
+			out.WriteString(name)
+			if label := sample.LabelValue(desc); label != "" {
+				out.WriteString("{" + desc.Label + "=\"" +
+					escapePrometheusLabel(label) + "\"")
+				if sample.Alias != "" {
+					out.WriteString(",alias=\"" + escapePrometheusLabel(sample.Alias) +
+						"\"")
+				}
+				out.WriteString("}")
+			}
+			out.WriteString(" " + strconv.FormatFloat(sample.Value, 'g', -1, 64) + "\n")
+		}
+		if err := out.Flush(); err != nil {
+			m.log().Debug("Failed to write the Prometheus metrics! " + err.Error())
+		}
+	})
+}
+
+// PrometheusHandler serves the metrics of the default Monitor, see
+// Monitor.PrometheusHandler
+func PrometheusHandler() http.Handler { return defaultMonitor.PrometheusHandler() }
+
+// StartPrometheusServer serves the metrics of the default Monitor for Prometheus on
+// `addr` (at PROMETHEUS_PATH) until the server fails, or returns nil once the default
+// Monitor is shut down
+func StartPrometheusServer(addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle(PROMETHEUS_PATH, PrometheusHandler())
+	logger().Info("Serving Prometheus metrics on http://" + addr + PROMETHEUS_PATH)
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	return defaultMonitor.serve(server)
+}
+
+func escapePrometheusHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+func escapePrometheusLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(value)
+}
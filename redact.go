@@ -0,0 +1,206 @@
+package gtm
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// REDACTED replaces every value removed by a RedactionProfile
+const REDACTED = "[REDACTED]"
+
+// RedactionProfile decides what is removed from exported snapshots and recordings before
+// they are written, so capture files can be attached to public bug reports
+type RedactionProfile struct {
+	Name               string `json:"name"`
+	StripCommandLines  bool   `json:"strip_command_lines"`
+	StripIPAddresses   bool   `json:"strip_ip_addresses"`
+	StripMACAddresses  bool   `json:"strip_mac_addresses"`
+	StripSerialNumbers bool   `json:"strip_serial_numbers"`
+	AnonymizeHostnames bool   `json:"anonymize_hostnames"`
+	AnonymizeUsernames bool   `json:"anonymize_usernames"`
+}
+
+var (
+	// RedactNone exports everything as-is
+	RedactNone = RedactionProfile{Name: "none"}
+	// RedactNetwork only removes addresses that identify the network gtm runs on
+	RedactNetwork = RedactionProfile{
+		Name:              "network",
+		StripIPAddresses:  true,
+		StripMACAddresses: true,
+	}
+	// RedactPublic is meant for files attached to public bug reports
+	RedactPublic = RedactionProfile{
+		Name:               "public",
+		StripCommandLines:  true,
+		StripIPAddresses:   true,
+		StripMACAddresses:  true,
+		StripSerialNumbers: true,
+		AnonymizeHostnames: true,
+		AnonymizeUsernames: true,
+	}
+)
+
+var (
+	redactionProfiles = map[string]RedactionProfile{
+		RedactNone.Name:    RedactNone,
+		RedactNetwork.Name: RedactNetwork,
+		RedactPublic.Name:  RedactPublic,
+	}
+	redactionMut sync.RWMutex
+)
+
+// JSON keys (lowercase, without "_" or "-") that are redacted by each profile option
+var (
+	redactKeysCommandLine = []string{"cmdline", "commandline", "cmd", "args", "argv"}
+	redactKeysSerial      = []string{"serial", "serialnumber", "productserial",
+		"boardserial", "hostid", "uuid", "productuuid"}
+	redactKeysHostname = []string{"hostname", "host", "fqdn"}
+	redactKeysUsername = []string{"user", "username", "owner", "uids", "gids"}
+)
+
+var (
+	regexIPv4 = regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`)
+	regexIPv6 = regexp.MustCompile(`[0-9A-Fa-f]{0,4}(?::[0-9A-Fa-f]{0,4}){2,7}`)
+	regexMAC  = regexp.MustCompile(`\b(?:[0-9A-Fa-f]{2}[:-]){5}[0-9A-Fa-f]{2}\b`)
+)
+
+// RegisterRedactionProfile adds (or replaces) a named profile
+func RegisterRedactionProfile(profile RedactionProfile) {
+	redactionMut.Lock()
+	defer redactionMut.Unlock()
+	redactionProfiles[profile.Name] = profile
+}
+
+// GetRedactionProfile looks up a profile by name
+func GetRedactionProfile(name string) (RedactionProfile, bool) {
+	redactionMut.RLock()
+	defer redactionMut.RUnlock()
+	profile, ok := redactionProfiles[name]
+	return profile, ok
+}
+
+// GetRedactionProfileNames returns the names of every registered profile, sorted
+func GetRedactionProfileNames() []string {
+	redactionMut.RLock()
+	defer redactionMut.RUnlock()
+	names := make([]string, 0, len(redactionProfiles))
+	for name := range redactionProfiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetConfiguredRedactionProfile returns the profile named by REDACTION_PROFILE in the
+// config, falling back to RedactNone for unknown names
+func GetConfiguredRedactionProfile() RedactionProfile {
+	profile, ok := GetRedactionProfile(Cfg.RedactionProfile)
+	if !ok {
+		logger().Error("Unknown redaction profile: " + Cfg.RedactionProfile + " ... using: " +
+			RedactNone.Name)
+		return RedactNone
+	}
+	return profile
+}
+
+// Redact marshals `v` to JSON, then applies the profile to the result
+func (p RedactionProfile) Redact(v any) ([]byte, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	return p.RedactJSON(data)
+}
+
+// RedactJSON applies the profile to an already marshalled JSON document. Values are
+// removed by their key name (ie. "cmdline", "serial_number"), and IP/MAC addresses are
+// removed from every string value
+func (p RedactionProfile) RedactJSON(data []byte) ([]byte, error) {
+	if p == (RedactionProfile{Name: p.Name}) {
+		// nothing to redact
+		return data, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber() // keep large integers (ie. byte counters) exact
+	var document any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, err
+	}
+	return json.Marshal(p.redactValue("", document))
+}
+
+func (p RedactionProfile) redactValue(key string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for k, child := range v {
+			v[k] = p.redactValue(k, child)
+		}
+		return v
+	case []any:
+		for i, child := range v {
+			v[i] = p.redactValue(key, child)
+		}
+		return v
+	case string:
+		return p.redactString(key, v)
+	case json.Number:
+		if p.StripSerialNumbers && matchesRedactKey(key, redactKeysSerial) {
+			return REDACTED
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func (p RedactionProfile) redactString(key string, value string) string {
+	switch {
+	case value == "":
+		return value
+	case p.StripCommandLines && matchesRedactKey(key, redactKeysCommandLine):
+		return REDACTED
+	case p.StripSerialNumbers && matchesRedactKey(key, redactKeysSerial):
+		return REDACTED
+	case p.AnonymizeHostnames && matchesRedactKey(key, redactKeysHostname):
+		return AnonymizeIdentifier("host", value)
+	case p.AnonymizeUsernames && matchesRedactKey(key, redactKeysUsername):
+		return AnonymizeIdentifier("user", value)
+	}
+
+	if p.StripMACAddresses {
+		value = regexMAC.ReplaceAllString(value, REDACTED)
+	}
+	if p.StripIPAddresses {
+		value = regexIPv4.ReplaceAllStringFunc(value, redactIP)
+		value = regexIPv6.ReplaceAllStringFunc(value, redactIP)
+	}
+	return value
+}
+
+// redactIP only replaces candidates that really are IP addresses, so timestamps like
+// "12:30:45" are left alone
+func redactIP(candidate string) string {
+	if net.ParseIP(candidate) == nil {
+		return candidate
+	}
+	return REDACTED
+}
+
+func matchesRedactKey(key string, keys []string) bool {
+	key = strings.ToLower(key)
+	key = strings.ReplaceAll(key, "_", "")
+	key = strings.ReplaceAll(key, "-", "")
+	for _, k := range keys {
+		if key == k {
+			return true
+		}
+	}
+	return false
+}
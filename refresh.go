@@ -0,0 +1,114 @@
+package gtm
+
+import (
+	"errors"
+	"time"
+)
+
+// Refresh fetches the stats of `collectors` (every collector of the Monitor when none
+// are given) right away, even when their cache is still fresh, ie. when the user pressed
+// refresh or a disk was just mounted. Subscribers are updated like on any other fetch.
+// The errors of the collectors that failed are joined
+func (m *Monitor) Refresh(collectors ...Collector) error {
+	all := m.collectors()
+	if len(collectors) == 0 {
+		for collector := range all {
+			collectors = append(collectors, collector)
+		}
+	}
+
+	var errs []error
+	for _, collector := range collectors {
+		collect, ok := all[collector]
+		if !ok {
+			errs = append(errs, errors.New("the monitor has no "+string(collector)+
+				" collector"))
+			continue
+		}
+		m.expire(collector)
+		if err := collect(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Refresh fetches stats of the default Monitor right away, see Monitor.Refresh
+func Refresh(collectors ...Collector) error {
+	return defaultMonitor.Refresh(collectors...)
+}
+
+// collectors returns the collect function of every collector of the Monitor, plugins
+// included. The GPU is only included once HasGPU found one
+func (m *Monitor) collectors() map[Collector]func() error {
+	collectors := map[Collector]func() error{
+		CollectorCPU: func() error {
+			_, err := m.CPUStats()
+			return err
+		},
+		CollectorDisk: func() error {
+			_, err := m.DisksStats()
+			return err
+		},
+		CollectorHost: func() error {
+			_, err := m.HostInfo()
+			return err
+		},
+		CollectorMemory: func() error {
+			_, err := m.MemoryStats()
+			return err
+		},
+		CollectorNetwork: func() error {
+			_, err := m.AllNetworkStats()
+			return err
+		},
+	}
+	if IsCollectorEnabled(CollectorGPU) && m.HasGPU() {
+		collectors[CollectorGPU] = func() error {
+			_, err := m.GPUStats()
+			return err
+		}
+	}
+	for _, name := range m.Plugins() {
+		collectors[Collector(name)] = func() error {
+			_, err := m.PluginMetrics(name)
+			return err
+		}
+	}
+	return collectors
+}
+
+// expire makes the next fetch of a collector read the stats again
+func (m *Monitor) expire(collector Collector) {
+	switch collector {
+	case CollectorCPU:
+		m.cpu.mut.Lock()
+		m.cpu.lastFetch = time.Time{}
+		m.cpu.mut.Unlock()
+	case CollectorDisk:
+		m.disks.expire()
+		m.allDisks.expire()
+	case CollectorGPU:
+		m.gpu.mut.Lock()
+		m.gpu.lastFetch = time.Time{}
+		m.gpu.mut.Unlock()
+	case CollectorHost:
+		m.host.mut.Lock()
+		m.host.lastFetch = time.Time{}
+		m.host.mut.Unlock()
+	case CollectorMemory:
+		m.mem.mut.Lock()
+		m.mem.lastFetch = time.Time{}
+		m.mem.mut.Unlock()
+	case CollectorNetwork:
+		m.net.mut.Lock()
+		m.net.expired = true
+		m.net.mut.Unlock()
+	default:
+		if p, ok := m.plugin(string(collector)); ok {
+			p.mut.Lock()
+			p.lastFetch = time.Time{}
+			p.mut.Unlock()
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package gtm
+
+import (
+	"cmp"
+	"github.com/shirou/gopsutil/v4/mem"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MetricSample is the current value of a metric of the registry. Name is the name of its
+// descriptor (see Metrics), ie. "gpu.temperature", and Device is the device of
+// per-device metrics, ie. "0", named by the Label of the descriptor. Alias is the alias of
+// the device in the config (see DISK_ALIASES, GPU_ALIASES and NET_ALIASES), if any
+type MetricSample struct {
+	Name   string  `json:"name"`
+	Device string  `json:"device,omitempty"`
+	Alias  string  `json:"alias,omitempty"`
+	Value  float64 `json:"value"`
+}
+
+// METRIC_LABEL_ALL is the label value of the sample of a per-device metric that covers
+// every device, ie. core="all" for the usage of all CPUs, so summing the series of a
+// metric doesn't count the devices twice
+const METRIC_LABEL_ALL = "all"
+
+// LabelValue returns the value of the Label of `desc` for the sample: its device, or
+// METRIC_LABEL_ALL for the sample of every device. It's "" for metrics without a label
+func (s MetricSample) LabelValue(desc MetricDescriptor) string {
+	if desc.Label == "" {
+		return ""
+	} else if s.Device == "" {
+		return METRIC_LABEL_ALL
+	}
+	return s.Device
+}
+
+// Samples returns the current value of every metric the Monitor collects, sorted by
+// name and device, for exporters that convert them to their own format. The collectors
+// are read concurrently through their caches, like Snapshot. Collectors that fail are
+// left out (their errors are logged already), and so are the disks, GPUs and UPSes that
+// couldn't be read. The power draw, processes, sensors, batteries, UPSes and WiFi
+// interfaces aren't per Monitor, so they're only sampled by the default Monitor
+func (m *Monitor) Samples() []MetricSample {
+	var (
+		samples []MetricSample
+		mut     sync.Mutex
+		wg      sync.WaitGroup
+	)
+	collect := func(fn func() []MetricSample) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collected := fn()
+			mut.Lock()
+			samples = append(samples, collected...)
+			mut.Unlock()
+		}()
+	}
+
+	collect(func() []MetricSample {
+		stats, err := m.CPUStats()
+		if err != nil {
+			return nil
+		}
+		return m.samplesOf(CollectorCPU, stats)
+	})
+	collect(func() []MetricSample {
+		stats, _ := m.MemoryStats()
+		return m.samplesOf(CollectorMemory, stats)
+	})
+	collect(func() []MetricSample {
+		stats, _ := m.DisksStats()
+		return m.samplesOf(CollectorDisk, stats)
+	})
+	collect(func() []MetricSample {
+		stats, err := m.AllNetworkStats()
+		if err != nil {
+			return nil
+		}
+		return m.samplesOf(CollectorNetwork, stats)
+	})
+	if m.gpu.found.Load() {
+		collect(func() []MetricSample {
+			stats, _ := m.GPUStats()
+			return m.samplesOf(CollectorGPU, stats)
+		})
+	}
+	if m.global {
+		collect(func() []MetricSample {
+			stats, err := GetPowerStats()
+			if err != nil {
+				return nil
+			}
+			return m.samplesOf(CollectorPower, stats)
+		})
+		collect(func() []MetricSample {
+			counts, err := GetProcessCounts()
+			if err != nil {
+				return nil
+			}
+			return m.samplesOf(CollectorProcesses, counts)
+		})
+		collect(func() []MetricSample {
+			stats, _ := GetSensors()
+			return m.samplesOf(CollectorSensors, stats)
+		})
+		collect(func() []MetricSample {
+			stats, _ := GetBatteryStats()
+			return m.samplesOf(CollectorBattery, stats)
+		})
+		collect(func() []MetricSample {
+			stats, _ := GetUPSStats()
+			return m.samplesOf(CollectorUPS, stats)
+		})
+		collect(func() []MetricSample {
+			stats, _ := GetWiFiStats()
+			return m.samplesOf(CollectorWiFi, stats)
+		})
+	}
+	for _, name := range m.Plugins() {
+		collect(func() []MetricSample {
+			stats, _ := m.PluginMetrics(name)
+			return m.samplesOf(Collector(name), stats)
+		})
+	}
+	wg.Wait()
+
+	slices.SortFunc(samples, func(a, b MetricSample) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Device, b.Device))
+	})
+	return samples
+}
+
+// Samples returns the samples of the default Monitor, see Monitor.Samples
+func Samples() []MetricSample { return defaultMonitor.Samples() }
+
+// pushEvery calls `push` every interval in the background, for the exporters pushing
+// the samples of the Monitor, until the returned function is called or the Monitor is
+// shut down. `done` is called once it stopped, ie. to close a connection
+func (m *Monitor) pushEvery(interval time.Duration, push func(),
+	done func()) (stop func()) {
+	stopped := make(chan struct{})
+	shutdown := m.done()
+	go func() {
+		defer done()
+		for {
+			select {
+			case <-stopped:
+				return
+			case <-shutdown:
+				return
+			case <-GetClock().After(interval):
+				push()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() { once.Do(func() { close(stopped) }) }
+}
+
+// samplesOf converts the stats of a collector, as returned by its Get method or held by
+// an Update, to samples. The disks, GPUs and UPSes that couldn't be read are left out,
+// and the network interfaces are filtered like NetworkStats
+func (m *Monitor) samplesOf(collector Collector, stats any) []MetricSample {
+	var samples []MetricSample
+	switch stats := stats.(type) {
+	case CPUStats:
+		samples = append(samples, MetricSample{Name: MetricCPUUsage,
+			Value: stats.UsagePercent})
+		for core, usage := range stats.CoreUsagePercent {
+			samples = append(samples, MetricSample{Name: MetricCPUUsage,
+				Device: strconv.Itoa(core), Value: usage})
+		}
+	case *mem.VirtualMemoryStat:
+		if stats != nil {
+			samples = append(samples, MetricSample{Name: MetricMemoryUsed,
+				Value: stats.UsedPercent})
+		}
+	case []DiskStats:
+		for _, disk := range stats {
+			if disk.Error != "" {
+				continue
+			}
+			samples = append(samples,
+				diskSample(MetricDiskUsed, disk, disk.UsedPercent),
+				diskSample(MetricDiskRead, disk, disk.ReadBytesPerSec),
+				diskSample(MetricDiskWrite, disk, disk.WriteBytesPerSec))
+		}
+	case []NetStats:
+		filter := m.netFilter()
+		for _, iface := range stats {
+			if !filter.Match(iface.Name, iface.Alias) {
+				continue
+			}
+			samples = append(samples,
+				netSample(MetricNetDownload, iface, iface.DownloadBytesPerSec),
+				netSample(MetricNetUpload, iface, iface.UploadBytesPerSec),
+				netSample(MetricNetBytesRecv, iface, float64(iface.BytesRecv)),
+				netSample(MetricNetBytesSent, iface, float64(iface.BytesSent)))
+		}
+	case []GPUStats:
+		for _, gpu := range stats {
+			if gpu.Error != "" {
+				continue
+			}
+			samples = append(samples,
+				gpuSample(MetricGPULoad, gpu, gpu.Load),
+				gpuSample(MetricGPUMemoryUsed, gpu, gpu.MemoryUsage),
+				gpuSample(MetricGPUPower, gpu, gpu.Power),
+				gpuSample(MetricGPUTemperature, gpu, float64(gpu.Temperature)))
+		}
+	case PowerStats:
+		// the power draw is unknown without a source
+		if stats.Source != "" {
+			samples = append(samples,
+				MetricSample{Name: MetricPowerTotal, Value: stats.TotalWatts},
+				MetricSample{Name: MetricPowerCPU, Value: stats.CPUWatts},
+				MetricSample{Name: MetricPowerGPU, Value: stats.GPUWatts})
+		}
+	case ProcessCounts:
+		samples = append(samples,
+			MetricSample{Name: MetricProcessCount, Value: float64(stats.Total)})
+		// the states are named like the fields of ProcessCounts
+		for state, count := range map[string]int{"running": stats.Running,
+			"sleeping": stats.Sleeping, "blocked": stats.Blocked,
+			"stopped": stats.Stopped, "zombie": stats.Zombie} {
+			samples = append(samples, MetricSample{Name: MetricProcessCount,
+				Device: state, Value: float64(count)})
+		}
+	case []Sensor:
+		for _, sensor := range stats {
+			device := sensor.Label
+			if sensor.Chip != "" {
+				device = sensor.Chip + "/" + sensor.Label
+			}
+			samples = append(samples, deviceSample(metricPrefixSensor,
+				sensorMetrics[sensor.Kind], device, "", sensor.Value))
+		}
+	case []BatteryStats:
+		for _, battery := range stats {
+			samples = append(samples,
+				deviceSample(metricPrefixBattery, MetricBatteryCharge, battery.Name, "",
+					battery.Percent),
+				deviceSample(metricPrefixBattery, MetricBatteryPower, battery.Name, "",
+					battery.Watts))
+		}
+	case []UPSStats:
+		for _, ups := range stats {
+			if ups.Error != "" {
+				continue
+			}
+			samples = append(samples,
+				deviceSample(metricPrefixUPS, MetricUPSBattery, ups.Address, ups.Name,
+					ups.BatteryPercent),
+				deviceSample(metricPrefixUPS, MetricUPSLoad, ups.Address, ups.Name,
+					ups.LoadPercent),
+				deviceSample(metricPrefixUPS, MetricUPSRuntime, ups.Address, ups.Name,
+					ups.RuntimeRemaining.Seconds()))
+		}
+	case []WiFiStats:
+		for _, wifi := range stats {
+			samples = append(samples,
+				deviceSample(metricPrefixWiFi, MetricWiFiLinkRate, wifi.Interface,
+					wifi.Alias, wifi.LinkRateMbps),
+				deviceSample(metricPrefixWiFi, MetricWiFiSignal, wifi.Interface,
+					wifi.Alias, float64(wifi.SignalDBm)),
+				deviceSample(metricPrefixWiFi, MetricWiFiSignalPercent, wifi.Interface,
+					wifi.Alias, float64(wifi.SignalPercent)))
+		}
+	case []PluginMetric:
+		for _, metric := range stats {
+			samples = append(samples, MetricSample{
+				Name:  PluginMetricName(string(collector), metric.Name),
+				Value: metric.Value})
+		}
+	}
+	return samples
+}
+
+// MetricPathSegment converts a device to a single segment of a dotted metric path (ie.
+// for StatsD or Graphite): anything but letters, digits, '-' and '_' becomes '_'. The
+// root mountpoint "/" becomes "root"
+func MetricPathSegment(device string) string {
+	segment := strings.Trim(strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			r == '-' || r == '_' {
+			return r
+		}
+		return '_'
+	}, device), "_")
+	if segment == "" {
+		return "root"
+	}
+	return segment
+}
+
+// samplePath returns the dotted path of a sample, with the device of per-device metrics
+// as a segment, ie. "disk.mnt_data.used_percent"
+func samplePath(sample MetricSample) string {
+	if sample.Device == "" {
+		return sample.Name
+	}
+	prefix, metric, _ := strings.Cut(sample.Name, metricDeviceSeparator)
+	return prefix + metricDeviceSeparator + MetricPathSegment(sample.Device) +
+		metricDeviceSeparator + metric
+}
+
+// diskSample, gpuSample and netSample build the sample of a per-device metric
+func diskSample(metric string, disk DiskStats, value float64) MetricSample {
+	return MetricSample{Name: metricPrefixDisk + metric, Device: disk.Mountpoint,
+		Alias: disk.Alias, Value: value}
+}
+
+func gpuSample(metric string, gpu GPUStats, value float64) MetricSample {
+	return MetricSample{Name: metricPrefixGPU + metric,
+		Device: strconv.FormatInt(int64(gpu.Id), 10), Alias: gpu.Alias, Value: value}
+}
+
+func netSample(metric string, iface NetStats, value float64) MetricSample {
+	return MetricSample{Name: metricPrefixNet + metric, Device: iface.Name,
+		Alias: iface.Alias, Value: value}
+}
+
+// deviceSample builds the sample of a metric described by deviceMetric. The UPSes are
+// aliased with their name, the WiFi interfaces like the network interfaces
+func deviceSample(prefix, metric, device, alias string, value float64) MetricSample {
+	return MetricSample{Name: prefix + metric, Device: device, Alias: alias,
+		Value: value}
+}
+
+// sensorMetrics are the metrics of the kinds of sensors
+var sensorMetrics = map[SensorKind]string{
+	SensorFan:         MetricSensorFan,
+	SensorTemperature: MetricSensorTemperature,
+	SensorVoltage:     MetricSensorVoltage,
+}
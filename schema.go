@@ -0,0 +1,244 @@
+package gtm
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OPENAPI_VERSION is the OpenAPI specification version of the generated document
+const OPENAPI_VERSION = "3.0.3"
+
+// SCHEMA_VERSION is the version of the JSON of the stats types. It's bumped whenever a
+// field is renamed or removed, or changes its meaning, so parsers can tell which layout
+// a document has. Snapshot and Capture carry it as their top level "schema_version"
+const SCHEMA_VERSION = 1
+
+// schemaTypes are the exported types published in the OpenAPI document, keyed by their
+// component name. Add new stats types here so clients can be generated for them
+var schemaTypes = []any{
+	Annotation{},
+	BandwidthUsage{},
+	BatteryStats{},
+	Capabilities{},
+	Capability{},
+	CgroupThrottling{},
+	Connection{},
+	CPU{},
+	CPUStats{},
+	Correlation{},
+	DeviceEvent{},
+	DiskHealth{},
+	DiskHistory{},
+	DiskStats{},
+	Environment{},
+	GPUStats{},
+	HostIdentity{},
+	HostIdentityEvent{},
+	HostInfo{},
+	IPSplit{},
+	ListeningPort{},
+	MetricDescriptor{},
+	MemoryStats{},
+	MetricHistory{},
+	MetricSample{},
+	NetInterface{},
+	NetStats{},
+	NetworkEnvironment{},
+	OpenFile{},
+	Platform{},
+	PluginMetric{},
+	PowerState{},
+	PowerStats{},
+	ProcStats{},
+	ProcessBandwidth{},
+	ProcessCounts{},
+	ProcessDetail{},
+	ProcessEvent{},
+	ProcessGroupStats{},
+	RedactionProfile{},
+	Sensor{},
+	Service{},
+	ServiceUsage{},
+	SessionSummary{},
+	Snapshot{},
+	SocketStates{},
+	SystemInfo{},
+	TimeSyncStatus{},
+	UPSStats{},
+	WiFiStats{},
+}
+
+var (
+	typeTime     = reflect.TypeOf(time.Time{})
+	typeDuration = reflect.TypeOf(time.Duration(0))
+)
+
+// OpenAPISchema generates an OpenAPI document describing every type in schemaTypes,
+// derived from the Go types and their `json` tags, and the endpoints of the REST API
+// (see APIHandler). Feed it to a generator (ie. openapi-generator or oapi-codegen) to
+// get Python/TypeScript/... clients
+func OpenAPISchema() ([]byte, error) {
+	schemas := map[string]any{}
+	for _, v := range schemaTypes {
+		t := reflect.TypeOf(v)
+		schemas[t.Name()] = structSchema(t)
+	}
+
+	document := map[string]any{
+		"openapi": OPENAPI_VERSION,
+		"info": map[string]any{
+			"title":   "gtm",
+			"version": strconv.Itoa(SCHEMA_VERSION) + ".0.0",
+		},
+		"paths": apiPaths(),
+		"components": map[string]any{
+			"schemas": schemas,
+		},
+	}
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// apiPaths describes the endpoints of the REST API, with their responses referencing
+// the component schemas
+func apiPaths() map[string]any {
+	paths := map[string]any{}
+	for _, endpoint := range apiEndpoints {
+		schema := typeSchema(reflect.TypeOf(endpoint.response), false)
+		paths[API_PATH+endpoint.name] = map[string]any{
+			"get": map[string]any{
+				"operationId": endpoint.operationID,
+				"summary":     endpoint.summary,
+				"responses": map[string]any{
+					"200": map[string]any{
+						"description": endpoint.summary,
+						"content": map[string]any{
+							"application/json": map[string]any{"schema": schema},
+						},
+					},
+					"404": map[string]any{
+						"description": "The collector is disabled (or there is no GPU)",
+					},
+					"503": map[string]any{
+						"description": "The collector failed and has no cached stats",
+					},
+				},
+			},
+		}
+	}
+	return paths
+}
+
+// JSONSchema returns the schema of a single value's type, referencing other published
+// types through "#/components/schemas/..."
+func JSONSchema(v any) map[string]any {
+	return typeSchema(reflect.TypeOf(v), true)
+}
+
+func isSchemaType(t reflect.Type) bool {
+	for _, v := range schemaTypes {
+		if reflect.TypeOf(v) == t {
+			return true
+		}
+	}
+	return false
+}
+
+func typeSchema(t reflect.Type, root bool) map[string]any {
+	switch {
+	case t == typeTime:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t == typeDuration:
+		return map[string]any{"type": "integer", "format": "int64",
+			"description": "duration in nanoseconds"}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		schema := typeSchema(t.Elem(), root)
+		schema["nullable"] = true
+		return schema
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0}
+	case reflect.Float32:
+		return map[string]any{"type": "number", "format": "float"}
+	case reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as a base64 string
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem(), false)}
+	case reflect.Map:
+		return map[string]any{"type": "object",
+			"additionalProperties": typeSchema(t.Elem(), false)}
+	case reflect.Struct:
+		if !root && isSchemaType(t) {
+			return map[string]any{"$ref": "#/components/schemas/" + t.Name()}
+		}
+		return structSchema(t)
+	default:
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type) map[string]any {
+	properties := map[string]any{}
+	var required []string
+
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		omitEmpty := false
+		if tag, ok := field.Tag.Lookup("json"); ok {
+			tagName, options, _ := strings.Cut(tag, ",")
+			if tagName == "-" {
+				continue
+			}
+			if tagName != "" {
+				name = tagName
+			}
+			omitEmpty = strings.Contains(options, "omitempty")
+		}
+
+		// Embedded structs without a json name have their fields promoted
+		if field.Anonymous && field.Type.Kind() == reflect.Struct && name == field.Name {
+			embedded := structSchema(field.Type)
+			for k, v := range embedded["properties"].(map[string]any) {
+				properties[k] = v
+			}
+			if r, ok := embedded["required"].([]string); ok {
+				required = append(required, r...)
+			}
+			continue
+		}
+
+		properties[name] = typeSchema(field.Type, false)
+		if !omitEmpty {
+			required = append(required, name)
+		}
+	}
+
+	schema := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
@@ -0,0 +1,111 @@
+package gtm
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// updateGolden rewrites the golden files with the current output instead of comparing:
+//
+//	go test -run TestGolden -update
+//
+// Review the diff of testdata before committing it: a changed golden file is a changed
+// schema, which needs a new SCHEMA_VERSION when a field was renamed or removed
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// fixtureTime is the time of every time.Time in the fixtures
+var fixtureTime = time.Date(2024, time.March, 1, 12, 30, 0, 0, time.UTC)
+
+// fixture returns a T with every exported field set, so every field of the schema shows
+// up in the golden files. Strings hold their field name, numbers are 1 and slices and
+// maps have a single element
+func fixture[T any]() T {
+	var v T
+	fillFixture(reflect.ValueOf(&v).Elem(), reflect.TypeFor[T]().Name())
+	return v
+}
+
+func fillFixture(v reflect.Value, name string) {
+	if v.Type() == typeTime {
+		v.Set(reflect.ValueOf(fixtureTime))
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(true)
+	case reflect.String:
+		v.SetString(name)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		v.SetInt(1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v.SetUint(1)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(1.5)
+	case reflect.Pointer:
+		v.Set(reflect.New(v.Type().Elem()))
+		fillFixture(v.Elem(), name)
+	case reflect.Slice:
+		v.Set(reflect.MakeSlice(v.Type(), 1, 1))
+		fillFixture(v.Index(0), name)
+	case reflect.Map:
+		key := reflect.New(v.Type().Key()).Elem()
+		fillFixture(key, name)
+		elem := reflect.New(v.Type().Elem()).Elem()
+		fillFixture(elem, name)
+		v.Set(reflect.MakeMap(v.Type()))
+		v.SetMapIndex(key, elem)
+	case reflect.Struct:
+		for i := range v.NumField() {
+			if field := v.Type().Field(i); field.IsExported() {
+				fillFixture(v.Field(i), field.Name)
+			}
+		}
+	}
+}
+
+// checkGolden compares `got` with testdata/<name>.golden, or rewrites it with -update
+func checkGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name+".golden")
+	if *updateGolden {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("%s doesn't match the golden file, run "+
+			"`go test -run TestGolden -update` if the schema changed on purpose:\n%s",
+			name, got)
+	}
+}
+
+func TestGoldenSnapshot(t *testing.T) {
+	checkGolden(t, "snapshot.json", []byte(fixture[Snapshot]().JSON(true)))
+}
+
+func TestGoldenCapture(t *testing.T) {
+	data, err := json.MarshalIndent(fixture[Capture](), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "capture.json", data)
+}
+
+func TestGoldenOpenAPISchema(t *testing.T) {
+	data, err := OpenAPISchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "openapi.json", data)
+}
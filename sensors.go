@@ -0,0 +1,105 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/sensors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SENSORS_UPDATE_INTERVAL is how often the hardware sensors are read. Reading some of
+// them (ie. through WMI on Windows) is slow
+const SENSORS_UPDATE_INTERVAL = 2 * time.Second
+
+// SensorKind is what a sensor measures
+type SensorKind string
+
+const (
+	SensorTemperature SensorKind = "temperature"
+	SensorFan         SensorKind = "fan"
+	SensorVoltage     SensorKind = "voltage"
+)
+
+// Units of Sensor.Unit
+const (
+	UnitCelsius = "°C"
+	UnitRPM     = "RPM"
+	UnitVolts   = "V"
+)
+
+// Sensor is a single hardware sensor reading. Chip is the device the sensor belongs to
+// (ie. "coretemp" or "nct6775" on linux), and it's empty where the OS doesn't tell.
+// High and Critical are the thresholds reported by the chip, 0 when it has none
+type Sensor struct {
+	Kind     SensorKind `json:"kind"`
+	Chip     string     `json:"chip,omitempty"`
+	Label    string     `json:"label"`
+	Value    float64    `json:"value"`
+	Unit     string     `json:"unit"`
+	High     float64    `json:"high,omitempty"`
+	Critical float64    `json:"critical,omitempty"`
+}
+
+// sensorKindOrder is the order of kinds in GetSensors
+var sensorKindOrder = map[SensorKind]int{
+	SensorTemperature: 0,
+	SensorFan:         1,
+	SensorVoltage:     2,
+}
+
+var (
+	sensorStats     []Sensor
+	lastFetchSensor time.Time
+	sensorMut       sync.Mutex
+)
+
+// GetSensors returns every temperature, fan and voltage sensor, sorted by kind, chip and
+// label. Fans and voltages are only read on linux (hwmon); elsewhere only temperatures
+// are available (SMC on macOS, ACPI thermal zones through WMI on Windows)
+func GetSensors() ([]Sensor, error) {
+	sensorMut.Lock()
+	defer sensorMut.Unlock()
+
+	if GetClock().Since(lastFetchSensor) < SENSORS_UPDATE_INTERVAL && sensorStats != nil {
+		return sensorStats, nil
+	}
+	if !IsCollectorEnabled(CollectorSensors) {
+		return nil, ErrCollectorDisabled
+	}
+	lastFetchSensor = GetClock().Now()
+
+	result, err := readSensors()
+	if err != nil && len(result) == 0 {
+		collectorError(CollectorSensors, "Failed to retrieve the sensors!", err)
+		return sensorStats, err
+	}
+	for i := range result {
+		result[i].Value = RoundStat(result[i].Value)
+		result[i].High = RoundStat(result[i].High)
+		result[i].Critical = RoundStat(result[i].Critical)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return sensorKindOrder[result[i].Kind] < sensorKindOrder[result[j].Kind]
+		}
+		if result[i].Chip != result[j].Chip {
+			return result[i].Chip < result[j].Chip
+		}
+		return result[i].Label < result[j].Label
+	})
+	sensorStats = result
+	checkSensorAlerts(sensorStats)
+	return sensorStats, nil
+}
+
+// readTemperatures reads the temperatures through gopsutil. It fails with partial
+// results when some sensors can't be read, so results are kept whenever there are any
+func readTemperatures() ([]Sensor, error) {
+	temperatures, err := sensors.SensorsTemperatures()
+	result := make([]Sensor, 0, len(temperatures))
+	for _, t := range temperatures {
+		result = append(result, Sensor{Kind: SensorTemperature, Label: t.SensorKey,
+			Value: t.Temperature, Unit: UnitCelsius, High: t.High, Critical: t.Critical})
+	}
+	return result, err
+}
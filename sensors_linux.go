@@ -0,0 +1,109 @@
+package gtm
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// HWMON_PATH is where linux exposes the hardware monitoring chips
+const HWMON_PATH = "/sys/class/hwmon"
+
+// hwmonSensors are the hwmon attributes read for each kind, with the factor from their
+// unit (millidegrees, RPM and millivolts) to the unit of the Sensor
+var hwmonSensors = []struct {
+	Prefix string
+	Kind   SensorKind
+	Unit   string
+	Scale  float64
+}{
+	{"temp", SensorTemperature, UnitCelsius, 1000},
+	{"fan", SensorFan, UnitRPM, 1},
+	{"in", SensorVoltage, UnitVolts, 1000},
+}
+
+// readSensors reads every hwmon chip. Machines without hwmon temperatures (ie. some ARM
+// boards) fall back to the thermal zones read by gopsutil
+func readSensors() ([]Sensor, error) {
+	chips, err := os.ReadDir(HWMON_PATH)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	var result []Sensor
+	hasTemperatures := false
+	for _, chip := range chips {
+		dir := filepath.Join(HWMON_PATH, chip.Name())
+		name := readSysfsString(filepath.Join(dir, "name"))
+		if name == "" {
+			name = chip.Name()
+		}
+		for _, sensor := range readHwmonChip(dir, name) {
+			hasTemperatures = hasTemperatures || sensor.Kind == SensorTemperature
+			result = append(result, sensor)
+		}
+	}
+	if !hasTemperatures {
+		temperatures, err := readTemperatures()
+		if err != nil && len(temperatures) == 0 && len(result) == 0 {
+			return nil, err
+		}
+		result = append(result, temperatures...)
+	}
+	return result, nil
+}
+
+// readHwmonChip reads the <prefix><n>_input attributes of a chip, ie. temp1_input. The
+// label comes from <prefix><n>_label, and defaults to the attribute name (ie. "temp1")
+func readHwmonChip(dir string, chip string) []Sensor {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var result []Sensor
+	for _, entry := range entries {
+		attribute, ok := strings.CutSuffix(entry.Name(), "_input")
+		if !ok {
+			continue
+		}
+		for _, kind := range hwmonSensors {
+			index, ok := strings.CutPrefix(attribute, kind.Prefix)
+			if !ok {
+				continue
+			}
+			if _, err := strconv.Atoi(index); err != nil {
+				continue
+			}
+			value, ok := readHwmonValue(filepath.Join(dir, attribute+"_input"))
+			if !ok {
+				break
+			}
+			label := readSysfsString(filepath.Join(dir, attribute+"_label"))
+			if label == "" {
+				label = attribute
+			}
+			high, _ := readHwmonValue(filepath.Join(dir, attribute+"_max"))
+			critical, _ := readHwmonValue(filepath.Join(dir, attribute+"_crit"))
+			result = append(result, Sensor{Kind: kind.Kind, Chip: chip, Label: label,
+				Value: value / kind.Scale, Unit: kind.Unit, High: high / kind.Scale,
+				Critical: critical / kind.Scale})
+			break
+		}
+	}
+	return result
+}
+
+func readHwmonValue(path string) (float64, bool) {
+	value, err := strconv.ParseFloat(readSysfsString(path), 64)
+	return value, err == nil
+}
+
+// readSysfsString returns the trimmed contents of a sysfs attribute, empty when it
+// can't be read
+func readSysfsString(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
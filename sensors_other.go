@@ -0,0 +1,7 @@
+//go:build !linux
+
+package gtm
+
+func readSensors() ([]Sensor, error) {
+	return readTemperatures()
+}
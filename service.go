@@ -0,0 +1,185 @@
+package gtm
+
+import (
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SERVICES_UPDATE_INTERVAL limits how often the service manager is asked which process
+// runs which service (Windows and macOS), since services rarely start or stop
+const SERVICES_UPDATE_INTERVAL = 10 * time.Second
+
+// ServiceUsage is the resource usage of every process of a service together, since a
+// service like nginx or postgres is made of many worker processes
+type ServiceUsage struct {
+	Service          string  `json:"service"`
+	Processes        int     `json:"processes"`
+	CPUPercent       float64 `json:"cpu_percent"`
+	RSS              uint64  `json:"rss"`
+	MemoryPercent    float64 `json:"memory_percent"`
+	ReadBytesPerSec  float64 `json:"read_bytes_per_sec"`
+	WriteBytesPerSec float64 `json:"write_bytes_per_sec"`
+	GPUMemory        uint64  `json:"gpu_memory"`
+}
+
+// ServiceState is the state of a service, normalized across service managers
+type ServiceState string
+
+const (
+	ServiceRunning      ServiceState = "running"
+	ServiceStarting     ServiceState = "starting"
+	ServiceStopping     ServiceState = "stopping"
+	ServiceStopped      ServiceState = "stopped"
+	ServicePaused       ServiceState = "paused"
+	ServiceFailed       ServiceState = "failed"
+	ServiceStateUnknown ServiceState = "unknown"
+)
+
+// ServiceStartType is when a service is started
+type ServiceStartType string
+
+const (
+	// ServiceStartAuto is started at boot (Windows automatic, systemd enabled)
+	ServiceStartAuto ServiceStartType = "auto"
+	// ServiceStartManual is started on demand (Windows manual, systemd disabled, static)
+	ServiceStartManual ServiceStartType = "manual"
+	// ServiceStartDisabled can't be started (Windows disabled, systemd masked)
+	ServiceStartDisabled ServiceStartType = "disabled"
+	ServiceStartUnknown  ServiceStartType = "unknown"
+)
+
+// Service is a service of the service manager: the Service Control Manager on Windows,
+// systemd on linux and launchd on macOS. PID is the main process of a running service,
+// 0 when unknown (always on linux). The start type is unknown on macOS
+type Service struct {
+	Name        string           `json:"name"`
+	DisplayName string           `json:"display_name,omitempty"`
+	State       ServiceState     `json:"state"`
+	StartType   ServiceStartType `json:"start_type"`
+	PID         int32            `json:"pid,omitempty"`
+}
+
+// ServiceFilter selects services. Every empty field matches every service
+type ServiceFilter struct {
+	// Names matches the name or the display name, ie. Include: []string{"ssh*"}
+	Names      NameFilter
+	States     []ServiceState
+	StartTypes []ServiceStartType
+}
+
+var (
+	servicePIDs          map[int32]string
+	lastFetchServicePIDs time.Time
+	servicePIDMut        sync.Mutex
+
+	services          []Service
+	lastFetchServices time.Time
+	servicesMut       sync.Mutex
+)
+
+// GetServiceUsage sums up the processes of the last fetch by ProcStats.Service, busiest
+// (by CPU) first. Processes that don't belong to a service are left out
+func GetServiceUsage() ([]ServiceUsage, error) {
+	procs, err := GetProcesses()
+	if err != nil && procs == nil {
+		return nil, err
+	}
+	services := map[string]*ServiceUsage{}
+	for _, proc := range procs {
+		if proc.Service == "" {
+			continue
+		}
+		usage, ok := services[proc.Service]
+		if !ok {
+			usage = &ServiceUsage{Service: proc.Service}
+			services[proc.Service] = usage
+		}
+		usage.Processes++
+		usage.CPUPercent += proc.CPUPercent
+		usage.RSS += proc.RSS
+		usage.MemoryPercent += proc.MemoryPercent
+		usage.ReadBytesPerSec += proc.ReadBytesPerSec
+		usage.WriteBytesPerSec += proc.WriteBytesPerSec
+		usage.GPUMemory += proc.GPUMemory
+	}
+
+	result := make([]ServiceUsage, 0, len(services))
+	for _, usage := range services {
+		usage.CPUPercent = RoundStat(usage.CPUPercent)
+		usage.MemoryPercent = RoundStat(usage.MemoryPercent)
+		usage.ReadBytesPerSec = RoundStat(usage.ReadBytesPerSec)
+		usage.WriteBytesPerSec = RoundStat(usage.WriteBytesPerSec)
+		result = append(result, *usage)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].CPUPercent != result[j].CPUPercent {
+			return result[i].CPUPercent > result[j].CPUPercent
+		}
+		return result[i].Service < result[j].Service
+	})
+	return result, err
+}
+
+// getServicePIDs returns the service of every process run by the service manager. It
+// is nil on linux, where the systemd unit comes from the cgroup of each process instead
+func getServicePIDs() map[int32]string {
+	servicePIDMut.Lock()
+	defer servicePIDMut.Unlock()
+
+	if GetClock().Since(lastFetchServicePIDs) < SERVICES_UPDATE_INTERVAL &&
+		servicePIDs != nil {
+		return servicePIDs
+	}
+	lastFetchServicePIDs = GetClock().Now()
+	pids, err := readServicePIDs()
+	if err != nil {
+		collectorError(CollectorProcesses, "Failed to retrieve the services!", err)
+		return servicePIDs
+	}
+	servicePIDs = pids
+	return servicePIDs
+}
+
+// GetServices returns the services that match the filter, sorted by name. The service
+// list is cached for SERVICES_UPDATE_INTERVAL, and the last list is filtered when it
+// can't be read
+func GetServices(filter ServiceFilter) ([]Service, error) {
+	servicesMut.Lock()
+	defer servicesMut.Unlock()
+
+	var err error
+	if GetClock().Since(lastFetchServices) >= SERVICES_UPDATE_INTERVAL || services == nil {
+		lastFetchServices = GetClock().Now()
+		var list []Service
+		if list, err = readServices(); err != nil {
+			collectorError(CollectorProcesses, "Failed to retrieve the services!", err)
+			if services == nil {
+				return nil, err
+			}
+		} else {
+			sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+			services = list
+		}
+	}
+
+	result := []Service{}
+	for _, service := range services {
+		if filter.Match(service) {
+			result = append(result, service)
+		}
+	}
+	return result, err
+}
+
+// Match returns true when the service passes the filter
+func (f ServiceFilter) Match(service Service) bool {
+	if !f.Names.Match(service.Name, service.DisplayName) {
+		return false
+	}
+	if len(f.States) > 0 && !slices.Contains(f.States, service.State) {
+		return false
+	}
+	return len(f.StartTypes) == 0 || slices.Contains(f.StartTypes, service.StartType)
+}
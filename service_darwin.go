@@ -0,0 +1,69 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// readServicePIDs lists the running launchd jobs. As a regular user, only the jobs of
+// that user are listed
+func readServicePIDs() (map[int32]string, error) {
+	out, err := runCommand("launchctl", "list")
+	if err != nil {
+		return nil, err
+	}
+	return parseLaunchctlList(out), nil
+}
+
+// parseLaunchctlList parses `launchctl list`, a header and a "PID Status Label" line per
+// job. The PID is "-" for jobs that aren't running
+func parseLaunchctlList(out []byte) map[int32]string {
+	pids := map[int32]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		pid, err := strconv.ParseInt(fields[0], 10, 32)
+		if err != nil || pid <= 0 {
+			continue
+		}
+		pids[int32(pid)] = fields[2]
+	}
+	return pids
+}
+
+// readServices lists the launchd jobs. As a regular user, only the jobs of that user are
+// listed. launchd has no start types, since every job starts on demand or at load
+func readServices() ([]Service, error) {
+	out, err := runCommand("launchctl", "list")
+	if err != nil {
+		return nil, err
+	}
+	return parseLaunchctlServices(out), nil
+}
+
+// parseLaunchctlServices parses the "PID Status Label" lines of `launchctl list`. Jobs
+// that aren't running have the PID "-", and the exit status of their last run
+func parseLaunchctlServices(out []byte) []Service {
+	services := []Service{}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || fields[0] == "PID" {
+			continue
+		}
+		service := Service{Name: fields[2], State: ServiceStopped,
+			StartType: ServiceStartUnknown}
+		if pid, err := strconv.ParseInt(fields[0], 10, 32); err == nil && pid > 0 {
+			service.State, service.PID = ServiceRunning, int32(pid)
+		} else if fields[1] != "0" {
+			service.State = ServiceFailed
+		}
+		services = append(services, service)
+	}
+	return services
+}
@@ -0,0 +1,85 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+func readServicePIDs() (map[int32]string, error) {
+	return map[int32]string{}, nil
+}
+
+// readServices lists the systemd service units, loaded or not, along with whether
+// their unit files are enabled
+func readServices() ([]Service, error) {
+	units, err := runCommand("systemctl", "list-units", "--type=service", "--all",
+		"--no-legend", "--no-pager", "--plain")
+	if err != nil {
+		return nil, err
+	}
+	unitFiles, err := runCommand("systemctl", "list-unit-files", "--type=service",
+		"--no-legend", "--no-pager")
+	if err != nil {
+		return nil, err
+	}
+	return parseSystemdServices(units, unitFiles), nil
+}
+
+// parseSystemdServices joins the "UNIT LOAD ACTIVE SUB DESCRIPTION" lines of
+// `systemctl list-units` with the "UNIT STATE PRESET" lines of `list-unit-files`.
+// Template units (ie. getty@.service) only appear in the unit files, and are left out
+func parseSystemdServices(units []byte, unitFiles []byte) []Service {
+	startTypes := map[string]ServiceStartType{}
+	scanner := bufio.NewScanner(bytes.NewReader(unitFiles))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[1] {
+		case "enabled", "enabled-runtime", "alias":
+			startTypes[fields[0]] = ServiceStartAuto
+		case "masked", "masked-runtime":
+			startTypes[fields[0]] = ServiceStartDisabled
+		default:
+			// disabled, static, indirect, generated and transient units only start
+			//	when something asks for them
+			startTypes[fields[0]] = ServiceStartManual
+		}
+	}
+
+	services := []Service{}
+	scanner = bufio.NewScanner(bytes.NewReader(units))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasSuffix(fields[0], ".service") {
+			continue
+		}
+		service := Service{
+			Name:        strings.TrimSuffix(fields[0], ".service"),
+			DisplayName: strings.Join(fields[4:], " "),
+			State:       ServiceStateUnknown,
+			StartType:   ServiceStartUnknown,
+		}
+		if startType, ok := startTypes[fields[0]]; ok {
+			service.StartType = startType
+		} else if fields[1] == "masked" {
+			service.StartType = ServiceStartDisabled
+		}
+		switch fields[2] {
+		case "active", "reloading":
+			service.State = ServiceRunning
+		case "inactive":
+			service.State = ServiceStopped
+		case "failed":
+			service.State = ServiceFailed
+		case "activating":
+			service.State = ServiceStarting
+		case "deactivating":
+			service.State = ServiceStopping
+		}
+		services = append(services, service)
+	}
+	return services
+}
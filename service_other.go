@@ -0,0 +1,11 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+func readServicePIDs() (map[int32]string, error) {
+	return nil, ErrUnsupportedPlatform
+}
+
+func readServices() ([]Service, error) {
+	return nil, ErrUnsupportedPlatform
+}
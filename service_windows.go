@@ -0,0 +1,130 @@
+package gtm
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"sort"
+	"strings"
+	"unsafe"
+)
+
+// SERVICES_KEY holds the configuration of every service, ie. its start type
+const SERVICES_KEY = `SYSTEM\CurrentControlSet\Services`
+
+// readServicePIDs lists the running services. Services sharing a svchost.exe are
+// joined by commas
+func readServicePIDs() (map[int32]string, error) {
+	services, err := enumServices(windows.SERVICE_ACTIVE)
+	if err != nil {
+		return nil, err
+	}
+	names := map[int32][]string{}
+	for _, service := range services {
+		pid := int32(service.ServiceStatusProcess.ProcessId)
+		if pid == 0 {
+			continue
+		}
+		names[pid] = append(names[pid], windows.UTF16PtrToString(service.ServiceName))
+	}
+	pids := make(map[int32]string, len(names))
+	for pid, services := range names {
+		sort.Strings(services)
+		pids[pid] = strings.Join(services, ",")
+	}
+	return pids, nil
+}
+
+// readServices lists every service, running or not. The start type is read from the
+// registry, since QueryServiceConfig needs a handle per service
+func readServices() ([]Service, error) {
+	list, err := enumServices(windows.SERVICE_STATE_ALL)
+	if err != nil {
+		return nil, err
+	}
+	services := make([]Service, 0, len(list))
+	for _, status := range list {
+		service := Service{
+			Name:        windows.UTF16PtrToString(status.ServiceName),
+			DisplayName: windows.UTF16PtrToString(status.DisplayName),
+			State:       ServiceStateUnknown,
+			StartType:   ServiceStartUnknown,
+			PID:         int32(status.ServiceStatusProcess.ProcessId),
+		}
+		switch status.ServiceStatusProcess.CurrentState {
+		case windows.SERVICE_RUNNING:
+			service.State = ServiceRunning
+		case windows.SERVICE_START_PENDING, windows.SERVICE_CONTINUE_PENDING:
+			service.State = ServiceStarting
+		case windows.SERVICE_STOP_PENDING, windows.SERVICE_PAUSE_PENDING:
+			service.State = ServiceStopping
+		case windows.SERVICE_STOPPED:
+			service.State = ServiceStopped
+			if status.ServiceStatusProcess.Win32ExitCode != 0 {
+				service.State = ServiceFailed
+			}
+		case windows.SERVICE_PAUSED:
+			service.State = ServicePaused
+		}
+		service.StartType = serviceStartType(service.Name)
+		services = append(services, service)
+	}
+	return services, nil
+}
+
+func serviceStartType(name string) ServiceStartType {
+	key, err := registry.OpenKey(registry.LOCAL_MACHINE, SERVICES_KEY+`\`+name,
+		registry.QUERY_VALUE)
+	if err != nil {
+		return ServiceStartUnknown
+	}
+	defer key.Close()
+	start, _, err := key.GetIntegerValue("Start")
+	if err != nil {
+		return ServiceStartUnknown
+	}
+	switch start {
+	case windows.SERVICE_BOOT_START, windows.SERVICE_SYSTEM_START,
+		windows.SERVICE_AUTO_START:
+		return ServiceStartAuto
+	case windows.SERVICE_DEMAND_START:
+		return ServiceStartManual
+	case windows.SERVICE_DISABLED:
+		return ServiceStartDisabled
+	}
+	return ServiceStartUnknown
+}
+
+// enumServices lists the Win32 services in `state` (SERVICE_ACTIVE, SERVICE_INACTIVE or
+// SERVICE_STATE_ALL)
+func enumServices(state uint32) ([]windows.ENUM_SERVICE_STATUS_PROCESS, error) {
+	manager, err := windows.OpenSCManager(nil, nil, windows.SC_MANAGER_ENUMERATE_SERVICE)
+	if err != nil {
+		return nil, err
+	}
+	defer windows.CloseServiceHandle(manager)
+
+	var bytesNeeded, servicesReturned uint32
+	var buf []byte
+	for {
+		var p *byte
+		if len(buf) > 0 {
+			p = &buf[0]
+		}
+		err = windows.EnumServicesStatusEx(manager, windows.SC_ENUM_PROCESS_INFO,
+			windows.SERVICE_WIN32, state, p, uint32(len(buf)),
+			&bytesNeeded, &servicesReturned, nil, nil)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, windows.ERROR_MORE_DATA) || bytesNeeded <= uint32(len(buf)) {
+			return nil, err
+		}
+		buf = make([]byte, bytesNeeded)
+	}
+	if servicesReturned == 0 {
+		return nil, nil
+	}
+	return unsafe.Slice((*windows.ENUM_SERVICE_STATUS_PROCESS)(unsafe.Pointer(&buf[0])),
+		int(servicesReturned)), nil
+}
@@ -0,0 +1,270 @@
+package gtm
+
+import (
+	"encoding/json"
+	"github.com/shirou/gopsutil/v4/process"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// SESSION_PROCESS_SAMPLE_INTERVAL is how often the CPU time of every process is
+	// sampled for the top processes of the session summary
+	SESSION_PROCESS_SAMPLE_INTERVAL = 10 * time.Second
+	// SESSION_TOP_PROCESSES is the number of processes in the session summary
+	SESSION_TOP_PROCESSES = 5
+	// SESSION_SUMMARY_STDOUT prints the session summary instead of writing it to a file
+	SESSION_SUMMARY_STDOUT = "stdout"
+)
+
+// SessionSummary sums up a gtm session, from StartSession until now. Network traffic
+// only counts the interfaces shown in the network stats (see NET_EXCLUDE)
+type SessionSummary struct {
+	Start             time.Time        `json:"start"`
+	End               time.Time        `json:"end"`
+	Duration          time.Duration    `json:"duration"`
+	CPUAvgPercent     float64          `json:"cpu_avg_percent"`
+	CPUPeakPercent    float64          `json:"cpu_peak_percent"`
+	MemoryAvgPercent  float64          `json:"memory_avg_percent"`
+	MemoryPeakPercent float64          `json:"memory_peak_percent"`
+	BytesSent         uint64           `json:"bytes_sent"`
+	BytesRecv         uint64           `json:"bytes_recv"`
+	TopProcesses      []SessionProcess `json:"top_processes"`
+	Alerts            []Annotation     `json:"alerts"`
+}
+
+// SessionProcess is the CPU time all processes with the same name used during the
+// session
+type SessionProcess struct {
+	Name       string  `json:"name"`
+	CPUSeconds float64 `json:"cpu_seconds"`
+}
+
+// sessionAggregate is a running average and peak, so long sessions don't need to keep
+// every sample
+type sessionAggregate struct {
+	Count uint64
+	Sum   float64
+	Peak  float64
+}
+
+var (
+	sessionStart      time.Time
+	sessionCPU        sessionAggregate
+	sessionMemory     sessionAggregate
+	sessionBytesSent  uint64
+	sessionBytesRecv  uint64
+	sessionCPUTimes   = map[int32]float64{}
+	sessionProcessCPU = map[string]float64{}
+	sessionMut        sync.Mutex
+	sessionOnce       sync.Once
+	sessionStop       = make(chan struct{})
+	sessionStopOnce   sync.Once
+)
+
+// StartSession marks the start of the session and starts sampling processes in the
+// background. Nothing is aggregated for the summary before it's called
+func StartSession() {
+	sessionOnce.Do(func() {
+		sessionMut.Lock()
+		sessionStart = GetClock().Now()
+		sessionMut.Unlock()
+
+		go func() {
+			for {
+				sampleSessionProcesses()
+				select {
+				case <-sessionStop:
+					return
+				case <-GetClock().After(SESSION_PROCESS_SAMPLE_INTERVAL):
+				}
+			}
+		}()
+	})
+}
+
+// stopSession stops sampling the processes of the session. The summary keeps what was
+// aggregated so far
+func stopSession() {
+	sessionStopOnce.Do(func() { close(sessionStop) })
+}
+
+// GetSessionSummary returns the summary of the session so far
+func GetSessionSummary() SessionSummary {
+	sessionMut.Lock()
+	defer sessionMut.Unlock()
+
+	now := GetClock().Now()
+	summary := SessionSummary{
+		Start:             sessionStart,
+		End:               now,
+		Duration:          now.Sub(sessionStart),
+		CPUAvgPercent:     RoundStat(sessionCPU.Average()),
+		CPUPeakPercent:    RoundStat(sessionCPU.Peak),
+		MemoryAvgPercent:  RoundStat(sessionMemory.Average()),
+		MemoryPeakPercent: RoundStat(sessionMemory.Peak),
+		BytesSent:         sessionBytesSent,
+		BytesRecv:         sessionBytesRecv,
+		TopProcesses:      []SessionProcess{},
+		Alerts:            []Annotation{},
+	}
+	if sessionStart.IsZero() {
+		summary.Duration = 0
+		return summary
+	}
+
+	for name, seconds := range sessionProcessCPU {
+		if seconds <= 0 {
+			continue
+		}
+		summary.TopProcesses = append(summary.TopProcesses,
+			SessionProcess{Name: name, CPUSeconds: RoundStat(seconds)})
+	}
+	sort.Slice(summary.TopProcesses, func(i, j int) bool {
+		return summary.TopProcesses[i].CPUSeconds > summary.TopProcesses[j].CPUSeconds
+	})
+	summary.TopProcesses = summary.TopProcesses[:min(len(summary.TopProcesses),
+		SESSION_TOP_PROCESSES)]
+
+	for _, annotation := range GetAnnotations(summary.Duration) {
+		if annotation.Kind == AnnotationAlert {
+			summary.Alerts = append(summary.Alerts, annotation)
+		}
+	}
+	return summary
+}
+
+// WriteSessionSummary prints the summary when `path` is SESSION_SUMMARY_STDOUT, or
+// writes it to `path`. Paths ending with ".json" get JSON, anything else gets text
+func WriteSessionSummary(summary SessionSummary, path string) error {
+	if path == SESSION_SUMMARY_STDOUT {
+		_, err := os.Stdout.WriteString(summary.String())
+		return err
+	}
+	if strings.HasSuffix(path, ".json") {
+		data, err := json.MarshalIndent(summary, "", "  ")
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(path, data, 0o644)
+	}
+	return os.WriteFile(path, []byte(summary.String()), 0o644)
+}
+
+func (s SessionSummary) String() string {
+	var sb strings.Builder
+	sb.WriteString("gtm session: " + s.Start.Format(time.DateTime) + " - " +
+		s.End.Format(time.DateTime) + " (" + s.Duration.Round(time.Second).String() + ")\n")
+	sb.WriteString("CPU:     avg " + formatPercent(s.CPUAvgPercent) + ", peak " +
+		formatPercent(s.CPUPeakPercent) + "\n")
+	sb.WriteString("Memory:  avg " + formatPercent(s.MemoryAvgPercent) + ", peak " +
+		formatPercent(s.MemoryPeakPercent) + "\n")
+	sb.WriteString("Network: " + formatBytes(float64(s.BytesRecv)) + " down, " +
+		formatBytes(float64(s.BytesSent)) + " up\n")
+	if len(s.TopProcesses) > 0 {
+		sb.WriteString("Top processes (CPU time):\n")
+		for _, p := range s.TopProcesses {
+			sb.WriteString("  " + p.Name + "  " +
+				strconv.FormatFloat(p.CPUSeconds, 'f', 1, 64) + "s\n")
+		}
+	}
+	sb.WriteString("Alerts:  " + strconv.Itoa(len(s.Alerts)) + "\n")
+	for _, alert := range s.Alerts {
+		sb.WriteString("  " + alert.Timestamp.Format(time.TimeOnly) + "  " + alert.Text +
+			"\n")
+	}
+	return sb.String()
+}
+
+func (a sessionAggregate) Average() float64 {
+	if a.Count == 0 {
+		return 0
+	}
+	return a.Sum / float64(a.Count)
+}
+
+func (a *sessionAggregate) add(value float64) {
+	a.Count++
+	a.Sum += value
+	a.Peak = max(a.Peak, value)
+}
+
+func formatPercent(v float64) string {
+	return strconv.FormatFloat(v, 'f', 1, 64) + "%"
+}
+
+// observeSessionMetric adds a sample of a recorded metric to the session aggregates
+func observeSessionMetric(name string, value float64) {
+	sessionMut.Lock()
+	defer sessionMut.Unlock()
+	if sessionStart.IsZero() {
+		return
+	}
+	switch name {
+	case MetricCPUUsage:
+		sessionCPU.add(value)
+	case MetricMemoryUsed:
+		sessionMemory.add(value)
+	}
+}
+
+// observeSessionTraffic adds the traffic of a shown interface between two fetches
+func observeSessionTraffic(sent uint64, recv uint64) {
+	sessionMut.Lock()
+	defer sessionMut.Unlock()
+	if sessionStart.IsZero() {
+		return
+	}
+	sessionBytesSent += sent
+	sessionBytesRecv += recv
+}
+
+// sampleSessionProcesses adds the CPU time every process used since the last sample.
+// Processes started before the session only count from their first sample
+func sampleSessionProcesses() {
+	procs, err := process.Processes()
+	if err != nil {
+		collectorError(CollectorProcesses, "Failed to retrieve process.Processes()!", err)
+		return
+	}
+
+	sessionMut.Lock()
+	startMillis := sessionStart.UnixMilli()
+	sessionMut.Unlock()
+
+	cpuTimes := make(map[int32]float64, len(procs))
+	names := make(map[int32]string, len(procs))
+	created := make(map[int32]bool, len(procs))
+	for _, p := range procs {
+		times, err := p.Times()
+		if err != nil {
+			continue
+		}
+		name, err := p.Name()
+		if err != nil {
+			continue
+		}
+		cpuTimes[p.Pid] = times.User + times.System
+		names[p.Pid] = name
+		if createTime, err := p.CreateTime(); err == nil && createTime >= startMillis {
+			created[p.Pid] = true
+		}
+	}
+
+	sessionMut.Lock()
+	defer sessionMut.Unlock()
+	for pid, total := range cpuTimes {
+		previous, seen := sessionCPUTimes[pid]
+		switch {
+		case seen && total >= previous:
+			sessionProcessCPU[names[pid]] += total - previous
+		case !seen && created[pid]:
+			sessionProcessCPU[names[pid]] += total
+		}
+	}
+	sessionCPUTimes = cpuTimes
+}
@@ -0,0 +1,138 @@
+package gtm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// ErrMonitorClosed is returned by a Monitor that was shut down, and (wrapped) by the
+// commands killed by the shutdown of the default Monitor
+var ErrMonitorClosed = errors.New("monitor is closed")
+
+// shutdownState tracks what a Monitor has to tear down, see Monitor.Shutdown
+type shutdownState struct {
+	mut     sync.Mutex
+	closed  bool
+	servers map[*http.Server]struct{}
+	// done is closed by Shutdown, for the background senders of the Monitor
+	done chan struct{}
+}
+
+// Shutdown stops the Monitor so applications embedding gtm can exit cleanly: it stops
+// polling, shuts down the servers of the Monitor (ie. the capture webhook) and closes
+// the channels of its subscribers. Shutting down the default Monitor also stops device
+// discovery and the session, kills the external commands in progress (nvidia-smi,
+// smartctl, plugins, ...) and saves the bandwidth accounting. It waits for collections
+// and requests in progress until `ctx` is done. The caches still answer the Get
+// methods afterwards, but the Monitor can't be started again. Shutting down a Monitor
+// that was shut down already does nothing
+func (m *Monitor) Shutdown(ctx context.Context) error {
+	m.shutdown.mut.Lock()
+	if m.shutdown.closed {
+		m.shutdown.mut.Unlock()
+		return nil
+	}
+	m.shutdown.closed = true
+	servers := m.shutdown.servers
+	m.shutdown.servers = nil
+	if m.shutdown.done != nil {
+		close(m.shutdown.done)
+	}
+	m.shutdown.mut.Unlock()
+
+	if m.global {
+		StopDeviceDiscovery()
+		stopSession()
+		// the pollers can't stop while they wait for a command that hangs
+		cancelCommands()
+	}
+
+	var errs []error
+	for server := range servers {
+		if err := server.Shutdown(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		m.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+	}
+
+	if m.global {
+		if err := SaveBandwidthAccounting(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	m.updates.Close()
+	return errors.Join(errs...)
+}
+
+// Close shuts down the Monitor without a deadline, see Shutdown
+func (m *Monitor) Close() error {
+	return m.Shutdown(context.Background())
+}
+
+// Shutdown shuts down the default Monitor, see Monitor.Shutdown
+func Shutdown(ctx context.Context) error {
+	return defaultMonitor.Shutdown(ctx)
+}
+
+// isClosed reports whether the Monitor was shut down
+func (m *Monitor) isClosed() bool {
+	m.shutdown.mut.Lock()
+	defer m.shutdown.mut.Unlock()
+	return m.shutdown.closed
+}
+
+// done returns a channel closed once the Monitor is shut down
+func (m *Monitor) done() <-chan struct{} {
+	m.shutdown.mut.Lock()
+	defer m.shutdown.mut.Unlock()
+	if m.shutdown.done == nil {
+		m.shutdown.done = make(chan struct{})
+		if m.shutdown.closed {
+			close(m.shutdown.done)
+		}
+	}
+	return m.shutdown.done
+}
+
+// serve runs `server` until the Monitor is shut down, which shuts the server down
+// gracefully. It returns nil once the server was shut down, like Shutdown does. Servers
+// with a TLSConfig are served over TLS with its certificates
+func (m *Monitor) serve(server *http.Server) error {
+	m.shutdown.mut.Lock()
+	if m.shutdown.closed {
+		m.shutdown.mut.Unlock()
+		return ErrMonitorClosed
+	}
+	if m.shutdown.servers == nil {
+		m.shutdown.servers = map[*http.Server]struct{}{}
+	}
+	m.shutdown.servers[server] = struct{}{}
+	m.shutdown.mut.Unlock()
+
+	var err error
+	if server.TLSConfig != nil {
+		err = server.ListenAndServeTLS("", "")
+	} else {
+		err = server.ListenAndServe()
+	}
+
+	m.shutdown.mut.Lock()
+	delete(m.shutdown.servers, server)
+	m.shutdown.mut.Unlock()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,56 @@
+package gtm
+
+import (
+	"errors"
+	"strconv"
+	"syscall"
+)
+
+// ErrProcessNotFound is returned (wrapped) when signaling a process that doesn't exist,
+// usually because it exited in the meantime
+var ErrProcessNotFound = errors.New("process not found")
+
+// ProcessError is returned by the functions acting on a single process. Check it
+// with errors.Is(err, fs.ErrPermission) for processes of other users (or protected
+// system processes on Windows), and errors.Is(err, ErrProcessNotFound) for processes
+// that already exited
+type ProcessError struct {
+	PID int32
+	// Op is what was done to the process, ie. "signal terminated" or "read"
+	Op  string
+	Err error
+}
+
+func (e *ProcessError) Error() string {
+	return e.Op + " process " + strconv.FormatInt(int64(e.PID), 10) + ": " + e.Err.Error()
+}
+
+func (e *ProcessError) Unwrap() error { return e.Err }
+
+// SignalProcess sends a signal to a process. Windows has no signals, so only
+// syscall.SIGTERM and syscall.SIGKILL are supported there, and both terminate the
+// process immediately
+func SignalProcess(pid int32, sig syscall.Signal) error {
+	if pid <= 0 {
+		// kill(0) and kill(-1) would signal a whole process group, or every process
+		return &ProcessError{PID: pid, Op: "signal " + sig.String(),
+			Err: errors.New("invalid PID")}
+	}
+	if err := signalProcess(pid, sig); err != nil {
+		return &ProcessError{PID: pid, Op: "signal " + sig.String(), Err: err}
+	}
+	// refresh the process list on the next fetch, so it doesn't show a killed process
+	resetProcesses()
+	return nil
+}
+
+// TerminateProcess asks a process to exit (SIGTERM), so it can clean up first
+func TerminateProcess(pid int32) error {
+	return SignalProcess(pid, syscall.SIGTERM)
+}
+
+// KillProcess ends a process immediately (SIGKILL), without giving it a chance to clean
+// up
+func KillProcess(pid int32) error {
+	return SignalProcess(pid, syscall.SIGKILL)
+}
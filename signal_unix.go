@@ -0,0 +1,17 @@
+//go:build !windows
+
+package gtm
+
+import (
+	"errors"
+	"syscall"
+)
+
+func signalProcess(pid int32, sig syscall.Signal) error {
+	err := syscall.Kill(int(pid), sig)
+	if errors.Is(err, syscall.ESRCH) {
+		return ErrProcessNotFound
+	}
+	// EPERM matches fs.ErrPermission already
+	return err
+}
@@ -0,0 +1,35 @@
+package gtm
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"syscall"
+)
+
+// PROCESS_EXIT_CODE_KILLED is the exit code of a process ended by TerminateProcess
+const PROCESS_EXIT_CODE_KILLED = 1
+
+// signalProcess ends the process with TerminateProcess, since Windows has no signals and
+// no generic way to ask any process (console or GUI) to exit gracefully
+func signalProcess(pid int32, sig syscall.Signal) error {
+	if sig != syscall.SIGTERM && sig != syscall.SIGKILL {
+		return errors.ErrUnsupported
+	}
+	handle, err := openProcess(windows.PROCESS_TERMINATE, pid)
+	if err != nil {
+		return err
+	}
+	defer windows.CloseHandle(handle)
+	return windows.TerminateProcess(handle, PROCESS_EXIT_CODE_KILLED)
+}
+
+// openProcess opens a process with the `access` rights. It fails with
+// ErrProcessNotFound when the process doesn't exist, and with ERROR_ACCESS_DENIED
+// (which matches fs.ErrPermission) when the rights aren't granted
+func openProcess(access uint32, pid int32) (windows.Handle, error) {
+	handle, err := windows.OpenProcess(access, false, uint32(pid))
+	if errors.Is(err, windows.ERROR_INVALID_PARAMETER) {
+		return 0, ErrProcessNotFound
+	}
+	return handle, err
+}
@@ -0,0 +1,223 @@
+package gtm
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMART_UPDATE_INTERVAL is slow on purpose, since the written bytes of a drive barely
+// move in a minute and smartctl can wake up sleeping disks
+const SMART_UPDATE_INTERVAL = 10 * time.Minute
+
+// NVME_DATA_UNIT is the size of a "data unit" in the NVMe health log (1000 * 512 bytes)
+const NVME_DATA_UNIT = 512_000
+
+// DiskHealth is the write endurance of a SSD, read with smartctl. RatedTBW is the
+// manufacturer's endurance rating in terabytes written, from TBW_RATINGS in the config
+// or the built-in table. EnduranceUsedPercent comes from the drive itself when it
+// reports it (NVMe), otherwise it is calculated from the rating. Both it and
+// DaysLeft are -1 when unknown
+type DiskHealth struct {
+	Device               string  `json:"device"`
+	Model                string  `json:"model"`
+	SerialNumber         string  `json:"serial_number"`
+	IsSSD                bool    `json:"is_ssd"`
+	PowerOnHours         uint64  `json:"power_on_hours"`
+	BytesWritten         uint64  `json:"bytes_written"`
+	RatedTBW             float64 `json:"rated_tbw"`
+	EnduranceUsedPercent float64 `json:"endurance_used_percent"`
+	// BytesWrittenPerDay is the lifetime average, from BytesWritten and PowerOnHours
+	BytesWrittenPerDay float64 `json:"bytes_written_per_day"`
+	DaysLeft           float64 `json:"days_left"`
+}
+
+// tbwRatings maps a (case insensitive) model name substring to the rated endurance in
+// TB written. Ratings from TBW_RATINGS in the config take precedence
+var tbwRatings = map[string]float64{
+	"Samsung SSD 860 EVO 1TB":      600,
+	"Samsung SSD 870 EVO 1TB":      600,
+	"Samsung SSD 970 EVO Plus 1TB": 600,
+	"Samsung SSD 970 EVO Plus 2TB": 1200,
+	"Samsung SSD 980 PRO 1TB":      600,
+	"Samsung SSD 980 PRO 2TB":      1200,
+	"CT1000MX500SSD1":              360,
+	"CT2000MX500SSD1":              700,
+	"WDS100T3X0C":                  600,
+	"WD_BLACK SN850X 1000GB":       600,
+	"WD_BLACK SN850X 2000GB":       1200,
+}
+
+var (
+	diskHealth     []DiskHealth
+	lastFetchSMART time.Time
+	smartMut       sync.Mutex
+)
+
+// smartctlOutput is the part of `smartctl --json` output that is used
+type smartctlOutput struct {
+	Devices []struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"devices"`
+	ModelName    string `json:"model_name"`
+	SerialNumber string `json:"serial_number"`
+	RotationRate *int   `json:"rotation_rate"`
+	LogicalBlock uint64 `json:"logical_block_size"`
+	PowerOnTime  struct {
+		Hours uint64 `json:"hours"`
+	} `json:"power_on_time"`
+	NVMeHealth *struct {
+		DataUnitsWritten uint64  `json:"data_units_written"`
+		PercentageUsed   float64 `json:"percentage_used"`
+	} `json:"nvme_smart_health_information_log"`
+	ATAAttributes struct {
+		Table []struct {
+			ID  int `json:"id"`
+			Raw struct {
+				Value uint64 `json:"value"`
+			} `json:"raw"`
+		} `json:"table"`
+	} `json:"ata_smart_attributes"`
+}
+
+// GetDiskHealth returns the endurance of every drive smartctl can read. Reading SMART
+// data needs root/admin, without it the list is empty and an error is returned
+func GetDiskHealth() ([]DiskHealth, error) {
+	smartMut.Lock()
+	defer smartMut.Unlock()
+
+	if !IsCollectorEnabled(CollectorSMART) {
+		return nil, ErrCollectorDisabled
+	}
+	if GetClock().Since(lastFetchSMART) < SMART_UPDATE_INTERVAL && diskHealth != nil {
+		return diskHealth, nil
+	}
+	lastFetchSMART = GetClock().Now()
+	if diskHealth == nil {
+		// a failed scan is cached too, so smartctl isn't run again on every call
+		diskHealth = []DiskHealth{}
+	}
+
+	out, err := runCommand("smartctl", "--scan", "--json")
+	if err != nil {
+		collectorError(CollectorSMART, "Failed to scan for drives with smartctl!", err)
+		return diskHealth, err
+	}
+	var scan smartctlOutput
+	if err = json.Unmarshal(out, &scan); err != nil {
+		return diskHealth, err
+	}
+
+	health := []DiskHealth{}
+	var errs []error
+	for _, device := range scan.Devices {
+		// smartctl exits non-zero for any failing SMART check, even when the output is
+		//	fine, so the output is parsed whatever the exit code is. Disks in standby
+		//	aren't woken up, smartctl exits with 0 and no data instead
+		out, err := runCommand("smartctl", "--json", "--info", "--attributes",
+			"--nocheck=standby,0", "--device="+device.Type, device.Name)
+		var info smartctlOutput
+		jsonErr := json.Unmarshal(out, &info)
+		if err == nil && jsonErr == nil && info.ModelName == "" {
+			// the disk sleeps, so keep what it reported while it was awake
+			if i := slices.IndexFunc(diskHealth, func(h DiskHealth) bool {
+				return h.Device == device.Name
+			}); i >= 0 {
+				health = append(health, diskHealth[i])
+			}
+			continue
+		}
+		if jsonErr != nil || info.ModelName == "" {
+			errs = append(errs, &DiskError{Mountpoint: device.Name, Err: errors.Join(err,
+				jsonErr)})
+			continue
+		}
+		health = append(health, parseSmartctlHealth(device.Name, info))
+	}
+	if len(errs) > 0 {
+		collectorError(CollectorSMART, "Failed to read SMART data!", errors.Join(errs...))
+	}
+
+	diskHealth = health
+	return diskHealth, errors.Join(errs...)
+}
+
+// GetDiskHealthForDevice returns the health of the drive a partition (ie. /dev/sda1 or
+// /dev/nvme0n1p2) is on
+func GetDiskHealthForDevice(device string) (DiskHealth, bool) {
+	health, _ := GetDiskHealth()
+	for _, h := range health {
+		if strings.HasPrefix(device, h.Device) {
+			return h, true
+		}
+	}
+	return DiskHealth{}, false
+}
+
+func parseSmartctlHealth(device string, info smartctlOutput) DiskHealth {
+	health := DiskHealth{
+		Device:               device,
+		Model:                info.ModelName,
+		SerialNumber:         info.SerialNumber,
+		PowerOnHours:         info.PowerOnTime.Hours,
+		RatedTBW:             lookupTBWRating(info.ModelName),
+		EnduranceUsedPercent: -1,
+		DaysLeft:             -1,
+	}
+
+	if info.NVMeHealth != nil {
+		health.IsSSD = true
+		health.BytesWritten = info.NVMeHealth.DataUnitsWritten * NVME_DATA_UNIT
+		health.EnduranceUsedPercent = info.NVMeHealth.PercentageUsed
+	} else {
+		// ATA drives report a rotation rate of 0 for SSDs
+		health.IsSSD = info.RotationRate != nil && *info.RotationRate == 0
+		blockSize := max(info.LogicalBlock, 512)
+		for _, attr := range info.ATAAttributes.Table {
+			if attr.ID == 241 { // Total_LBAs_Written
+				health.BytesWritten = attr.Raw.Value * blockSize
+			}
+		}
+	}
+
+	if health.EnduranceUsedPercent < 0 && health.RatedTBW > 0 {
+		health.EnduranceUsedPercent = float64(health.BytesWritten) /
+			(health.RatedTBW * 1e12) * 100
+	}
+	if health.PowerOnHours > 0 {
+		health.BytesWrittenPerDay = float64(health.BytesWritten) /
+			(float64(health.PowerOnHours) / 24)
+	}
+	if health.EnduranceUsedPercent > 0 && health.PowerOnHours > 0 {
+		// the drive wears at the same average pace it has so far
+		daysUsed := float64(health.PowerOnHours) / 24
+		health.DaysLeft = max(daysUsed*(100-health.EnduranceUsedPercent)/
+			health.EnduranceUsedPercent, 0)
+	}
+
+	health.EnduranceUsedPercent = RoundStat(health.EnduranceUsedPercent)
+	health.BytesWrittenPerDay = RoundStat(health.BytesWrittenPerDay)
+	health.DaysLeft = RoundStat(health.DaysLeft)
+	return health
+}
+
+func lookupTBWRating(model string) float64 {
+	if rating, ok := Cfg.TBWRatings[model]; ok {
+		if tbw, err := strconv.ParseFloat(rating, 64); err == nil {
+			return tbw
+		}
+		logger().Error("Failed to parse float: TBW_RATINGS ... ignoring: " + model + "=" + rating)
+	}
+	model = strings.ToLower(model)
+	for name, tbw := range tbwRatings {
+		if strings.Contains(model, strings.ToLower(name)) {
+			return tbw
+		}
+	}
+	return 0
+}
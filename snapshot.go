@@ -0,0 +1,122 @@
+package gtm
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Snapshot is the current stats of every subsystem at one point in time, the building
+// block for exporters and remote monitoring. Unlike a Capture, it has no history: CPU is
+// the latest usage sample only
+type Snapshot struct {
+	// SchemaVersion is SCHEMA_VERSION of the gtm that took the snapshot
+	SchemaVersion int          `json:"schema_version"`
+	Timestamp     time.Time    `json:"timestamp"`
+	Host          HostInfo     `json:"host"`
+	CPU           CPUStats     `json:"cpu"`
+	Memory        *MemoryStats `json:"memory"`
+	Disks         []DiskStats  `json:"disks"`
+	Network       []NetStats   `json:"network"`
+	GPU           []GPUStats   `json:"gpu,omitempty"`
+	Processes     []ProcStats  `json:"processes,omitempty"`
+	// Plugins holds the metrics of every plugin, keyed by plugin name
+	Plugins map[string][]PluginMetric `json:"plugins,omitempty"`
+	// Errors holds the collectors that failed, as "collector: error"
+	Errors []string `json:"errors,omitempty"`
+}
+
+// GetSnapshot returns a snapshot of the default Monitor, see Monitor.Snapshot
+func GetSnapshot() Snapshot { return defaultMonitor.Snapshot() }
+
+// Snapshot collects every subsystem concurrently, so the snapshot takes as long as the
+// slowest collector instead of all of them combined. Cached stats are used while they
+// are fresh. Disks and network are filtered like DisksStats and NetworkStats. Processes
+// come from the package level ExportProcesses, since processes aren't per Monitor
+func (m *Monitor) Snapshot() Snapshot {
+	var (
+		snapshot = Snapshot{SchemaVersion: SCHEMA_VERSION, Timestamp: GetClock().Now()}
+		mut      sync.Mutex
+		wg       sync.WaitGroup
+	)
+	collect := func(collector Collector, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn()
+			// disabled collectors didn't fail, they're listed in the capabilities
+			if err != nil && !errors.Is(err, ErrCollectorDisabled) {
+				mut.Lock()
+				snapshot.Errors = append(snapshot.Errors, string(collector)+": "+err.Error())
+				mut.Unlock()
+			}
+		}()
+	}
+
+	// every collector only writes its own field, so only Errors and Plugins need the
+	//	mutex
+	collect(CollectorHost, func() error {
+		info, err := m.HostInfo()
+		snapshot.Host = exportHostInfo(info)
+		return err
+	})
+	collect(CollectorCPU, func() (err error) {
+		snapshot.CPU, err = m.CPUStats()
+		return err
+	})
+	collect(CollectorMemory, func() error {
+		stats, err := m.MemoryStats()
+		snapshot.Memory = newMemoryStats(stats)
+		return err
+	})
+	collect(CollectorDisk, func() (err error) {
+		snapshot.Disks, err = m.DisksStats()
+		return err
+	})
+	collect(CollectorNetwork, func() (err error) {
+		snapshot.Network, err = m.NetworkStats()
+		return err
+	})
+	if m.gpu.found.Load() {
+		collect(CollectorGPU, func() (err error) {
+			snapshot.GPU, err = m.GPUStats()
+			return err
+		})
+	}
+	collect(CollectorProcesses, func() (err error) {
+		snapshot.Processes, err = ExportProcesses()
+		return err
+	})
+	for _, name := range m.Plugins() {
+		collect(Collector(name), func() error {
+			metrics, err := m.PluginMetrics(name)
+			mut.Lock()
+			if snapshot.Plugins == nil {
+				snapshot.Plugins = map[string][]PluginMetric{}
+			}
+			snapshot.Plugins[name] = metrics
+			mut.Unlock()
+			return err
+		})
+	}
+	wg.Wait()
+
+	return snapshot
+}
+
+func (s Snapshot) JSON(indent bool) string {
+	if indent {
+		out, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			logger().Error("Failed to marshal indent JSON from struct Snapshot{} !" + err.Error())
+		}
+		return string(out)
+	} else {
+		out, err := json.Marshal(s)
+		if err != nil {
+			logger().Error("Failed to marshal JSON from struct Snapshot{} !" + err.Error())
+		}
+		return string(out)
+	}
+}
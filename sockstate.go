@@ -0,0 +1,74 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/net"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// SOCKET_STATES_UPDATE_INTERVAL limits how often sockets are counted. Counting is much
+// cheaper than GetConnections, since no process is looked up, so it can run every second
+const SOCKET_STATES_UPDATE_INTERVAL = time.Second
+
+// SocketStates counts the TCP sockets (IPv4 and IPv6) in every state, and the UDP
+// sockets. A growing number of CLOSE_WAIT or TIME_WAIT sockets usually means a server
+// is leaking connections
+type SocketStates struct {
+	TCP      map[ConnState]int `json:"tcp"`
+	TCPTotal int               `json:"tcp_total"`
+	UDP      int               `json:"udp"`
+}
+
+var (
+	socketStates        SocketStates
+	lastFetchSockStates time.Time
+	sockStateMut        sync.Mutex
+)
+
+// GetSocketStates returns the number of sockets per state, without reading the full
+// connection table
+func GetSocketStates() (SocketStates, error) {
+	sockStateMut.Lock()
+	defer sockStateMut.Unlock()
+
+	if GetClock().Since(lastFetchSockStates) < SOCKET_STATES_UPDATE_INTERVAL &&
+		socketStates.TCP != nil {
+		return socketStates, nil
+	}
+
+	states := SocketStates{TCP: map[ConnState]int{}}
+	if err := countSocketStates(&states); err != nil {
+		collectorError(CollectorNetwork, "Failed to count sockets!", err)
+		return socketStates, err
+	}
+	for _, count := range states.TCP {
+		states.TCPTotal += count
+	}
+
+	socketStates = states
+	lastFetchSockStates = GetClock().Now()
+	return socketStates, nil
+}
+
+// Count returns the number of TCP sockets in a state
+func (s SocketStates) Count(state ConnState) int {
+	return s.TCP[state]
+}
+
+// countConnectionStates is the fallback where sockets can't be counted directly, which
+// reads the connection table without looking up processes
+func countConnectionStates(states *SocketStates) error {
+	stats, err := net.ConnectionsWithoutUids("inet")
+	if err != nil {
+		return err
+	}
+	for _, stat := range stats {
+		if stat.Type == syscall.SOCK_DGRAM {
+			states.UDP++
+		} else {
+			states.TCP[convertConnState(stat.Status)]++
+		}
+	}
+	return nil
+}
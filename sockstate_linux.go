@@ -0,0 +1,65 @@
+package gtm
+
+import (
+	"bufio"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+)
+
+// procNetStates maps the hex state ("st" column) of /proc/net/tcp to a ConnState
+var procNetStates = map[string]ConnState{
+	"01": ConnEstablished,
+	"02": ConnSynSent,
+	"03": ConnSynRecv,
+	"04": ConnFinWait1,
+	"05": ConnFinWait2,
+	"06": ConnTimeWait,
+	"07": ConnClose,
+	"08": ConnCloseWait,
+	"09": ConnLastAck,
+	"0A": ConnListen,
+	"0B": ConnClosing,
+}
+
+// countSocketStates only reads the state column of /proc/net/{tcp,tcp6,udp,udp6}, and
+// unlike net.Connections() doesn't walk /proc/*/fd to find the owners
+func countSocketStates(states *SocketStates) error {
+	var errs []error
+	for _, file := range []string{"tcp", "tcp6"} {
+		err := countProcNet("/proc/net/"+file, func(state string) {
+			if connState, ok := procNetStates[state]; ok {
+				states.TCP[connState]++
+			}
+		})
+		errs = append(errs, err)
+	}
+	for _, file := range []string{"udp", "udp6"} {
+		errs = append(errs, countProcNet("/proc/net/"+file, func(string) { states.UDP++ }))
+	}
+	return errors.Join(errs...)
+}
+
+// countProcNet calls `count` with the state of every socket in a /proc/net table. A
+// missing table (ie. tcp6 with IPv6 disabled) isn't an error
+func countProcNet(path string, count func(state string)) error {
+	file, err := os.Open(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Scan() // skip the header
+	for scanner.Scan() {
+		// sl local_address rem_address st ...
+		fields := strings.Fields(scanner.Text())
+		if len(fields) > 3 {
+			count(fields[3])
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !windows
+
+package gtm
+
+func countSocketStates(states *SocketStates) error {
+	return countConnectionStates(states)
+}
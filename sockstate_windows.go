@@ -0,0 +1,69 @@
+package gtm
+
+import (
+	"errors"
+	"golang.org/x/sys/windows"
+	"unsafe"
+)
+
+const (
+	udpTableBasic = 0 // UDP_TABLE_BASIC
+	// mibTCP6RowOwnerPIDSize is the size of MIB_TCP6ROW_OWNER_PID, and
+	//	mibTCP6RowStateOffset the offset of its dwState
+	mibTCP6RowOwnerPIDSize = 56
+	mibTCP6RowStateOffset  = 48
+)
+
+var procGetExtendedUDPTable = modIphlpapi.NewProc("GetExtendedUdpTable")
+
+// mibTCPStates maps MIB_TCP_STATE to a ConnState
+var mibTCPStates = map[uint32]ConnState{
+	1:  ConnClose,
+	2:  ConnListen,
+	3:  ConnSynSent,
+	4:  ConnSynRecv,
+	5:  ConnEstablished,
+	6:  ConnFinWait1,
+	7:  ConnFinWait2,
+	8:  ConnCloseWait,
+	9:  ConnClosing,
+	10: ConnLastAck,
+	11: ConnTimeWait,
+	12: ConnClose, // DELETE_TCB
+}
+
+// countSocketStates reads the TCP and UDP tables without resolving the owning processes
+func countSocketStates(states *SocketStates) error {
+	var errs []error
+
+	rows, err := getTCPTableOwnerPID()
+	errs = append(errs, err)
+	for _, row := range rows {
+		states.TCP[mibTCPStates[row.State]]++
+	}
+
+	// MIB_TCP6TABLE_OWNER_PID: DWORD dwNumEntries, then the rows
+	buf, err := getExtendedTable(procGetExtendedTCPTable, windows.AF_INET6,
+		tcpTableOwnerPIDAll)
+	errs = append(errs, err)
+	if err == nil {
+		count := int(*(*uint32)(unsafe.Pointer(&buf[0])))
+		for i := range count {
+			offset := 4 + i*mibTCP6RowOwnerPIDSize + mibTCP6RowStateOffset
+			if offset+4 > len(buf) {
+				break
+			}
+			state := *(*uint32)(unsafe.Pointer(&buf[offset]))
+			states.TCP[mibTCPStates[state]]++
+		}
+	}
+
+	for _, family := range []uint32{windows.AF_INET, windows.AF_INET6} {
+		buf, err := getExtendedTable(procGetExtendedUDPTable, family, udpTableBasic)
+		errs = append(errs, err)
+		if err == nil {
+			states.UDP += int(*(*uint32)(unsafe.Pointer(&buf[0])))
+		}
+	}
+	return errors.Join(errs...)
+}
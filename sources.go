@@ -0,0 +1,234 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/cpu"
+	"github.com/shirou/gopsutil/v4/disk"
+	"github.com/shirou/gopsutil/v4/host"
+	"github.com/shirou/gopsutil/v4/mem"
+	"github.com/shirou/gopsutil/v4/net"
+)
+
+// The sources are where a Monitor reads the hardware from. By default gopsutil (with
+// the native fallbacks) and the SMI tools of the GPU vendors are used. Swap them with
+// the With*Source options to simulate hardware, ie. in tests or to replay a recording
+
+// CPUSource reads the CPUs
+type CPUSource interface {
+	Info() ([]cpu.InfoStat, error)
+	// Percent returns the total CPU usage since the previous call, like cpu.Percent(0,
+	//	false)
+	Percent() ([]float64, error)
+}
+
+// CPUCoreSource is a CPUSource that also reads the usage of every core. The usage per
+// core is only collected from the CPU sources that implement it
+type CPUCoreSource interface {
+	CPUSource
+	// PercentPerCore returns the usage of every logical core since the previous call,
+	//	like cpu.Percent(0, true)
+	PercentPerCore() ([]float64, error)
+}
+
+// DiskSource reads the partitions and their usage
+type DiskSource interface {
+	// Partitions lists the physical partitions, or every partition when `all` is true
+	Partitions(all bool) ([]disk.PartitionStat, error)
+	Usage(mountpoint string) (*disk.UsageStat, error)
+	// IOCounters returns the IO counters keyed by device name (ie. "sda1" or "C:")
+	IOCounters() (map[string]disk.IOCountersStat, error)
+}
+
+// GPUSource reads the GPUs. `vendor` is what Probe returned
+type GPUSource interface {
+	// Probe returns the vendor of the GPUs ("nvidia" or "amd"), or "" when there are none
+	Probe() string
+	// Stats returns the name of the GPUs and the stats of every GPU. It fails with
+	//	ErrUnsupported for vendors it can't read. When only some GPUs fail, it returns
+	//	the stats of every GPU along with the error of the failed ones
+	Stats(vendor string) (string, []GPUStats, error)
+	// ProcessMemory returns the GPU memory (in bytes) each process uses, or nil when it
+	//	can't be read for the vendor
+	ProcessMemory(vendor string) (map[int32]uint64, error)
+}
+
+// HostSource reads the host info
+type HostSource interface {
+	Info() (*host.InfoStat, error)
+}
+
+// MemorySource reads the memory usage
+type MemorySource interface {
+	VirtualMemory() (*mem.VirtualMemoryStat, error)
+}
+
+// NetworkSource reads the counters of every network interface
+type NetworkSource interface {
+	IOCounters() ([]net.IOCountersStat, error)
+}
+
+// sources of a Monitor
+type sources struct {
+	cpu  CPUSource
+	disk DiskSource
+	gpu  GPUSource
+	host HostSource
+	mem  MemorySource
+	net  NetworkSource
+}
+
+func defaultSources() sources {
+	return sources{
+		cpu:  gopsutilSource{},
+		disk: gopsutilSource{},
+		gpu:  smiGPUSource{},
+		host: hostSource{},
+		mem:  gopsutilSource{},
+		net:  netSource{},
+	}
+}
+
+// WithCPUSource reads the CPUs from `source`
+func WithCPUSource(source CPUSource) MonitorOption {
+	return func(m *Monitor) { m.sources.cpu = source }
+}
+
+// WithDiskSource reads the disks from `source`
+func WithDiskSource(source DiskSource) MonitorOption {
+	return func(m *Monitor) { m.sources.disk = source }
+}
+
+// WithGPUSource reads the GPUs from `source`
+func WithGPUSource(source GPUSource) MonitorOption {
+	return func(m *Monitor) { m.sources.gpu = source }
+}
+
+// WithHostSource reads the host info from `source`
+func WithHostSource(source HostSource) MonitorOption {
+	return func(m *Monitor) { m.sources.host = source }
+}
+
+// WithMemorySource reads the memory usage from `source`
+func WithMemorySource(source MemorySource) MonitorOption {
+	return func(m *Monitor) { m.sources.mem = source }
+}
+
+// WithNetworkSource reads the network interfaces from `source`
+func WithNetworkSource(source NetworkSource) MonitorOption {
+	return func(m *Monitor) { m.sources.net = source }
+}
+
+//// gopsutil ////########################################################################
+
+// gopsutilSource reads the hardware with gopsutil, falling back to the native APIs
+// where gopsutil fails (see native_windows.go)
+type gopsutilSource struct{}
+
+func (gopsutilSource) Info() ([]cpu.InfoStat, error) {
+	cInfo, err := cpu.Info()
+	if err != nil {
+		logger().Debug("Failed to retrieve cpu.Info(), trying the native API! " + err.Error())
+		if native, nativeErr := nativeCPUInfo(); nativeErr == nil {
+			return native, nil
+		}
+		return nil, err
+	}
+	return cInfo, nil
+}
+
+func (gopsutilSource) Percent() ([]float64, error) { return cpu.Percent(0, false) }
+
+func (gopsutilSource) PercentPerCore() ([]float64, error) { return cpu.Percent(0, true) }
+
+func (gopsutilSource) Partitions(all bool) ([]disk.PartitionStat, error) {
+	return disk.Partitions(all)
+}
+
+func (gopsutilSource) Usage(mountpoint string) (*disk.UsageStat, error) {
+	return disk.Usage(mountpoint)
+}
+
+func (gopsutilSource) IOCounters() (map[string]disk.IOCountersStat, error) {
+	return disk.IOCounters()
+}
+
+func (gopsutilSource) VirtualMemory() (*mem.VirtualMemoryStat, error) {
+	mInfo, err := mem.VirtualMemory()
+	if err != nil {
+		logger().Debug("Failed to retrieve mem.VirtualMemory(), trying the native API! " +
+			err.Error())
+		if native, nativeErr := nativeMemoryStats(); nativeErr == nil {
+			return native, nil
+		}
+		return nil, err
+	}
+	return mInfo, nil
+}
+
+// hostSource is the HostSource of gopsutil. It's separate from gopsutilSource, since
+// both CPUSource and HostSource have an Info method
+type hostSource struct{}
+
+func (hostSource) Info() (*host.InfoStat, error) {
+	hInfo, err := host.Info()
+	if err != nil {
+		logger().Debug("Failed to retrieve host.Info(), trying the native API! " + err.Error())
+		if native, nativeErr := nativeHostInfo(); nativeErr == nil {
+			return native, nil
+		}
+		return nil, err
+	}
+	return hInfo, nil
+}
+
+// netSource is the NetworkSource of gopsutil. It's separate from gopsutilSource, since
+// both DiskSource and NetworkSource have an IOCounters method
+type netSource struct{}
+
+func (netSource) IOCounters() ([]net.IOCountersStat, error) { return net.IOCounters(true) }
+
+//// SMI ////#############################################################################
+
+// smiGPUSource reads the GPUs with nvidia-smi. rocm-smi is only used to find AMD GPUs
+type smiGPUSource struct{}
+
+func (smiGPUSource) Probe() string {
+	if _, err := runCommand("nvidia-smi"); err == nil {
+		return "nvidia"
+	}
+	if _, err := runCommand("rocm-smi"); err == nil {
+		return "amd"
+	}
+	return ""
+}
+
+func (smiGPUSource) Stats(vendor string) (string, []GPUStats, error) {
+	switch vendor {
+	case "nvidia":
+		data, err := runCommand(
+			"nvidia-smi",
+			"--query-gpu=index,name,utilization.gpu,memory.used,memory.total,"+
+				"power.draw,temperature.gpu",
+			"--format=csv,noheader,nounits")
+		if err != nil {
+			return "", nil, err
+		}
+		return parseGPUNvidiaStats(data)
+	case "amd":
+		// TODO: write rocm-smi code for AMD gpu detection and data parsing
+		return "", nil, ErrUnsupported
+	default:
+		return "", nil, ErrNoGPU
+	}
+}
+
+func (smiGPUSource) ProcessMemory(vendor string) (map[int32]uint64, error) {
+	if vendor != "nvidia" {
+		return nil, nil
+	}
+	data, err := runCommand("nvidia-smi", "--query-compute-apps=pid,used_memory",
+		"--format=csv,noheader,nounits")
+	if err != nil {
+		return nil, err
+	}
+	return parseGPUNvidiaProcesses(data), nil
+}
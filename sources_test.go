@@ -0,0 +1,40 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/cpu"
+)
+
+// The fake sources return fixed stats, so tests and benchmarks don't depend on the
+// hardware they run on (see the With*Source options)
+
+// fakeCPUSource is a CPU with 4 cores
+type fakeCPUSource struct{}
+
+func (fakeCPUSource) Info() ([]cpu.InfoStat, error) {
+	return []cpu.InfoStat{{CPU: 0, VendorID: "GenuineIntel", ModelName: "Fake CPU",
+		Cores: 4, Mhz: 3000}}, nil
+}
+
+func (fakeCPUSource) Percent() ([]float64, error) { return []float64{42.125}, nil }
+
+func (fakeCPUSource) PercentPerCore() ([]float64, error) {
+	return []float64{12.5, 25, 50, 80.5}, nil
+}
+
+// fakeGPUSource is 2 NVIDIA GPUs
+type fakeGPUSource struct{}
+
+func (fakeGPUSource) Probe() string { return "nvidia" }
+
+func (fakeGPUSource) Stats(vendor string) (string, []GPUStats, error) {
+	return "NVIDIA Fake GPU", []GPUStats{
+		{Id: 0, Load: 35, MemoryUsage: 2048, MemoryTotal: 8192, Power: 120.5,
+			Temperature: 61},
+		{Id: 1, Load: 0, MemoryUsage: 512, MemoryTotal: 8192, Power: 15.25,
+			Temperature: 38},
+	}, nil
+}
+
+func (fakeGPUSource) ProcessMemory(vendor string) (map[int32]uint64, error) {
+	return map[int32]uint64{1234: 1 << 30}, nil
+}
@@ -0,0 +1,111 @@
+package gtm
+
+import (
+	"net"
+	"strconv"
+	"strings"
+)
+
+// STATSD_DEFAULT_PREFIX prefixes the metrics sent to StatsD when STATSD_PREFIX isn't set
+const STATSD_DEFAULT_PREFIX = "gtm"
+
+// statsdMaxPacketSize keeps the datagrams below the MTU of most networks, so metrics
+// aren't lost to fragmentation
+const statsdMaxPacketSize = 1432
+
+// StatsDOptions configures the metrics sent by StartStatsD
+type StatsDOptions struct {
+	// Prefix is prepended to the name of every metric, ie. "gtm" sends
+	//	"gtm.cpu.usage_percent"
+	Prefix string
+	// DogStatsD sends the device of per-device metrics as a tag (ie. "mountpoint:/")
+	//	instead of in the name, along with its alias (ie. "alias:Backups") and Tags.
+	//	Vanilla StatsD has no tags, so aliases aren't sent
+	DogStatsD bool
+	// Tags are added to every metric when DogStatsD is set, ie. "env:prod"
+	Tags []string
+}
+
+// StartStatsD sends a gauge to the StatsD server at `addr` (over UDP) for every metric of
+// a collector every time the collector fetches, ie. every interval once the Monitor is
+// started (see Start). Per-device metrics are named after their device in vanilla
+// StatsD, ie. "gtm.disk.mnt_data.used_percent". Call the returned function to stop
+// sending; it also stops once the Monitor is shut down
+func (m *Monitor) StartStatsD(addr string, options StatsDOptions) (stop func(),
+	err error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	updates, unsubscribe := m.Subscribe()
+	go func() {
+		defer conn.Close()
+		packet := make([]byte, 0, statsdMaxPacketSize)
+		for update := range updates {
+			for _, sample := range m.samplesOf(update.Collector, update.Stats) {
+				line := statsdLine(sample, options)
+				if len(packet) > 0 && len(packet)+1+len(line) > statsdMaxPacketSize {
+					packet = m.sendStatsD(conn, packet)
+				}
+				if len(packet) > 0 {
+					packet = append(packet, '\n')
+				}
+				packet = append(packet, line...)
+			}
+			packet = m.sendStatsD(conn, packet)
+		}
+	}()
+	m.log().Info("Sending metrics to StatsD at " + addr + " ...")
+	return unsubscribe, nil
+}
+
+// StartStatsD sends the metrics of the default Monitor to StatsD, see Monitor.StartStatsD
+func StartStatsD(addr string, options StatsDOptions) (stop func(), err error) {
+	return defaultMonitor.StartStatsD(addr, options)
+}
+
+// sendStatsD sends a packet and returns it emptied. StatsD is fire and forget, so
+// failures (ie. no server listening) are only logged in debug
+func (m *Monitor) sendStatsD(conn net.Conn, packet []byte) []byte {
+	if len(packet) == 0 {
+		return packet
+	}
+	if _, err := conn.Write(packet); err != nil {
+		m.log().Debug("Failed to send metrics to StatsD! " + err.Error())
+	}
+	return packet[:0]
+}
+
+// statsdLine formats a sample as a StatsD gauge, ie. "gtm.cpu.usage_percent:12.5|g"
+func statsdLine(sample MetricSample, options StatsDOptions) string {
+	name := sample.Name
+	var tags []string
+	if options.DogStatsD {
+		tags = options.Tags
+		desc, _, _ := LookupMetric(sample.Name)
+		if label := sample.LabelValue(desc); label != "" {
+			tags = append(tags[:len(tags):len(tags)],
+				desc.Label+":"+statsdTagValue(label))
+		}
+		if sample.Alias != "" {
+			tags = append(tags[:len(tags):len(tags)],
+				"alias:"+statsdTagValue(sample.Alias))
+		}
+	} else if sample.Device != "" {
+		name = samplePath(sample)
+	}
+	if options.Prefix != "" {
+		name = options.Prefix + "." + name
+	}
+
+	line := name + ":" + strconv.FormatFloat(sample.Value, 'f', -1, 64) + "|g"
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	return line
+}
+
+// statsdTagValue removes the characters that separate the tags of DogStatsD
+func statsdTagValue(value string) string {
+	return strings.NewReplacer(",", "_", "|", "_", "#", "_", "\n", "_").Replace(value)
+}
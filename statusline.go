@@ -0,0 +1,114 @@
+package gtm
+
+import (
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// STATUS_LINE_TEMPLATE is the default status line, ie.
+// "CPU 23% | MEM 61% | ↓12Mbps ↑3Mbps | GPU 45% 62°C"
+const STATUS_LINE_TEMPLATE = `CPU {{.CPU | pct}} | MEM {{.Memory | pct}} | ` +
+	`↓{{.Download | bits}} ↑{{.Upload | bits}}` +
+	`{{if .HasGPU}} | GPU {{.GPU | pct}} {{.GPUTemp | temp}}{{end}}`
+
+// StatusLineData is passed to the status line template. Percentages are 0-100, network
+// rates are bytes per second summed over every interface except loopback, and GPUTemp
+// is in the configured unit (see CELSIUS in the config)
+type StatusLineData struct {
+	Hostname string
+	CPU      float64
+	Memory   float64
+	Download float64
+	Upload   float64
+	HasGPU   bool
+	GPU      float64
+	GPUTemp  float64
+	Pinned   string
+}
+
+var statusLineFuncs = template.FuncMap{
+	// pct formats a percentage without decimals, ie. "23%"
+	"pct": func(v float64) string {
+		return strconv.FormatFloat(v, 'f', 0, 64) + "%"
+	},
+	// bits formats a byte rate as a bit rate, ie. "12Mbps"
+	"bits": formatBitsPerSec,
+	// bytes formats a byte rate, ie. "1.5 MiB/s"
+	"bytes": formatBytesPerSec,
+	// rate formats a byte rate with the unit in NET_UNITS, ie. "12Mbps" or "1.5 MiB/s"
+	"rate": FormatNetRate,
+	// temp formats a temperature with its unit, ie. "62°C"
+	"temp": func(v float64) string {
+		if Cfg.Celsius {
+			return strconv.FormatFloat(v, 'f', 0, 64) + "°C"
+		}
+		return strconv.FormatFloat(v, 'f', 0, 64) + "°F"
+	},
+}
+
+// ParseStatusLineTemplate parses a user template (text/template syntax) for the status
+// line. An empty template uses STATUS_LINE_TEMPLATE
+func ParseStatusLineTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = STATUS_LINE_TEMPLATE
+	}
+	return template.New("status").Funcs(statusLineFuncs).Parse(text)
+}
+
+// GetStatusLineData collects the current values used by the status line
+func GetStatusLineData() StatusLineData {
+	data := StatusLineData{Hostname: GetHostname(), HasGPU: hasGPU()}
+
+	if stats, err := GetCPUStats(); err == nil {
+		data.CPU = stats.UsagePercent
+	}
+	if memStats, _ := GetMemoryStats(); memStats != nil {
+		data.Memory = memStats.UsedPercent
+	}
+	netStats, _ := GetNetworkStats()
+	for _, iface := range netStats {
+		if info, ok := GetNetworkInterface(iface.Name); ok && info.IsLoopback {
+			continue
+		}
+		data.Download += iface.DownloadBytesPerSec
+		data.Upload += iface.UploadBytesPerSec
+	}
+	if hasGPU() {
+		if stats, _ := GetGPUStats(); len(stats) > 0 {
+			gpu := stats[len(stats)-1]
+			data.GPU = gpu.Load
+			data.GPUTemp = float64(gpu.Temperature)
+			if !Cfg.Celsius {
+				data.GPUTemp = data.GPUTemp*9/5 + 32
+			}
+		}
+	}
+	data.Pinned = FormatPinnedMetrics()
+	return data
+}
+
+// RenderStatusLine renders a single status line with the given template
+func RenderStatusLine(tmpl *template.Template) (string, error) {
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, GetStatusLineData()); err != nil {
+		return "", err
+	}
+	// a status bar only has room for a single line
+	return strings.ReplaceAll(sb.String(), "\n", " "), nil
+}
+
+// formatBitsPerSec formats a byte rate as a decimal bit rate, ie. "12Mbps"
+func formatBitsPerSec(bytesPerSec float64) string {
+	bitsPerSec := bytesPerSec * 8
+	units := []string{"bps", "Kbps", "Mbps", "Gbps", "Tbps"}
+	unit := 0
+	for bitsPerSec >= 1000 && unit < len(units)-1 {
+		bitsPerSec /= 1000
+		unit++
+	}
+	if unit == 0 || bitsPerSec >= 10 {
+		return strconv.FormatFloat(bitsPerSec, 'f', 0, 64) + units[unit]
+	}
+	return strconv.FormatFloat(bitsPerSec, 'f', 1, 64) + units[unit]
+}
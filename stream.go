@@ -0,0 +1,294 @@
+package gtm
+
+import (
+	"slices"
+	"sync"
+	"time"
+)
+
+const (
+	// STREAM_BUFFER_SIZE is the default number of values buffered per subscriber
+	STREAM_BUFFER_SIZE = 4
+	// STREAM_SLOW_THRESHOLD is the number of consecutive full-buffer publishes before a
+	//	subscriber's update interval is doubled, and the number of consecutive on-time
+	//	deliveries before it is halved again
+	STREAM_SLOW_THRESHOLD = 3
+	// STREAM_MAX_INTERVAL is the slowest a lagging subscriber will be throttled to
+	STREAM_MAX_INTERVAL = 30 * time.Second
+)
+
+// Broadcaster fans out published values to any number of subscribers (ie. WebSocket or
+// gRPC clients) without ever blocking the publisher. A subscriber that can't keep up is
+// throttled: while its buffer is full the oldest buffered value is replaced by the
+// newest one, and its update interval backs off until it catches up again. Values
+// published between two updates of a throttled subscriber are coalesced, so it always
+// receives the most recent value: the last one is delivered once the subscriber's
+// interval has passed, even when nothing is published anymore
+type Broadcaster[T any] struct {
+	mut         sync.Mutex
+	subscribers map[*Subscriber[T]]struct{}
+}
+
+// Subscriber receives values from a Broadcaster on C
+type Subscriber[T any] struct {
+	C <-chan T
+
+	ch           chan T
+	cancel       func()
+	filter       func(T) bool
+	broadcaster  *Broadcaster[T]
+	baseInterval time.Duration
+	interval     time.Duration
+	lastSent     time.Time
+	// key groups the values that replace each other while they're pending, see
+	//	SubscribeKeyed
+	key func(T) string
+	// pending holds the values waiting for the subscriber to be due (see flushLater),
+	//	at most one per key
+	pending  []T
+	flushing bool
+	// done is closed once the subscriber is cancelled, to stop a flush waiting
+	done       chan struct{}
+	slowStreak int
+	fastStreak int
+	dropped    uint64
+	coalesced  uint64
+}
+
+// SubscriberStats describes how far behind a subscriber is
+type SubscriberStats struct {
+	Interval  time.Duration `json:"interval"`
+	Dropped   uint64        `json:"dropped"`
+	Coalesced uint64        `json:"coalesced"`
+}
+
+func NewBroadcaster[T any]() *Broadcaster[T] {
+	return &Broadcaster[T]{subscribers: map[*Subscriber[T]]struct{}{}}
+}
+
+// Subscribe registers a new subscriber. `interval` is the fastest rate it wants values
+// at (0 for every published value), and `bufferSize` is the number of values buffered
+// before backpressure kicks in (0 uses STREAM_BUFFER_SIZE). Call the returned cancel
+// function to unsubscribe; it closes C
+func (b *Broadcaster[T]) Subscribe(interval time.Duration, bufferSize int) (*Subscriber[T],
+	func()) {
+	return b.SubscribeFunc(interval, bufferSize, nil)
+}
+
+// SubscribeFunc is Subscribe, but the subscriber only receives the values `filter`
+// returns true for. A nil filter receives every value
+func (b *Broadcaster[T]) SubscribeFunc(interval time.Duration, bufferSize int,
+	filter func(T) bool) (*Subscriber[T], func()) {
+	return b.SubscribeKeyed(interval, bufferSize, filter, nil)
+}
+
+// SubscribeKeyed is SubscribeFunc, but values only replace the pending value with the
+// same `key` (ie. the collector of an Update) while the subscriber is throttled, so it
+// always receives the most recent value of every key. Values are never dropped from a
+// full buffer either: they wait, coalesced by key, until the subscriber catches up. A
+// nil key is SubscribeFunc
+func (b *Broadcaster[T]) SubscribeKeyed(interval time.Duration, bufferSize int,
+	filter func(T) bool, key func(T) string) (*Subscriber[T], func()) {
+
+	if bufferSize <= 0 {
+		bufferSize = STREAM_BUFFER_SIZE
+	}
+	ch := make(chan T, bufferSize)
+	s := &Subscriber[T]{C: ch, ch: ch, filter: filter, key: key, broadcaster: b,
+		baseInterval: interval, interval: interval, done: make(chan struct{})}
+
+	b.mut.Lock()
+	b.subscribers[s] = struct{}{}
+	b.mut.Unlock()
+
+	var once sync.Once
+	s.cancel = func() {
+		once.Do(func() {
+			b.mut.Lock()
+			delete(b.subscribers, s)
+			b.mut.Unlock()
+			close(s.done)
+			close(s.ch)
+		})
+	}
+	return s, s.cancel
+}
+
+// Close unsubscribes every subscriber, closing their C, so consumers ranging over it
+// return. Calling their cancel function afterwards does nothing
+func (b *Broadcaster[T]) Close() {
+	b.mut.Lock()
+	cancels := make([]func(), 0, len(b.subscribers))
+	for s := range b.subscribers {
+		cancels = append(cancels, s.cancel)
+	}
+	b.mut.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
+
+// Publish delivers `value` to every subscriber without blocking
+func (b *Broadcaster[T]) Publish(value T) {
+	now := GetClock().Now()
+
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	for s := range b.subscribers {
+		s.offer(now, value)
+	}
+}
+
+// Len returns the number of subscribers
+func (b *Broadcaster[T]) Len() int {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+	return len(b.subscribers)
+}
+
+// Stats returns the current throttling state of every subscriber
+func (b *Broadcaster[T]) Stats() []SubscriberStats {
+	b.mut.Lock()
+	defer b.mut.Unlock()
+
+	stats := make([]SubscriberStats, 0, len(b.subscribers))
+	for s := range b.subscribers {
+		stats = append(stats, SubscriberStats{
+			Interval:  s.interval,
+			Dropped:   s.dropped,
+			Coalesced: s.coalesced,
+		})
+	}
+	return stats
+}
+
+// Stats returns the current throttling state of the subscriber
+func (s *Subscriber[T]) Stats() SubscriberStats {
+	s.broadcaster.mut.Lock()
+	defer s.broadcaster.mut.Unlock()
+	return SubscriberStats{Interval: s.interval, Dropped: s.dropped, Coalesced: s.coalesced}
+}
+
+// offer is always called with the broadcaster locked
+func (s *Subscriber[T]) offer(now time.Time, value T) {
+	if s.filter != nil && !s.filter(value) {
+		return
+	}
+	s.hold(value)
+	if s.interval > 0 && now.Sub(s.lastSent) < s.interval {
+		// Too soon for this subscriber. The value is delivered once it's due, unless a
+		//	newer value replaces it first
+		s.flushLater(s.lastSent.Add(s.interval).Sub(now))
+		return
+	}
+	s.flush(now)
+}
+
+// hold adds a value to the pending values, replacing the pending value of its key
+func (s *Subscriber[T]) hold(value T) {
+	for i, pending := range s.pending {
+		if s.key == nil || s.key(pending) == s.key(value) {
+			// the pending value is older, it's never delivered
+			s.pending[i] = value
+			s.coalesced++
+			return
+		}
+	}
+	s.pending = append(s.pending, value)
+}
+
+// flushLater delivers the pending values after `wait`, so the last value published
+// reaches a throttled subscriber even when nothing is published after it. It's always
+// called with the broadcaster locked
+func (s *Subscriber[T]) flushLater(wait time.Duration) {
+	if s.flushing {
+		return
+	}
+	s.flushing = true
+	b := s.broadcaster
+	go func() {
+		select {
+		case <-GetClock().After(wait):
+		case <-s.done:
+			return
+		}
+		b.mut.Lock()
+		defer b.mut.Unlock()
+		s.flushing = false
+		if _, ok := b.subscribers[s]; !ok || len(s.pending) == 0 {
+			return
+		}
+		now := GetClock().Now()
+		if s.interval > 0 && now.Sub(s.lastSent) < s.interval {
+			// the interval backed off in the meantime
+			s.flushLater(s.lastSent.Add(s.interval).Sub(now))
+			return
+		}
+		s.flush(now)
+	}()
+}
+
+// flush delivers the pending values, it's always called with the broadcaster locked
+func (s *Subscriber[T]) flush(now time.Time) {
+	s.lastSent = now
+	for len(s.pending) > 0 {
+		select {
+		case s.ch <- s.pending[0]:
+			s.pending = slices.Delete(s.pending, 0, 1)
+			s.caughtUp()
+			continue
+		default:
+		}
+
+		if s.key != nil {
+			// The buffer is full. Keyed values wait for the subscriber to catch up
+			//	instead, so no key is ever pushed out by another one
+			s.fallBehind()
+			s.flushLater(max(s.interval, time.Second))
+			return
+		}
+		// The buffer is full. Make room by dropping the oldest value, so the subscriber
+		//	always sees the newest data when it catches up
+		select {
+		case <-s.ch:
+			s.dropped++
+		default:
+		}
+		select {
+		case s.ch <- s.pending[0]:
+		default:
+			s.dropped++
+		}
+		s.pending = slices.Delete(s.pending, 0, 1)
+		s.fallBehind()
+	}
+}
+
+// caughtUp halves the interval of a throttled subscriber once it keeps up again
+func (s *Subscriber[T]) caughtUp() {
+	s.slowStreak = 0
+	s.fastStreak++
+	if s.fastStreak >= STREAM_SLOW_THRESHOLD && s.interval > s.baseInterval {
+		s.fastStreak = 0
+		s.interval /= 2
+		if s.interval < time.Second || s.interval < s.baseInterval {
+			// backoff always starts at 1 second, so this is fully caught up again
+			s.interval = s.baseInterval
+		}
+	}
+}
+
+// fallBehind doubles the interval of a subscriber whose buffer keeps being full
+func (s *Subscriber[T]) fallBehind() {
+	s.fastStreak = 0
+	s.slowStreak++
+	if s.slowStreak >= STREAM_SLOW_THRESHOLD {
+		s.slowStreak = 0
+		if s.interval <= 0 {
+			s.interval = time.Second
+		} else {
+			s.interval = min(s.interval*2, STREAM_MAX_INTERVAL)
+		}
+	}
+}
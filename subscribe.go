@@ -0,0 +1,61 @@
+package gtm
+
+import (
+	"slices"
+	"time"
+)
+
+// Update is pushed to the subscribers of a Monitor every time one of its collectors
+// fetched new stats, or failed to. Stats is what the Get method of the collector
+// returns: CPUStats, []DiskStats, []GPUStats, HostInfo, *mem.VirtualMemoryStat, or
+// []NetStats of every interface (see AllNetworkStats). Err is set when the fetch failed;
+// Stats is nil when it failed entirely, and holds what was collected when only some
+// disks or GPUs failed
+type Update struct {
+	Collector Collector `json:"collector"`
+	Timestamp time.Time `json:"timestamp"`
+	Stats     any       `json:"stats,omitempty"`
+	Err       error     `json:"-"`
+}
+
+// Subscribe receives an Update every time one of `collectors` (every collector of the
+// Monitor when none are given) refreshes its stats, whether that's from polling (see
+// Start) or from a Get method whose cache expired. Updates are never waited on: when a
+// subscriber falls behind, its updates are coalesced per collector until it catches up.
+// It then receives the latest update of every collector, so only the intermediate
+// updates of a collector can be skipped, never the update of another collector. Call
+// the returned function to unsubscribe; it closes the channel
+func (m *Monitor) Subscribe(collectors ...Collector) (<-chan Update, func()) {
+	sub, cancel := m.subscribe(collectors...)
+	return sub.C, cancel
+}
+
+// subscribe is Subscribe, returning the subscriber for its Stats
+func (m *Monitor) subscribe(collectors ...Collector) (*Subscriber[Update], func()) {
+	var filter func(Update) bool
+	if len(collectors) > 0 {
+		collectors = slices.Clone(collectors)
+		filter = func(u Update) bool { return slices.Contains(collectors, u.Collector) }
+	}
+	// every collector can have an update buffered, and the updates of a subscriber that
+	//	fell behind only replace the pending update of the same collector
+	bufferSize := len(m.Intervals()) * STREAM_BUFFER_SIZE
+	sub, cancel := m.updates.SubscribeKeyed(0, bufferSize, filter,
+		func(u Update) string { return string(u.Collector) })
+	return sub, cancel
+}
+
+// Subscribe receives the updates of the default Monitor, see Monitor.Subscribe
+func Subscribe(collectors ...Collector) (<-chan Update, func()) {
+	return defaultMonitor.Subscribe(collectors...)
+}
+
+// publish pushes an update to the subscribers. It never blocks, so it can be called
+// with the collector cache locked
+func (m *Monitor) publish(collector Collector, stats any, err error) {
+	if m.updates.Len() == 0 {
+		return
+	}
+	m.updates.Publish(Update{Collector: collector, Timestamp: GetClock().Now(), Stats: stats,
+		Err: err})
+}
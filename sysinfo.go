@@ -0,0 +1,115 @@
+package gtm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"sync"
+)
+
+// SystemInfo is the hardware inventory of the host from DMI/SMBIOS (or the system
+// profiler on macOS). Serial numbers usually need root/admin, and are empty without
+// it. Fields that don't apply (ie. the board of a Mac) are empty
+type SystemInfo struct {
+	Manufacturer   string `json:"manufacturer"`
+	ProductName    string `json:"product_name"`
+	ProductVersion string `json:"product_version"`
+	SerialNumber   string `json:"serial_number"`
+	BoardVendor    string `json:"board_vendor"`
+	BoardName      string `json:"board_name"`
+	BoardSerial    string `json:"board_serial"`
+	BIOSVendor     string `json:"bios_vendor"`
+	BIOSVersion    string `json:"bios_version"`
+	BIOSDate       string `json:"bios_date"`
+}
+
+var (
+	systemInfo     SystemInfo
+	systemInfoErr  error
+	systemInfoOnce sync.Once
+)
+
+// GetSystemInfo reads the hardware inventory once, since it can't change while gtm runs
+func GetSystemInfo() (SystemInfo, error) {
+	systemInfoOnce.Do(func() {
+		systemInfo, systemInfoErr = readSystemInfo()
+		if systemInfoErr != nil {
+			collectorError(CollectorHost, "Failed to retrieve the system info!",
+				systemInfoErr)
+		}
+	})
+	return systemInfo, systemInfoErr
+}
+
+// SMBIOS structure types and the offsets of their string fields
+const (
+	SMBIOS_TYPE_BIOS      = 0
+	SMBIOS_TYPE_SYSTEM    = 1
+	SMBIOS_TYPE_BASEBOARD = 2
+	SMBIOS_TYPE_END       = 127
+)
+
+// parseSMBIOS reads the BIOS, system and baseboard structures of a raw SMBIOS table.
+// Every structure is a formatted area (type, length, handle and fields) followed by
+// its strings, each null terminated, and an extra null. String fields hold the 1-based
+// index of their string
+func parseSMBIOS(table []byte) SystemInfo {
+	var info SystemInfo
+	for len(table) >= 4 {
+		kind, length := table[0], int(table[1])
+		if length < 4 || length > len(table) {
+			break
+		}
+		formatted := table[:length]
+		end := bytes.Index(table[length:], []byte{0, 0})
+		if end < 0 {
+			break
+		}
+		stringsArea := table[length : length+end]
+		field := func(offset int) string {
+			if offset >= len(formatted) || formatted[offset] == 0 {
+				return ""
+			}
+			values := bytes.Split(stringsArea, []byte{0})
+			if index := int(formatted[offset]); index <= len(values) {
+				return strings.TrimSpace(string(values[index-1]))
+			}
+			return ""
+		}
+
+		switch kind {
+		case SMBIOS_TYPE_BIOS:
+			info.BIOSVendor, info.BIOSVersion, info.BIOSDate = field(0x04), field(0x05),
+				field(0x08)
+		case SMBIOS_TYPE_SYSTEM:
+			info.Manufacturer, info.ProductName = field(0x04), field(0x05)
+			info.ProductVersion, info.SerialNumber = field(0x06), field(0x07)
+		case SMBIOS_TYPE_BASEBOARD:
+			info.BoardVendor, info.BoardName, info.BoardSerial = field(0x04), field(0x05),
+				field(0x07)
+		case SMBIOS_TYPE_END:
+			return info
+		}
+		table = table[length+end+2:]
+	}
+	return info
+}
+
+// rawSMBIOSHeader is the header GetSystemFirmwareTable puts before the SMBIOS table
+type rawSMBIOSHeader struct {
+	Used20CallingMethod byte
+	MajorVersion        byte
+	MinorVersion        byte
+	DMIRevision         byte
+	Length              uint32
+}
+
+// parseRawSMBIOS parses the RawSMBIOSData returned by GetSystemFirmwareTable("RSMB")
+func parseRawSMBIOS(data []byte) SystemInfo {
+	var header rawSMBIOSHeader
+	if binary.Read(bytes.NewReader(data), binary.LittleEndian, &header) != nil {
+		return SystemInfo{}
+	}
+	table := data[binary.Size(header):]
+	return parseSMBIOS(table[:min(int(header.Length), len(table))])
+}
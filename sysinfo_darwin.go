@@ -0,0 +1,40 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+)
+
+// readSystemInfo asks the system profiler, since Macs have no SMBIOS. It's slow (about
+// half a second), but only runs once
+func readSystemInfo() (SystemInfo, error) {
+	out, err := runCommand("system_profiler", "SPHardwareDataType")
+	if err != nil {
+		return SystemInfo{}, err
+	}
+	return parseSystemProfilerHardware(out), nil
+}
+
+// parseSystemProfilerHardware parses the "Key: Value" lines of the hardware overview
+func parseSystemProfilerHardware(out []byte) SystemInfo {
+	info := SystemInfo{Manufacturer: "Apple Inc.", BIOSVendor: "Apple Inc."}
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ": ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "Model Name":
+			info.ProductName = value
+		case "Model Identifier":
+			info.ProductVersion = value
+		case "Serial Number (system)":
+			info.SerialNumber = value
+		case "System Firmware Version", "Boot ROM Version":
+			info.BIOSVersion = value
+		}
+	}
+	return info
+}
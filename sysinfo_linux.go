@@ -0,0 +1,27 @@
+package gtm
+
+import "path/filepath"
+
+// readSystemInfo reads the DMI attributes linux exposes in DMI_PATH. The serials are
+// only readable by root
+func readSystemInfo() (SystemInfo, error) {
+	if !fileExists(DMI_PATH) {
+		// ie. ARM boards without SMBIOS, or a container without /sys/class/dmi
+		return SystemInfo{}, nil
+	}
+	read := func(name string) string {
+		return readSysfsString(filepath.Join(DMI_PATH, name))
+	}
+	return SystemInfo{
+		Manufacturer:   read("sys_vendor"),
+		ProductName:    read("product_name"),
+		ProductVersion: read("product_version"),
+		SerialNumber:   read("product_serial"),
+		BoardVendor:    read("board_vendor"),
+		BoardName:      read("board_name"),
+		BoardSerial:    read("board_serial"),
+		BIOSVendor:     read("bios_vendor"),
+		BIOSVersion:    read("bios_version"),
+		BIOSDate:       read("bios_date"),
+	}, nil
+}
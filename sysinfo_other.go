@@ -0,0 +1,7 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+func readSystemInfo() (SystemInfo, error) {
+	return SystemInfo{}, ErrUnsupportedPlatform
+}
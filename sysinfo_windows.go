@@ -0,0 +1,24 @@
+package gtm
+
+import "unsafe"
+
+// RSMB_PROVIDER is the 'RSMB' firmware table provider, the raw SMBIOS table
+const RSMB_PROVIDER = 0x52534D42
+
+var procGetSystemFirmwareTable = kernel32.NewProc("GetSystemFirmwareTable")
+
+// readSystemInfo reads the raw SMBIOS table, which unlike WMI's Win32_BIOS needs
+// neither admin nor a working WMI repository
+func readSystemInfo() (SystemInfo, error) {
+	size, _, err := procGetSystemFirmwareTable.Call(RSMB_PROVIDER, 0, 0, 0)
+	if size == 0 {
+		return SystemInfo{}, err
+	}
+	data := make([]byte, size)
+	n, _, err := procGetSystemFirmwareTable.Call(RSMB_PROVIDER, 0,
+		uintptr(unsafe.Pointer(&data[0])), size)
+	if n == 0 || n > size {
+		return SystemInfo{}, err
+	}
+	return parseRawSMBIOS(data[:n]), nil
+}
@@ -0,0 +1,50 @@
+package gtm
+
+import (
+	"sync"
+	"time"
+)
+
+// TIME_SYNC_UPDATE_INTERVAL is how often the time sync status is read. Time daemons
+// only poll their servers every few minutes, so it changes slowly
+const TIME_SYNC_UPDATE_INTERVAL = time.Minute
+
+// TimeSyncStatus is whether the system clock is synchronized, ie. by NTP or W32Time.
+// Offset is the correction the time daemon estimates the clock still needs (positive
+// when the clock is behind), and MaxError bounds how wrong the clock may be. Source is
+// the time server, when the OS reports it
+type TimeSyncStatus struct {
+	Synchronized bool          `json:"synchronized"`
+	Source       string        `json:"source,omitempty"`
+	Offset       time.Duration `json:"offset"`
+	MaxError     time.Duration `json:"max_error,omitempty"`
+}
+
+var (
+	timeSyncStatus    TimeSyncStatus
+	lastFetchTimeSync time.Time
+	timeSyncMut       sync.Mutex
+)
+
+// GetTimeSyncStatus returns whether the system clock is synchronized, and how far off
+// it's estimated to be
+func GetTimeSyncStatus() (TimeSyncStatus, error) {
+	timeSyncMut.Lock()
+	defer timeSyncMut.Unlock()
+
+	if GetClock().Since(lastFetchTimeSync) < TIME_SYNC_UPDATE_INTERVAL {
+		return timeSyncStatus, nil
+	}
+	if !IsCollectorEnabled(CollectorTimeSync) {
+		return TimeSyncStatus{}, ErrCollectorDisabled
+	}
+	lastFetchTimeSync = GetClock().Now()
+
+	status, err := readTimeSyncStatus()
+	if err != nil {
+		collectorError(CollectorTimeSync, "Failed to retrieve the time sync status!", err)
+		return timeSyncStatus, err
+	}
+	timeSyncStatus = status
+	return timeSyncStatus, nil
+}
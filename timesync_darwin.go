@@ -0,0 +1,72 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// NTP_CONF_PATH holds the time server set in System Settings
+	NTP_CONF_PATH = "/etc/ntp.conf"
+	// TIME_SYNC_MAX_OFFSET is the offset NTP steps the clock at instead of slewing it.
+	//	macOS doesn't report whether timed synchronized the clock, so a clock within it
+	//	counts as synchronized
+	TIME_SYNC_MAX_OFFSET = 128 * time.Millisecond
+)
+
+// readTimeSyncStatus measures the offset to the configured time server with `sntp`,
+// since timed doesn't report its state
+func readTimeSyncStatus() (TimeSyncStatus, error) {
+	data, err := os.ReadFile(NTP_CONF_PATH)
+	if err != nil {
+		return TimeSyncStatus{}, err
+	}
+	server := ""
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 2 &&
+			fields[0] == "server" {
+			server = fields[1]
+			break
+		}
+	}
+	if server == "" {
+		return TimeSyncStatus{}, nil
+	}
+
+	out, err := runCommand("sntp", "-t", "2", server)
+	if err != nil {
+		return TimeSyncStatus{}, err
+	}
+	return parseSntp(out, server)
+}
+
+// parseSntp parses the "+0.005634 +/- 0.031260 time.apple.com 17.253.4.253" line of
+// `sntp`, the offset and its error in seconds
+func parseSntp(out []byte, server string) (TimeSyncStatus, error) {
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 3 || fields[1] != "+/-" {
+			continue
+		}
+		offset, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return TimeSyncStatus{}, err
+		}
+		maxError, _ := strconv.ParseFloat(fields[2], 64)
+		status := TimeSyncStatus{
+			Source:   server,
+			Offset:   time.Duration(offset * float64(time.Second)),
+			MaxError: time.Duration(maxError * float64(time.Second)),
+		}
+		status.Synchronized = status.Offset.Abs() <= TIME_SYNC_MAX_OFFSET
+		return status, nil
+	}
+	return TimeSyncStatus{}, errors.New("unexpected sntp output: " +
+		strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,25 @@
+package gtm
+
+import (
+	"golang.org/x/sys/unix"
+	"time"
+)
+
+// readTimeSyncStatus reads the kernel clock discipline with a read-only adjtimex(2),
+// which every NTP daemon (chrony, ntpd, systemd-timesyncd) keeps up to date. The kernel
+// marks the clock unsynchronized when no daemon updated it for a while
+func readTimeSyncStatus() (TimeSyncStatus, error) {
+	var tx unix.Timex
+	if _, err := unix.Adjtimex(&tx); err != nil {
+		return TimeSyncStatus{}, err
+	}
+	offset := time.Duration(tx.Offset) * time.Microsecond
+	if tx.Status&unix.STA_NANO != 0 {
+		offset = time.Duration(tx.Offset)
+	}
+	return TimeSyncStatus{
+		Synchronized: tx.Status&unix.STA_UNSYNC == 0,
+		Offset:       offset,
+		MaxError:     time.Duration(tx.Maxerror) * time.Microsecond,
+	}, nil
+}
@@ -0,0 +1,53 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// readTimeSyncStatus asks the Windows Time service. Its output is localized, so only
+// the English labels are understood
+func readTimeSyncStatus() (TimeSyncStatus, error) {
+	out, err := runCommand("w32tm", "/query", "/status", "/verbose")
+	if err != nil {
+		return TimeSyncStatus{}, err
+	}
+	return parseW32tmStatus(out), nil
+}
+
+// parseW32tmStatus parses the "Label: value" lines of `w32tm /query /status /verbose`.
+// A leap indicator of 3 means the clock isn't synchronized, and so does a local source
+// (ie. "Local CMOS Clock" or "Free-running System Clock")
+func parseW32tmStatus(out []byte) TimeSyncStatus {
+	status := TimeSyncStatus{}
+	leap, local := "", true
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), ": ")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "Leap Indicator":
+			leap, _, _ = strings.Cut(value, "(")
+		case "Source":
+			// ie. time.windows.com,0x9
+			status.Source, _, _ = strings.Cut(value, ",")
+			local = strings.Contains(value, "Clock")
+		case "Phase Offset":
+			if seconds, err := strconv.ParseFloat(strings.TrimSuffix(value, "s"),
+				64); err == nil {
+				status.Offset = time.Duration(seconds * float64(time.Second))
+			}
+		}
+	}
+	status.Synchronized = leap != "" && leap != "3" && !local
+	if local {
+		status.Source = ""
+	}
+	return status
+}
@@ -2,11 +2,13 @@ package gtm
 
 import (
 	"context"
+	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
-	"log/slog"
 	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,15 +23,6 @@ const (
 	YELLOW        = "[yellow]"
 )
 
-const (
-	LblCPUTemp = " CPU Temp "
-	LblDisk    = " HDD / SSD "
-	LblGPUTemp = " GPU Temp "
-	LblMemory  = " Memory "
-	LblNetwork = " Network "
-	LblProc    = " Processes "
-)
-
 var (
 	barSymbols  = [8]string{" ", "░", "▒", "▓", "█", "[", "|", "]"}
 	treeSymbols = [4]string{"│", "├", "─", "└"}
@@ -39,20 +32,20 @@ var (
 func sleepWithTimestampDelta(timestamp time.Time, isResized bool) {
 	if isResized {
 		// When the window/box primitive is resized, refresh the window info ASAP
-		//slog.Debug("sleep SKIP")
-		time.Sleep(0)
+		//logger().Debug("sleep SKIP")
+		GetClock().Sleep(0)
 	} else {
 		// Only sleep window refresh/updates when the window is NOT resized.
-		timeDelta := time.Now().UnixMilli() - timestamp.UnixMilli()
+		timeDelta := GetClock().Now().UnixMilli() - timestamp.UnixMilli()
 		if timeDelta == 0 {
-			//slog.Debug("sleep update = " + strconv.Itoa(int(update.Milliseconds())))
-			time.Sleep(*update)
+			//logger().Debug("sleep update = " + strconv.Itoa(int(update.Milliseconds())))
+			GetClock().Sleep(*update)
 		} else if timeDelta < update.Milliseconds() {
-			//slog.Debug("sleep timeDelta = " + strconv.Itoa(int(update.Milliseconds()-timeDelta)))
-			time.Sleep(time.Duration(update.Milliseconds() - timeDelta))
+			//logger().Debug("sleep timeDelta = " + strconv.Itoa(int(update.Milliseconds()-timeDelta)))
+			GetClock().Sleep(time.Duration(update.Milliseconds() - timeDelta))
 		} else if timeDelta > update.Milliseconds() {
 			// the timeDelta is greater than the update, don't sleep and update immediately
-			time.Sleep(0)
+			GetClock().Sleep(0)
 		}
 	}
 }
@@ -113,7 +106,7 @@ func getInnerBoxSize(box *tview.Box, oldWidth int, oldHeight int) (width int, he
 		isResized = true
 		boxTitle := strings.TrimSpace(box.GetTitle())
 
-		slog.Debug(boxTitle + " inner box size changed from (" +
+		logger().Debug(boxTitle + " inner box size changed from (" +
 			strconv.Itoa(oldWidth) + "->" + strconv.Itoa(width) + ") columns " +
 			"and (" + strconv.Itoa(oldHeight) + "->" + strconv.Itoa(height) + ") rows !")
 	}
@@ -130,6 +123,22 @@ func insertCenterSpacing(arg1 string, arg2 string, boxWidth int,
 	return spaces
 }
 
+// formatBytesPerSec formats a throughput with a binary unit, ie. "1.5 MiB/s"
+func formatBytesPerSec(bytesPerSec float64) string {
+	return formatBytes(bytesPerSec) + "/s"
+}
+
+// formatBytes formats an amount of bytes with a binary unit, ie. "1.5 MiB"
+func formatBytes(bytes float64) string {
+	units := []string{"B", "KiB", "MiB", "GiB", "TiB"}
+	unit := 0
+	for bytes >= 1024 && unit < len(units)-1 {
+		bytes /= 1024
+		unit++
+	}
+	return strconv.FormatFloat(bytes, 'f', 1, 64) + " " + units[unit]
+}
+
 func buildBoxTitleRow(title string, statStr string, boxWidth int, spaceChar string) string {
 	return title + insertCenterSpacing(title, statStr, boxWidth, spaceChar) + statStr + "\n"
 }
@@ -147,22 +156,20 @@ func UpdateCPU(app *tview.Application, box *tview.TextView, showBorder bool) {
 	)
 	box.SetDynamicColors(true)
 	box.SetBorder(showBorder)
-	slog.Info("Starting `UpdateCPU()` UI goroutine ...")
+	logger().Info("Starting `UpdateCPU()` UI goroutine ...")
 
 	for {
-		timestamp := time.Now()
+		timestamp := GetClock().Now()
 		width, height, isResized = getInnerBoxSize(box.Box, width, height)
 
 		// TODO: use 2 boxes as columns (side-by-side) to display a graph and stats
 		// 	(in that order)
 		//boxText = "col: " + strconv.Itoa(width) + ", row: " + strconv.Itoa(height) + "\n"
 
-		stats := GetCPUStats()
-		lastIndex := len(stats) - 1
+		stats, _ := GetCPUStats()
 
-		boxText = "CPU load: " + strconv.FormatFloat(
-			stats[lastIndex].UsagePercent, 'f', 1, 64) + " %" + "\n"
-		boxText += "len of stats = " + strconv.Itoa(len(stats)) + "\n"
+		boxText = T(MsgCPULoad) + " " + strconv.FormatFloat(
+			stats.UsagePercent, 'f', 1, 64) + " %" + "\n"
 
 		if isResized {
 			// Re-draw immediately if the window is resized
@@ -176,8 +183,8 @@ func UpdateCPU(app *tview.Application, box *tview.TextView, showBorder bool) {
 				box.SetText(boxText)
 			})
 		}
-		slog.Log(context.Background(), LevelPerf,
-			"UpdateCPU() time: "+(time.Since(timestamp)-*update).String())
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateCPU() time: "+(GetClock().Since(timestamp)-*update).String())
 	}
 }
 
@@ -189,11 +196,11 @@ func UpdateCPUTemp(app *tview.Application, box *tview.TextView, showBorder bool)
 	)
 
 	box.SetDynamicColors(true)
-	box.SetBorder(showBorder).SetTitle(LblCPUTemp)
-	slog.Info("Starting `UpdateCPUTemp()` UI goroutine ...")
+	box.SetBorder(showBorder).SetTitle(Title(LblCPUTemp))
+	logger().Info("Starting `UpdateCPUTemp()` UI goroutine ...")
 
 	for {
-		timestamp := time.Now()
+		timestamp := GetClock().Now()
 		width, height, isResized = getInnerBoxSize(box.Box, width, height)
 
 		//boxText = "col: " + strconv.Itoa(width) + ", row: " + strconv.Itoa(height) + "\n"
@@ -210,8 +217,75 @@ func UpdateCPUTemp(app *tview.Application, box *tview.TextView, showBorder bool)
 				box.SetText(boxText)
 			})
 		}
-		slog.Log(context.Background(), LevelPerf,
-			"UpdateCPUTemp() time: "+(time.Since(timestamp)-*update).String())
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateCPUTemp() time: "+(GetClock().Since(timestamp)-*update).String())
+	}
+}
+
+//// Alerts ////##########################################################################
+
+const (
+	// ALERT_FLASH_DURATION is how long the screen stays inverted per flash
+	ALERT_FLASH_DURATION = 250 * time.Millisecond
+	// ALERT_FLASH_COUNT is the number of flashes for every critical alert
+	ALERT_FLASH_COUNT = 3
+)
+
+var (
+	alertBeep  atomic.Bool
+	alertFlash atomic.Bool
+)
+
+// NotifyCriticalAlerts rings the terminal bell (ALERT_BELL) and/or flashes the screen
+// (ALERT_FLASH) for every critical alert, for gtm running full-screen on a monitoring
+// display. Alerts fire when a GPU gets hotter than GPU_TEMPERATURE_CRITICAL, when a
+// temperature sensor reaches the critical threshold of its chip (whenever the sensors
+// are read), or when an embedder annotates one. It returns right away when both are
+// disabled in the config
+func NotifyCriticalAlerts(app *tview.Application) {
+	if !Cfg.AlertBell && !Cfg.AlertFlash {
+		return
+	}
+	logger().Info("Starting `NotifyCriticalAlerts()` UI goroutine ...")
+
+	// The screen is only touched from the draw loop, so the bell and the flash are
+	//	applied after each draw
+	app.SetAfterDrawFunc(func(screen tcell.Screen) {
+		if alertBeep.Swap(false) {
+			_ = screen.Beep()
+		}
+		if alertFlash.Load() {
+			width, height := screen.Size()
+			for x := range width {
+				for y := range height {
+					mainc, combc, style, _ := screen.GetContent(x, y)
+					screen.SetContent(x, y, mainc, combc, style.Reverse(true))
+				}
+			}
+		}
+	})
+
+	alerts, cancel := WatchAnnotations()
+	defer cancel()
+	for annotation := range alerts.C {
+		if !annotation.IsCritical() {
+			continue
+		}
+		logger().Warn("Critical alert: " + annotation.Text)
+		if Cfg.AlertBell {
+			alertBeep.Store(true)
+			app.QueueUpdateDraw(func() {})
+		}
+		if Cfg.AlertFlash {
+			for range ALERT_FLASH_COUNT {
+				alertFlash.Store(true)
+				app.QueueUpdateDraw(func() {})
+				GetClock().Sleep(ALERT_FLASH_DURATION)
+				alertFlash.Store(false)
+				app.QueueUpdateDraw(func() {})
+				GetClock().Sleep(ALERT_FLASH_DURATION)
+			}
+		}
 	}
 }
 
@@ -226,17 +300,24 @@ func UpdateDisk(app *tview.Application, box *tview.TextView, showBorder bool) {
 	)
 
 	box.SetDynamicColors(true)
-	box.SetBorder(showBorder).SetTitle(LblDisk)
-	slog.Info("Starting `UpdateDisk()` UI goroutine ...")
+	box.SetBorder(showBorder).SetTitle(Title(LblDisk))
+	logger().Info("Starting `UpdateDisk()` UI goroutine ...")
 
 	for {
-		timestamp := time.Now()
+		timestamp := GetClock().Now()
 		width, height, isResized = getInnerBoxSize(box.Box, width, height)
 
-		disksStats = GetDisksStats()
+		// Failed mountpoints are already logged by the collector and marked below
+		disksStats, _ := GetDisksStats()
 		boxText = ""
 
 		for _, dsk := range disksStats {
+			if dsk.Error != "" {
+				boxText += buildBoxTitleRow(displayName(dsk.Mountpoint, dsk.Alias), "n/a",
+					width, " ")
+				boxText += buildProgressBar(0, width, RED, GRAY)
+				continue
+			}
 			var diskCapacityStr string
 			diskCapacity := ConvertBytesToGiB(dsk.Total, false)
 			if diskCapacity < 999 {
@@ -250,8 +331,19 @@ func UpdateDisk(app *tview.Application, box *tview.TextView, showBorder bool) {
 			//boxText += dsk.Mountpoint + " | " + strconv.FormatBool(dsk.IsVirtualDisk) +
 			//	" | " + strconv.FormatFloat(dsk.UsedPercent, 'g', -1, 64) +
 			//	"% of " + diskCapacityStr + "\n"
-			boxText += buildBoxTitleRow(dsk.Mountpoint, diskCapacityStr, width, " ")
-			boxText += buildProgressBar(dsk.UsedPercent, width, BLUE, WHITE)
+			boxText += buildBoxTitleRow(displayName(dsk.Mountpoint, dsk.Alias), diskCapacityStr,
+				width, " ")
+			boxText += buildProgressBar(dsk.UsedPercent/100, width, BLUE, WHITE)
+			if health, ok := GetDiskHealthForDevice(dsk.Device); ok &&
+				health.EnduranceUsedPercent >= 0 {
+				// ie. "Wear:  12% (~9.5 yrs left)"
+				wearStr := strconv.FormatFloat(health.EnduranceUsedPercent, 'f', 0, 64) + "%"
+				if health.DaysLeft >= 0 {
+					wearStr += " (~" + strconv.FormatFloat(health.DaysLeft/365, 'f', 1, 64) +
+						" yrs left)"
+				}
+				boxText += buildBoxTitleRow(T(MsgEndurance), wearStr, width, " ")
+			}
 			//boxText += "width=" + strconv.Itoa(width) + ", height=" + strconv.Itoa(height) + "\n"
 		}
 
@@ -267,8 +359,8 @@ func UpdateDisk(app *tview.Application, box *tview.TextView, showBorder bool) {
 				box.SetText(boxText)
 			})
 		}
-		slog.Log(context.Background(), LevelPerf,
-			"UpdateDisk() time: "+(time.Since(timestamp)-*update).String())
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateDisk() time: "+(GetClock().Since(timestamp)-*update).String())
 	}
 }
 
@@ -282,25 +374,26 @@ func UpdateGPU(app *tview.Application, box *tview.TextView, showBorder bool) {
 	)
 
 	box.SetDynamicColors(true)
-	box.SetBorder(showBorder).SetTitle(" " + GPUName() + " ")
-	slog.Info("Starting `UpdateGPU()` UI goroutine ...")
+	box.SetBorder(showBorder).SetTitle(" " + displayName(GPUName(), gpuAlias(0)) + " ")
+	logger().Info("Starting `UpdateGPU()` UI goroutine ...")
 
 	for {
-		timestamp := time.Now()
+		timestamp := GetClock().Now()
 		width, height, isResized = getInnerBoxSize(box.Box, width, height)
 
-		gpuStats = GetGPUStats()
+		gpuStats, _ := GetGPUStats()
 		lastElement := len(gpuStats) - 1
 		/// END DATA FETCH
 
-		gpuLoadStr := strconv.FormatInt(int64(gpuStats[lastElement].Load*100.0), 10) + "%"
-		gpuLoadTitleRow := buildBoxTitleRow("Load:", gpuLoadStr, width, " ")
+		gpuLoadStr := strconv.FormatInt(int64(gpuStats[lastElement].Load), 10) + "%"
+		gpuLoadTitleRow := buildBoxTitleRow(T(MsgGPULoad), gpuLoadStr, width, " ")
 
 		gpuMemoryUsageRatio := gpuStats[lastElement].MemoryUsage / gpuStats[lastElement].MemoryTotal
 		gpuMemoryStr := strconv.FormatInt(int64(gpuMemoryUsageRatio*100), 10) + "%"
-		gpuMemoryTitleRow := buildBoxTitleRow("Mem:", gpuMemoryStr, width, " ")
+		gpuMemoryTitleRow := buildBoxTitleRow(T(MsgGPUMemory), gpuMemoryStr, width, " ")
 
-		boxText = gpuLoadTitleRow + buildProgressBar(gpuStats[lastElement].Load, width, GREEN, WHITE)
+		boxText = gpuLoadTitleRow + buildProgressBar(gpuStats[lastElement].Load/100, width, GREEN,
+			WHITE)
 		boxText += "\n" // add an extra line gap to visually and obviously separate the info
 		boxText += gpuMemoryTitleRow + buildProgressBar(gpuMemoryUsageRatio, width, GREEN, WHITE)
 
@@ -316,8 +409,8 @@ func UpdateGPU(app *tview.Application, box *tview.TextView, showBorder bool) {
 				box.SetText(boxText)
 			})
 		}
-		slog.Log(context.Background(), LevelPerf,
-			"UpdateGPU() time: "+(time.Since(timestamp)-*update).String())
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateGPU() time: "+(GetClock().Since(timestamp)-*update).String())
 	}
 }
 
@@ -329,19 +422,19 @@ func UpdateGPUTemp(app *tview.Application, box *tview.TextView, showBorder bool)
 	)
 
 	box.SetDynamicColors(true)
-	box.SetBorder(showBorder).SetTitle(LblGPUTemp)
-	slog.Info("Starting `UpdateGPUTemp()` UI goroutine ...")
+	box.SetBorder(showBorder).SetTitle(Title(LblGPUTemp))
+	logger().Info("Starting `UpdateGPUTemp()` UI goroutine ...")
 
 	for {
-		timestamp := time.Now()
+		timestamp := GetClock().Now()
 		width, height, isResized = getInnerBoxSize(box.Box, width, height)
 
-		gpuStats = GetGPUStats()
+		gpuStats, _ := GetGPUStats()
 		lastElement := len(gpuStats) - 1
 
 		//boxText = "col: " + strconv.Itoa(width) + ", row: " + strconv.Itoa(height)
 		gpuTempStr := strconv.Itoa(int(gpuStats[lastElement].Temperature)) + "°C"
-		gpuTempTitle := buildBoxTitleRow("Temp:", gpuTempStr, width, " ")
+		gpuTempTitle := buildBoxTitleRow(T(MsgGPUTemp), gpuTempStr, width, " ")
 
 		boxText = gpuTempTitle + buildProgressBar(
 			float64(gpuStats[lastElement].Temperature)/100.0, width, GREEN, WHITE)
@@ -358,8 +451,8 @@ func UpdateGPUTemp(app *tview.Application, box *tview.TextView, showBorder bool)
 				box.SetText(boxText)
 			})
 		}
-		slog.Log(context.Background(), LevelPerf,
-			"UpdateGPUTemp() time: "+(time.Since(timestamp)-*update).String())
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateGPUTemp() time: "+(GetClock().Since(timestamp)-*update).String())
 	}
 }
 
@@ -373,27 +466,33 @@ func UpdateMemory(app *tview.Application, box *tview.TextView, showBorder bool)
 	)
 
 	box.SetDynamicColors(true)
-	box.SetBorder(showBorder).SetTitle(LblMemory)
-	slog.Info("Starting `UpdateMemory()` UI goroutine ...")
+	box.SetBorder(showBorder).SetTitle(Title(LblMemory))
+	logger().Info("Starting `UpdateMemory()` UI goroutine ...")
 
 	for {
-		timestamp := time.Now()
+		timestamp := GetClock().Now()
 		width, height, isResized = getInnerBoxSize(box.Box, width, height)
 
-		memInfo = GetMemoryStats()
+		memInfo, err := GetMemoryStats()
 		/// END DATA FETCH
 
-		memUsed := ConvertBytesToGiB(memInfo.Used, false)
-		memUsedText := strconv.FormatFloat(memUsed, 'f', 1, 64) + " GB"
+		memoryUsedTitleRow := buildBoxTitleRow(T(MsgMemoryUsed), T(MsgMemoryTotal), width,
+			" ")
+		if err != nil || memInfo == nil {
+			// the first read failed, so there's nothing to show yet
+			boxText = memoryUsedTitleRow + buildBoxTitleRow("n/a", "n/a", width, " ")
+		} else {
+			memUsed := ConvertBytesToGiB(memInfo.Used, false)
+			memUsedText := strconv.FormatFloat(memUsed, 'f', 1, 64) + " GB"
 
-		memTotal := ConvertBytesToGiB(memInfo.Total, false)
-		memTotalText := strconv.FormatFloat(memTotal, 'f', 1, 64) + " GB"
+			memTotal := ConvertBytesToGiB(memInfo.Total, false)
+			memTotalText := strconv.FormatFloat(memTotal, 'f', 1, 64) + " GB"
 
-		memoryUsedTitleRow := buildBoxTitleRow("Used", "Total", width, " ")
-		progressBar := buildProgressBar(memInfo.UsedPercent/100, width, GREEN, WHITE)
-		memoryStatsRow := buildBoxTitleRow(memUsedText, memTotalText, width, " ")
+			progressBar := buildProgressBar(memInfo.UsedPercent/100, width, GREEN, WHITE)
+			memoryStatsRow := buildBoxTitleRow(memUsedText, memTotalText, width, " ")
 
-		boxText = memoryUsedTitleRow + progressBar + memoryStatsRow
+			boxText = memoryUsedTitleRow + progressBar + memoryStatsRow
+		}
 
 		if isResized {
 			// Re-draw immediately if the window is resized
@@ -407,8 +506,8 @@ func UpdateMemory(app *tview.Application, box *tview.TextView, showBorder bool)
 				box.SetText(boxText)
 			})
 		}
-		slog.Log(context.Background(), LevelPerf,
-			"UpdateMemory() time: "+(time.Since(timestamp)-*update).String())
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateMemory() time: "+(GetClock().Since(timestamp)-*update).String())
 	}
 }
 
@@ -422,22 +521,53 @@ func UpdateNetwork(app *tview.Application, box *tview.TextView, showBorder bool)
 	)
 
 	box.SetDynamicColors(true)
-	box.SetBorder(showBorder).SetTitle(LblNetwork)
-	slog.Info("Starting `UpdateNetwork()` UI goroutine ...")
+	box.SetBorder(showBorder).SetTitle(Title(LblNetwork))
+	logger().Info("Starting `UpdateNetwork()` UI goroutine ...")
 
 	for {
-		timestamp := time.Now()
+		timestamp := GetClock().Now()
 		width, height, isResized = getInnerBoxSize(box.Box, width, height)
 
-		netInfo = GetNetworkStats()
+		netStats, _ := GetNetworkStats()
+		wifi, _ := GetWiFiStats()
 
 		boxText = GetHostname() + "\n"
+		netEnv, _ := GetNetworkEnvironment()
+		if netEnv.DefaultGateway != "" {
+			boxText += buildBoxTitleRow(T(MsgGateway), netEnv.DefaultGateway, width, " ")
+		}
+		if netEnv.PublicIP != "" {
+			boxText += buildBoxTitleRow(T(MsgPublicIP), netEnv.PublicIP, width, " ")
+		}
 		//boxText += "col: " + strconv.Itoa(width) + ", row: " + strconv.Itoa(height)
-		for _, iface := range netInfo {
+		for _, iface := range netStats {
+			boxText += displayName(iface.Name, iface.Alias)
+			if info, ok := GetNetworkInterface(iface.Name); ok {
+				// ie. "eth0 (1 GbE, 192.168.1.10)"
+				details := slices.DeleteFunc([]string{info.SpeedString(),
+					info.PrimaryAddress()}, func(s string) bool { return s == "" })
+				if len(details) > 0 {
+					boxText += " (" + strings.Join(details, ", ") + ")"
+				}
+			}
+			boxText += "\n"
+			for _, w := range wifi {
+				if w.Interface == iface.Name && w.SSID != "" {
+					// ie. "HomeNetwork  5 GHz  92%"
+					boxText += buildBoxTitleRow(w.SSID, w.Band+"  "+
+						strconv.Itoa(w.SignalPercent)+"%", width, " ")
+				}
+			}
 			boxText += buildBoxTitleRow(
-				"DOWN: ", strconv.FormatUint(iface.BytesSent, 10), width, " ")
+				T(MsgDown), FormatNetRate(iface.DownloadBytesPerSec), width, " ")
 			boxText += buildBoxTitleRow(
-				"UP: ", strconv.FormatUint(iface.BytesRecv, 10), width, " ")
+				T(MsgUp), FormatNetRate(iface.UploadBytesPerSec), width, " ")
+			if errorRate := iface.ErrInPerSec + iface.ErrOutPerSec + iface.DropInPerSec +
+				iface.DropOutPerSec; errorRate > 0 {
+				// only shown while errors/drops are rising, so they stand out
+				boxText += RED + buildBoxTitleRow(T(MsgErrors),
+					strconv.FormatFloat(errorRate, 'f', 1, 64)+"/s", width, " ") + WHITE
+			}
 		}
 
 		if isResized {
@@ -452,8 +582,31 @@ func UpdateNetwork(app *tview.Application, box *tview.TextView, showBorder bool)
 				box.SetText(boxText)
 			})
 		}
-		slog.Log(context.Background(), LevelPerf,
-			"UpdateNetwork() time: "+(time.Since(timestamp)-*update).String())
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateNetwork() time: "+(GetClock().Since(timestamp)-*update).String())
+	}
+}
+
+//// Pinned ////##########################################################################
+
+func UpdatePinned(app *tview.Application, box *tview.TextView, showBorder bool) {
+	var boxText string
+
+	box.SetDynamicColors(true)
+	box.SetBorder(showBorder).SetTitle(Title(LblPinned))
+	logger().Info("Starting `UpdatePinned()` UI goroutine ...")
+
+	for {
+		timestamp := GetClock().Now()
+
+		boxText = " " + FormatPinnedMetrics()
+
+		sleepWithTimestampDelta(timestamp, false)
+		app.QueueUpdateDraw(func() {
+			box.SetText(boxText)
+		})
+		logger().Log(context.Background(), LevelPerf,
+			"UpdatePinned() time: "+(GetClock().Since(timestamp)-*update).String())
 	}
 }
 
@@ -461,17 +614,39 @@ func UpdateNetwork(app *tview.Application, box *tview.TextView, showBorder bool)
 
 func UpdateProcesses(app *tview.Application, box *tview.Table, showBorder bool) {
 
-	box.SetBorder(showBorder).SetTitle(LblProc)
-	slog.Info("Starting `UpdateProcesses()` UI goroutine ...")
+	box.SetBorder(showBorder).SetTitle(Title(LblProc))
+	logger().Info("Starting `UpdateProcesses()` UI goroutine ...")
 
+	var height int
 	for {
-		//timestamp := time.Now()
-		// TODO: Get process info here then pass it into the app.QueueUpdateDraw()
-		// 	before sleeping
+		timestamp := GetClock().Now()
+		_, height, _ = getInnerBoxSize(box.Box, 0, height)
 
-		time.Sleep(*update)
-		app.QueueUpdate(func() {
+		// one row is the header
+		procs, _ := GetTopProcesses(max(height-1, 1), SortByCPU)
+		/// END DATA FETCH
 
+		sleepWithTimestampDelta(timestamp, false)
+		app.QueueUpdateDraw(func() {
+			box.Clear()
+			for col, header := range []MessageID{MsgProcPID, MsgProcUser, MsgProcCPU,
+				MsgProcMemory, MsgProcName} {
+				box.SetCell(0, col, tview.NewTableCell(T(header)).
+					SetTextColor(tcell.ColorYellow).SetSelectable(false))
+			}
+			for i, p := range procs {
+				row := i + 1
+				box.SetCell(row, 0, tview.NewTableCell(strconv.Itoa(int(p.PID))).
+					SetAlign(tview.AlignRight))
+				box.SetCell(row, 1, tview.NewTableCell(p.User).SetMaxWidth(12))
+				box.SetCell(row, 2, tview.NewTableCell(
+					strconv.FormatFloat(p.CPUPercent, 'f', 1, 64)).SetAlign(tview.AlignRight))
+				box.SetCell(row, 3, tview.NewTableCell(
+					strconv.FormatFloat(p.MemoryPercent, 'f', 1, 64)).SetAlign(tview.AlignRight))
+				box.SetCell(row, 4, tview.NewTableCell(p.Name).SetExpansion(1))
+			}
 		})
+		logger().Log(context.Background(), LevelPerf,
+			"UpdateProcesses() time: "+(GetClock().Since(timestamp)-*update).String())
 	}
 }
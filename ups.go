@@ -0,0 +1,286 @@
+package gtm
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// UPS_UPDATE_INTERVAL is how often the UPS daemons are asked for the UPS state
+	UPS_UPDATE_INTERVAL = 5 * time.Second
+	// UPS_TIMEOUT limits how long a UPS daemon may take to answer
+	UPS_TIMEOUT = 3 * time.Second
+	// APCUPSD_PORT and NUT_PORT are the default ports of the apcupsd network information
+	// server and of NUT's upsd
+	APCUPSD_PORT = "3551"
+	NUT_PORT     = "3493"
+)
+
+// UPSStatus is where a UPS gets its power from
+type UPSStatus string
+
+const (
+	UPSOnline     UPSStatus = "online"
+	UPSOnBattery  UPSStatus = "on_battery"
+	UPSLowBattery UPSStatus = "low_battery"
+	UPSUnknown    UPSStatus = "unknown"
+)
+
+// UPSStats is the state of a UPS, as reported by apcupsd or NUT. Address is the
+// configured address it was read from
+type UPSStats struct {
+	Address          string        `json:"address"`
+	Name             string        `json:"name"`
+	Model            string        `json:"model"`
+	Status           UPSStatus     `json:"status"`
+	LineVoltage      float64       `json:"line_voltage"`
+	LoadPercent      float64       `json:"load_percent"`
+	BatteryPercent   float64       `json:"battery_percent"`
+	RuntimeRemaining time.Duration `json:"runtime_remaining"`
+	// Error is set when the UPS could not be read. Every other field except Address is
+	// zero when Error is set
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	upsStats     []UPSStats
+	lastFetchUPS time.Time
+	upsMut       sync.Mutex
+)
+
+// GetUPSStats reads every UPS in UPS_ADDRS, ie.
+//
+//	UPS_ADDRS=apcupsd://localhost:3551,nut://myups@nas.lan:3493
+//
+// NUT addresses name the UPS like upsc does. It's empty when no UPS is configured. A
+// UPS that can't be read is returned with Error set, and its error is joined with the
+// others
+func GetUPSStats() ([]UPSStats, error) {
+	upsMut.Lock()
+	defer upsMut.Unlock()
+
+	if GetClock().Since(lastFetchUPS) < UPS_UPDATE_INTERVAL && upsStats != nil {
+		return upsStats, nil
+	}
+	if !IsCollectorEnabled(CollectorUPS) {
+		return nil, ErrCollectorDisabled
+	}
+	lastFetchUPS = GetClock().Now()
+
+	result := make([]UPSStats, 0, len(Cfg.UPSAddrs))
+	var errs []error
+	for _, addr := range Cfg.UPSAddrs {
+		stats, err := readUPS(addr)
+		if err != nil {
+			collectorError(CollectorUPS, "Failed to retrieve the UPS at "+addr+"!", err)
+			errs = append(errs, err)
+			stats = UPSStats{Address: addr, Status: UPSUnknown, Error: err.Error()}
+		}
+		result = append(result, stats)
+	}
+	upsStats = result
+	return upsStats, errors.Join(errs...)
+}
+
+func readUPS(addr string) (UPSStats, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return UPSStats{}, err
+	}
+	host := u.Host
+	var stats UPSStats
+	switch u.Scheme {
+	case "apcupsd":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), APCUPSD_PORT)
+		}
+		stats, err = readApcupsd(host)
+	case "nut":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), NUT_PORT)
+		}
+		stats, err = readNUT(host, u.User.Username())
+	default:
+		return UPSStats{}, errors.New("unknown UPS protocol: " + u.Scheme +
+			" (expected apcupsd:// or nut://)")
+	}
+	stats.Address = addr
+	stats.LineVoltage = RoundStat(stats.LineVoltage)
+	stats.LoadPercent = RoundStat(stats.LoadPercent)
+	stats.BatteryPercent = RoundStat(stats.BatteryPercent)
+	return stats, err
+}
+
+// readApcupsd sends the "status" command to the apcupsd network information server.
+// Every message is prefixed with its length as a 16 bit big endian integer, and the
+// answer ends with an empty message. Each answer line is "KEY : value unit"
+func readApcupsd(host string) (UPSStats, error) {
+	conn, err := net.DialTimeout("tcp", host, UPS_TIMEOUT)
+	if err != nil {
+		return UPSStats{}, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(UPS_TIMEOUT))
+
+	command := []byte("status")
+	if err = binary.Write(conn, binary.BigEndian, uint16(len(command))); err != nil {
+		return UPSStats{}, err
+	}
+	if _, err = conn.Write(command); err != nil {
+		return UPSStats{}, err
+	}
+
+	values := map[string]string{}
+	reader := bufio.NewReader(conn)
+	for {
+		var length uint16
+		if err = binary.Read(reader, binary.BigEndian, &length); err != nil {
+			return UPSStats{}, err
+		}
+		if length == 0 {
+			break
+		}
+		line := make([]byte, length)
+		if _, err = io.ReadFull(reader, line); err != nil {
+			return UPSStats{}, err
+		}
+		if key, value, ok := strings.Cut(string(line), ":"); ok {
+			values[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return parseApcupsdStatus(values), nil
+}
+
+func parseApcupsdStatus(values map[string]string) UPSStats {
+	// values carry their unit, ie. "230.0 Volts"
+	number := func(key string) float64 {
+		fields := strings.Fields(values[key])
+		if len(fields) == 0 {
+			return 0
+		}
+		value, _ := strconv.ParseFloat(fields[0], 64)
+		return value
+	}
+	stats := UPSStats{
+		Name:             values["UPSNAME"],
+		Model:            values["MODEL"],
+		Status:           UPSUnknown,
+		LineVoltage:      number("LINEV"),
+		LoadPercent:      number("LOADPCT"),
+		BatteryPercent:   number("BCHARGE"),
+		RuntimeRemaining: time.Duration(number("TIMELEFT") * float64(time.Minute)),
+	}
+	status := strings.Fields(values["STATUS"])
+	switch {
+	case slices.Contains(status, "LOWBATT"):
+		stats.Status = UPSLowBattery
+	case slices.Contains(status, "ONBATT"):
+		stats.Status = UPSOnBattery
+	case slices.Contains(status, "ONLINE"):
+		stats.Status = UPSOnline
+	}
+	return stats
+}
+
+// readNUT asks upsd for every variable of a UPS. Without a UPS name, the first UPS
+// upsd knows is read
+func readNUT(host string, name string) (UPSStats, error) {
+	conn, err := net.DialTimeout("tcp", host, UPS_TIMEOUT)
+	if err != nil {
+		return UPSStats{}, err
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(UPS_TIMEOUT))
+	reader := bufio.NewReader(conn)
+
+	if name == "" {
+		lines, err := nutList(conn, reader, "UPS")
+		if err != nil {
+			return UPSStats{}, err
+		}
+		if len(lines) == 0 {
+			return UPSStats{}, errors.New("upsd has no UPS")
+		}
+		// UPS <name> "<description>"
+		fields := strings.Fields(lines[0])
+		if len(fields) < 2 || fields[0] != "UPS" {
+			return UPSStats{}, errors.New("unexpected UPS list line from upsd: " +
+				strconv.Quote(lines[0]))
+		}
+		name = fields[1]
+	}
+	lines, err := nutList(conn, reader, "VAR "+name)
+	if err != nil {
+		return UPSStats{}, err
+	}
+	values := map[string]string{}
+	for _, line := range lines {
+		// VAR <ups> <variable> "<value>"
+		fields := strings.SplitN(line, " ", 4)
+		if len(fields) == 4 {
+			values[fields[2]] = strings.Trim(fields[3], `"`)
+		}
+	}
+	stats := parseNUTVariables(values)
+	stats.Name = name
+	return stats, nil
+}
+
+// nutList sends "LIST <query>" and returns the lines between BEGIN LIST and END LIST
+func nutList(conn net.Conn, reader *bufio.Reader, query string) ([]string, error) {
+	if _, err := conn.Write([]byte("LIST " + query + "\n")); err != nil {
+		return nil, err
+	}
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "ERR "):
+			return nil, errors.New("upsd: " + strings.TrimPrefix(line, "ERR "))
+		case strings.HasPrefix(line, "BEGIN LIST"):
+		case strings.HasPrefix(line, "END LIST"):
+			return lines, nil
+		default:
+			lines = append(lines, line)
+		}
+	}
+}
+
+func parseNUTVariables(values map[string]string) UPSStats {
+	number := func(key string) float64 {
+		value, _ := strconv.ParseFloat(values[key], 64)
+		return value
+	}
+	stats := UPSStats{
+		Model:            strings.TrimSpace(values["ups.mfr"] + " " + values["ups.model"]),
+		Status:           UPSUnknown,
+		LineVoltage:      number("input.voltage"),
+		LoadPercent:      number("ups.load"),
+		BatteryPercent:   number("battery.charge"),
+		RuntimeRemaining: time.Duration(number("battery.runtime")) * time.Second,
+	}
+	// ups.status holds flags like "OL CHRG" or "OB LB"
+	status := strings.Fields(values["ups.status"])
+	switch {
+	case slices.Contains(status, "LB"):
+		stats.Status = UPSLowBattery
+	case slices.Contains(status, "OB"):
+		stats.Status = UPSOnBattery
+	case slices.Contains(status, "OL"):
+		stats.Status = UPSOnline
+	}
+	return stats
+}
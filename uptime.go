@@ -0,0 +1,71 @@
+package gtm
+
+import (
+	"github.com/shirou/gopsutil/v4/host"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	bootTime    time.Time
+	bootTimeMut sync.Mutex
+)
+
+// GetBootTime returns when the host booted. It's read once, so it's cheap to call every
+// frame, unlike GetHostInfo
+func GetBootTime() (time.Time, error) {
+	bootTimeMut.Lock()
+	defer bootTimeMut.Unlock()
+	if !bootTime.IsZero() {
+		return bootTime, nil
+	}
+
+	seconds, err := host.BootTime()
+	if err != nil {
+		collectorError(CollectorHost, "Failed to retrieve host.BootTime()!", err)
+		native, nativeErr := nativeHostInfo()
+		if nativeErr != nil {
+			return time.Time{}, err
+		}
+		seconds = native.BootTime
+	}
+	bootTime = time.Unix(int64(seconds), 0)
+	return bootTime, nil
+}
+
+// GetUptime returns how long ago the host booted, see GetBootTime
+func GetUptime() (time.Duration, error) {
+	boot, err := GetBootTime()
+	if err != nil {
+		return 0, err
+	}
+	return GetClock().Since(boot).Truncate(time.Second), nil
+}
+
+// FormatUptime formats an uptime like `uptime` does, ie. "3 days, 4:05" or "12 min"
+func FormatUptime(uptime time.Duration) string {
+	days := int(uptime / (24 * time.Hour))
+	hours := int(uptime % (24 * time.Hour) / time.Hour)
+	minutes := int(uptime % time.Hour / time.Minute)
+
+	var result string
+	switch days {
+	case 0:
+	case 1:
+		result = "1 day, "
+	default:
+		result = strconv.Itoa(days) + " days, "
+	}
+	if hours == 0 {
+		return result + strconv.Itoa(minutes) + " min"
+	}
+	return result + strconv.Itoa(hours) + ":" + twoDigits(minutes)
+}
+
+func twoDigits(n int) string {
+	if n < 10 {
+		return "0" + strconv.Itoa(n)
+	}
+	return strconv.Itoa(n)
+}
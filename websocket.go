@@ -0,0 +1,380 @@
+package gtm
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/shirou/gopsutil/v4/mem"
+	"io"
+	"maps"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// WEBSOCKET_PATH is where the REST API server streams the live updates, see
+	//	WebSocketHandler
+	WEBSOCKET_PATH = "/ws"
+	// websocketGUID is appended to the key of the client to accept the handshake, see
+	//	RFC 6455
+	websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+	// websocketTimeout limits writing a message, so a client that stopped reading is
+	//	disconnected instead of holding the stream
+	websocketTimeout = 10 * time.Second
+	// websocketMaxFrame is the largest frame read from a client. Clients only have to
+	//	send control frames, which are 125 bytes at most
+	websocketMaxFrame = 4096
+)
+
+// The opcodes of the WebSocket frames gtm reads or writes
+const (
+	websocketText  = 0x1
+	websocketClose = 0x8
+	websocketPing  = 0x9
+	websocketPong  = 0xA
+)
+
+// websocketGoingAway is the close code sent when the Monitor is shut down
+const websocketGoingAway = 1001
+
+// WebSocketHandler streams the stats of the Monitor to a browser dashboard over a
+// WebSocket, so it doesn't have to poll the REST API. The first message is a whole
+// Snapshot, then every time a collector fetches (see Subscribe) a message carries the
+// timestamp and only the fields of the Snapshot that changed, ie.
+//
+//	{"timestamp": "2024-05-01T12:00:00.5Z", "cpu": {"usage_percent": 12.5}}
+//
+// so the dashboard merges each message into its snapshot. When the client falls
+// behind, the updates of a collector are coalesced into its latest one, so a change is
+// never missed. Processes are sent every PROCS_UPDATE_INTERVAL, and REDACTION_PROFILE
+// is applied like the REST API. Browsers can only connect from the same origin as the
+// server or from WEBSOCKET_ORIGINS in the config. The stream ends when the client
+// closes it or the Monitor is shut down
+func (m *Monitor) WebSocketHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if !headerHasToken(r.Header, "Connection", "upgrade") ||
+			!headerHasToken(r.Header, "Upgrade", "websocket") {
+			w.Header().Set("Upgrade", "websocket")
+			http.Error(w, "websocket upgrade required", http.StatusUpgradeRequired)
+			return
+		}
+		if r.Header.Get("Sec-WebSocket-Version") != "13" {
+			w.Header().Set("Sec-WebSocket-Version", "13")
+			http.Error(w, "unsupported websocket version", http.StatusUpgradeRequired)
+			return
+		}
+		key := r.Header.Get("Sec-WebSocket-Key")
+		if key == "" {
+			http.Error(w, "missing Sec-WebSocket-Key", http.StatusBadRequest)
+			return
+		}
+		if !websocketOriginAllowed(r) {
+			http.Error(w, "origin not allowed", http.StatusForbidden)
+			return
+		}
+
+		conn, rw, err := http.NewResponseController(w).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		accept := sha1.Sum([]byte(key + websocketGUID))
+		_, err = conn.Write([]byte("HTTP/1.1 101 Switching Protocols\r\n" +
+			"Upgrade: websocket\r\nConnection: Upgrade\r\n" +
+			"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(accept[:]) +
+			"\r\n\r\n"))
+		if err != nil {
+			return
+		}
+		ws := &websocketConn{conn: conn, reader: rw.Reader}
+		if err := m.streamWebSocket(ws); err != nil {
+			m.log().Debug("WebSocket stream to " + r.RemoteAddr + " ended! " +
+				err.Error())
+		}
+	})
+}
+
+// WebSocketHandler streams the stats of the default Monitor, see
+// Monitor.WebSocketHandler
+func WebSocketHandler() http.Handler { return defaultMonitor.WebSocketHandler() }
+
+// streamWebSocket sends the snapshot, then its changes, until the client leaves
+func (m *Monitor) streamWebSocket(ws *websocketConn) error {
+	sub, unsubscribe := m.subscribe()
+	defer unsubscribe()
+	left := make(chan error, 1)
+	go func() { left <- ws.readLoop() }()
+
+	var (
+		// sent holds the JSON of every field as the client last received it
+		sent    = map[string]json.RawMessage{}
+		plugins map[string][]PluginMetric
+	)
+	sendSnapshot := func() error {
+		snapshot := m.Snapshot()
+		plugins = maps.Clone(snapshot.Plugins)
+		data, err := json.Marshal(snapshot)
+		if err != nil {
+			return err
+		}
+		var fields map[string]json.RawMessage
+		if err := json.Unmarshal(data, &fields); err != nil {
+			return err
+		}
+		clear(sent)
+		return ws.sendChanges(sent, fields)
+	}
+	if err := sendSnapshot(); err != nil {
+		return err
+	}
+
+	processes := GetClock().After(PROCS_UPDATE_INTERVAL)
+	for {
+		var (
+			timestamp = GetClock().Now()
+			changes   = map[string]any{}
+		)
+		select {
+		case err := <-left:
+			return err
+		case <-m.done():
+			return ws.writeClose(websocketGoingAway)
+		case <-processes:
+			processes = GetClock().After(PROCS_UPDATE_INTERVAL)
+			if stats, err := ExportProcesses(); stats != nil || err == nil {
+				changes["processes"] = stats
+			}
+		case update, ok := <-sub.C:
+			if !ok {
+				return ws.writeClose(websocketGoingAway)
+			}
+			if update.Stats == nil {
+				continue
+			}
+			timestamp = update.Timestamp
+			if stats, ok := update.Stats.([]PluginMetric); ok {
+				if plugins == nil {
+					plugins = map[string][]PluginMetric{}
+				}
+				plugins[string(update.Collector)] = stats
+				changes["plugins"] = plugins
+			} else if name, value := m.snapshotField(update.Stats); name != "" {
+				changes[name] = value
+			}
+		}
+
+		fields := map[string]json.RawMessage{}
+		for name, value := range changes {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			fields[name] = data
+		}
+		data, err := json.Marshal(timestamp)
+		if err != nil {
+			return err
+		}
+		fields["timestamp"] = data
+		if err := ws.sendChanges(sent, fields); err != nil {
+			return err
+		}
+	}
+}
+
+// snapshotField converts the stats of an Update to the field of the Snapshot they go
+// in, with the host info and the network interfaces filtered like Snapshot
+func (m *Monitor) snapshotField(stats any) (name string, value any) {
+	switch stats := stats.(type) {
+	case CPUStats:
+		return "cpu", stats
+	case *mem.VirtualMemoryStat:
+		return "memory", newMemoryStats(stats)
+	case []DiskStats:
+		return "disks", stats
+	case []NetStats:
+		filter := m.netFilter()
+		network := make([]NetStats, 0, len(stats))
+		for _, iface := range stats {
+			if filter.Match(iface.Name, iface.Alias) {
+				network = append(network, iface)
+			}
+		}
+		return "network", network
+	case []GPUStats:
+		return "gpu", stats
+	case HostInfo:
+		return "host", exportHostInfo(stats)
+	}
+	return "", nil
+}
+
+//// WebSocket ////#######################################################################
+
+// websocketConn is the server side of a WebSocket, see RFC 6455. gtm only sends text
+// messages, and only reads the control frames of the client
+type websocketConn struct {
+	conn   net.Conn
+	reader *bufio.Reader
+	mut    sync.Mutex
+}
+
+// sendChanges sends the fields that differ from what the client last received, and
+// records them in `sent`. Nothing is sent when only the timestamp changed
+func (c *websocketConn) sendChanges(sent map[string]json.RawMessage,
+	fields map[string]json.RawMessage) error {
+	changed := false
+	for name, value := range fields {
+		if name != "timestamp" && bytes.Equal(sent[name], value) {
+			delete(fields, name)
+		} else if name != "timestamp" {
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	for name, value := range fields {
+		sent[name] = value
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return err
+	}
+	if data, err = GetConfiguredRedactionProfile().RedactJSON(data); err != nil {
+		return err
+	}
+	return c.writeFrame(websocketText, data)
+}
+
+// readLoop answers the pings of the client until it closes the connection
+func (c *websocketConn) readLoop() error {
+	for {
+		opcode, payload, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case websocketClose:
+			// echo the close code of the client, then hang up
+			return c.writeFrame(websocketClose, payload[:min(len(payload), 2)])
+		case websocketPing:
+			if err := c.writeFrame(websocketPong, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readFrame reads a frame of the client, which is always masked
+func (c *websocketConn) readFrame() (opcode byte, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(c.reader, header[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode = header[0] & 0x0F
+	if header[1]&0x80 == 0 {
+		return 0, nil, errors.New("unmasked websocket frame from the client")
+	}
+
+	length := uint64(header[1] & 0x7F)
+	switch length {
+	case 126:
+		var extended [2]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(extended[:]))
+	case 127:
+		var extended [8]byte
+		if _, err = io.ReadFull(c.reader, extended[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(extended[:])
+	}
+	if length > websocketMaxFrame {
+		return 0, nil, errors.New("websocket frame from the client is too large")
+	}
+
+	var mask [4]byte
+	if _, err = io.ReadFull(c.reader, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(c.reader, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
+
+// writeFrame sends a whole message in a single frame. Frames of the server aren't
+// masked
+func (c *websocketConn) writeFrame(opcode byte, payload []byte) error {
+	frame := []byte{0x80 | opcode}
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		frame = binary.BigEndian.AppendUint16(append(frame, 126), uint16(length))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, 127), uint64(length))
+	}
+	frame = append(frame, payload...)
+
+	c.mut.Lock()
+	defer c.mut.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(websocketTimeout))
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// writeClose tells the client the stream ends, with a close code of RFC 6455
+func (c *websocketConn) writeClose(code uint16) error {
+	return c.writeFrame(websocketClose, binary.BigEndian.AppendUint16(nil, code))
+}
+
+// headerHasToken reports whether a comma separated header holds `token`, ignoring case
+func headerHasToken(header http.Header, name string, token string) bool {
+	for _, value := range header.Values(name) {
+		for _, item := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(item), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// websocketOriginAllowed keeps other websites from reading the stats through the
+// browser of a visitor. Clients that aren't browsers send no Origin
+func websocketOriginAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	for _, allowed := range Cfg.WebSocketOrigins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, r.Host)
+}
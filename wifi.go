@@ -0,0 +1,105 @@
+package gtm
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WIFI_UPDATE_INTERVAL is slower than the byte counters, since every backend runs an
+// external command (iw, netsh or airport)
+const WIFI_UPDATE_INTERVAL = 5 * time.Second
+
+// WiFiStats is the state of a wireless interface. SSID is empty when the interface
+// isn't connected. SignalDBm and SignalPercent are both filled in, converting between
+// them when the backend only reports one
+type WiFiStats struct {
+	Interface     string  `json:"interface"`
+	Alias         string  `json:"alias,omitempty"`
+	SSID          string  `json:"ssid"`
+	Band          string  `json:"band"`
+	Channel       int     `json:"channel"`
+	SignalDBm     int     `json:"signal_dbm"`
+	SignalPercent int     `json:"signal_percent"`
+	LinkRateMbps  float64 `json:"link_rate_mbps"`
+}
+
+var (
+	wifiStats     []WiFiStats
+	lastFetchWiFi time.Time
+	// wifiErr is returned until the next fetch, so a backend that fails isn't run again
+	//	before WIFI_UPDATE_INTERVAL
+	wifiErr error
+	wifiMut sync.Mutex
+)
+
+// GetWiFiStats returns the state of every wireless interface. It returns an empty list
+// on machines without WiFi
+func GetWiFiStats() ([]WiFiStats, error) {
+	wifiMut.Lock()
+	defer wifiMut.Unlock()
+
+	if !IsCollectorEnabled(CollectorWiFi) {
+		return nil, ErrCollectorDisabled
+	}
+	if !lastFetchWiFi.IsZero() && GetClock().Since(lastFetchWiFi) < WIFI_UPDATE_INTERVAL {
+		return wifiStats, wifiErr
+	}
+
+	stats, err := getWiFiStats()
+	lastFetchWiFi = GetClock().Now()
+	wifiErr = err
+	if err != nil {
+		collectorError(CollectorWiFi, "Failed to retrieve WiFi stats!", err)
+		return wifiStats, err
+	}
+	for i := range stats {
+		stats[i].Alias = netAlias(stats[i].Interface)
+		stats[i].fillSignal()
+	}
+	wifiStats = stats
+	return wifiStats, nil
+}
+
+// fillSignal converts between dBm and percent with the same linear mapping windows uses
+// (-100 dBm = 0 %, -50 dBm = 100 %)
+func (w *WiFiStats) fillSignal() {
+	switch {
+	case w.SignalDBm != 0 && w.SignalPercent == 0:
+		w.SignalPercent = min(max(2*(w.SignalDBm+100), 0), 100)
+	case w.SignalPercent != 0 && w.SignalDBm == 0:
+		w.SignalDBm = w.SignalPercent/2 - 100
+	}
+}
+
+// wifiBand returns the band of a channel. Channels 1-14 are 2.4 GHz, but 6 GHz channels
+// reuse low numbers too, so a frequency (MHz) is used instead when it is known
+func wifiBand(channel int, frequencyMHz int) string {
+	switch {
+	case frequencyMHz >= 5925:
+		return "6 GHz"
+	case frequencyMHz >= 4900:
+		return "5 GHz"
+	case frequencyMHz >= 2400:
+		return "2.4 GHz"
+	case channel >= 1 && channel <= 14:
+		return "2.4 GHz"
+	case channel >= 32:
+		return "5 GHz"
+	default:
+		return ""
+	}
+}
+
+// parseLeadingNumber parses the number at the start of `value`, ie. "-52 dBm" -> -52
+func parseLeadingNumber(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	end := 0
+	for end < len(value) && (value[end] == '-' || value[end] == '.' ||
+		(value[end] >= '0' && value[end] <= '9')) {
+		end++
+	}
+	number, err := strconv.ParseFloat(value[:end], 64)
+	return number, err == nil
+}
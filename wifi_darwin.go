@@ -0,0 +1,60 @@
+package gtm
+
+import (
+	"strings"
+)
+
+// AIRPORT_PATH is the private framework tool that reports the current WiFi link. It is
+// deprecated (and stubbed out since macOS 14.4), in which case no interfaces are found
+const AIRPORT_PATH = "/System/Library/PrivateFrameworks/Apple80211.framework/Versions/" +
+	"Current/Resources/airport"
+
+func getWiFiStats() ([]WiFiStats, error) {
+	out, err := runCommand(AIRPORT_PATH, "-I")
+	if err != nil {
+		return []WiFiStats{}, nil
+	}
+	stats := []WiFiStats{}
+	if w, ok := parseAirportInfo(string(out)); ok {
+		stats = append(stats, w)
+	}
+	return stats, nil
+}
+
+// parseAirportInfo parses `airport -I`:
+//
+//	agrCtlRSSI: -52
+//	lastTxRate: 867
+//	SSID: HomeNetwork
+//	channel: 36,80
+func parseAirportInfo(output string) (WiFiStats, bool) {
+	// airport only reports the primary interface, which is always en0 on macs with WiFi
+	w := WiFiStats{Interface: "en0"}
+	found := false
+	for _, line := range strings.Split(output, "\n") {
+		field, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch field {
+		case "agrCtlRSSI":
+			if signal, ok := parseLeadingNumber(value); ok {
+				w.SignalDBm = int(signal)
+				found = true
+			}
+		case "lastTxRate":
+			if rate, ok := parseLeadingNumber(value); ok {
+				w.LinkRateMbps = rate
+			}
+		case "SSID":
+			w.SSID = value
+		case "channel":
+			if channel, ok := parseLeadingNumber(value); ok {
+				w.Channel = int(channel)
+				w.Band = wifiBand(w.Channel, 0)
+			}
+		}
+	}
+	return w, found
+}
@@ -0,0 +1,92 @@
+package gtm
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// getWiFiStats reads wireless interfaces from `iw dev`, then the signal and bitrate of
+// each connected one from `iw dev <interface> link`
+func getWiFiStats() ([]WiFiStats, error) {
+	out, err := runCommand("iw", "dev")
+	if errors.Is(err, exec.ErrNotFound) {
+		// without iw there's no way to tell, so treat it as a machine without WiFi
+		return []WiFiStats{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	stats := parseIwDev(string(out))
+	for i := range stats {
+		link, err := runCommand("iw", "dev", stats[i].Interface, "link")
+		if err != nil {
+			continue
+		}
+		parseIwLink(string(link), &stats[i])
+	}
+	return stats, nil
+}
+
+// parseIwDev parses the interface list of `iw dev`:
+//
+//	Interface wlan0
+//		ssid HomeNetwork
+//		channel 36 (5180 MHz), width: 80 MHz, center1: 5210 MHz
+func parseIwDev(output string) []WiFiStats {
+	stats := []WiFiStats{}
+	for _, line := range strings.Split(output, "\n") {
+		field, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch field {
+		case "Interface":
+			stats = append(stats, WiFiStats{Interface: value})
+		case "ssid":
+			if len(stats) > 0 {
+				stats[len(stats)-1].SSID = value
+			}
+		case "channel":
+			if len(stats) == 0 {
+				continue
+			}
+			w := &stats[len(stats)-1]
+			if channel, ok := parseLeadingNumber(value); ok {
+				w.Channel = int(channel)
+			}
+			frequency := 0
+			if _, f, ok := strings.Cut(value, "("); ok {
+				if mhz, ok := parseLeadingNumber(f); ok {
+					frequency = int(mhz)
+				}
+			}
+			w.Band = wifiBand(w.Channel, frequency)
+		}
+	}
+	return stats
+}
+
+// parseIwLink parses `iw dev <interface> link`:
+//
+//	Connected to 11:22:33:44:55:66 (on wlan0)
+//		SSID: HomeNetwork
+//		signal: -52 dBm
+//		tx bitrate: 866.7 MBit/s VHT-MCS 9 80MHz short GI VHT-NSS 2
+func parseIwLink(output string, w *WiFiStats) {
+	for _, line := range strings.Split(output, "\n") {
+		field, value, ok := strings.Cut(strings.TrimSpace(line), ":")
+		if !ok {
+			continue
+		}
+		switch field {
+		case "SSID":
+			w.SSID = strings.TrimSpace(value)
+		case "signal":
+			if signal, ok := parseLeadingNumber(value); ok {
+				w.SignalDBm = int(signal)
+			}
+		case "tx bitrate":
+			if rate, ok := parseLeadingNumber(value); ok {
+				w.LinkRateMbps = rate
+			}
+		}
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !linux && !windows && !darwin
+
+package gtm
+
+// getWiFiStats isn't implemented on this platform
+func getWiFiStats() ([]WiFiStats, error) {
+	return []WiFiStats{}, nil
+}
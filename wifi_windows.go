@@ -0,0 +1,68 @@
+package gtm
+
+import (
+	"strings"
+)
+
+// getWiFiStats parses `netsh wlan show interfaces`. The WLAN AutoConfig service isn't
+// running on machines without WiFi, which makes netsh fail, so that's treated as no
+// interfaces rather than an error
+func getWiFiStats() ([]WiFiStats, error) {
+	out, err := runCommand("netsh", "wlan", "show", "interfaces")
+	if err != nil {
+		return []WiFiStats{}, nil
+	}
+	return parseNetshInterfaces(string(out)), nil
+}
+
+// parseNetshInterfaces parses the (english) output of `netsh wlan show interfaces`:
+//
+//	Name                   : Wi-Fi
+//	SSID                   : HomeNetwork
+//	Band                   : 5 GHz
+//	Channel                : 36
+//	Receive rate (Mbps)    : 866.7
+//	Transmit rate (Mbps)   : 866.7
+//	Signal                 : 92%
+func parseNetshInterfaces(output string) []WiFiStats {
+	stats := []WiFiStats{}
+	for _, line := range strings.Split(output, "\n") {
+		field, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		field, value = strings.TrimSpace(field), strings.TrimSpace(value)
+		if field == "Name" {
+			stats = append(stats, WiFiStats{Interface: value})
+			continue
+		}
+		if len(stats) == 0 {
+			continue
+		}
+		w := &stats[len(stats)-1]
+		switch field {
+		case "SSID":
+			w.SSID = value
+		case "Band":
+			w.Band = value
+		case "Channel":
+			if channel, ok := parseLeadingNumber(value); ok {
+				w.Channel = int(channel)
+			}
+		case "Transmit rate (Mbps)":
+			if rate, ok := parseLeadingNumber(value); ok {
+				w.LinkRateMbps = rate
+			}
+		case "Signal":
+			if signal, ok := parseLeadingNumber(value); ok {
+				w.SignalPercent = int(signal)
+			}
+		}
+	}
+	for i := range stats {
+		if stats[i].Band == "" {
+			stats[i].Band = wifiBand(stats[i].Channel, 0)
+		}
+	}
+	return stats
+}